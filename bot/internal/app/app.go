@@ -1,13 +1,13 @@
 package app
 
 import (
+	bridgestate "bridge/state"
 	"context"
+	corepkg "core"
 	"log"
 	"sync"
 
 	"bot/internal/app/commands"
-	"bot/internal/batchstate"
-	"bot/internal/bridgeclient"
 	"bot/internal/config"
 	"bot/internal/erp"
 	"bot/internal/telegram"
@@ -17,9 +17,10 @@ type App struct {
 	cfg                      config.Config
 	tg                       *telegram.Client
 	erp                      *erp.Client
-	qtyReader                *bridgeclient.Client
-	batchState               *batchstate.Store
+	bridgeStore              *bridgestate.Store
 	epcHistory               *EPCHistory
+	epcRegistry              *corepkg.EPCRegistry
+	metrics                  *appMetrics
 	log                      *log.Logger
 	logRun                   *log.Logger
 	logBatch                 *log.Logger
@@ -35,17 +36,27 @@ type App struct {
 	batchMu     sync.Mutex
 	batchNextID int64
 	batchByChat map[int64]batchSession
+
+	countMu     sync.Mutex
+	countByChat map[int64]countState
+
+	stationMu     sync.Mutex
+	stations      map[string]*stationLink
+	stationByChat map[int64]string
 }
 
 type batchSession struct {
-	id     int64
-	cancel context.CancelFunc
+	id      int64
+	station string
+	cancel  context.CancelFunc
 }
 
 type SelectedContext struct {
 	ItemCode  string
 	ItemName  string
 	Warehouse string
+	// GTIN batch boshlanishida ERP barcode'laridan (bo'sh = item GTIN'siz).
+	GTIN string
 }
 
 type itemChoice struct {
@@ -69,12 +80,13 @@ func New(cfg config.Config, logger *log.Logger, runLogger *log.Logger, batchLogg
 	if cleanupLogger == nil {
 		cleanupLogger = logger
 	}
+	bridgeStore := bridgestate.NewWithOptions(cfg.BridgeStateFile, bridgestate.Options{JournalDir: cfg.BridgeJournalDir, Backend: cfg.BridgeBackend})
 	return &App{
 		cfg:                      cfg,
 		tg:                       telegram.New(cfg.TelegramBotToken),
 		erp:                      erp.New(cfg.ERPURL, cfg.ERPAPIKey, cfg.ERPAPISecret),
-		qtyReader:                bridgeclient.New(cfg.BridgeStateFile),
-		batchState:               batchstate.New(cfg.BridgeStateFile),
+		bridgeStore:              bridgeStore,
+		metrics:                  newAppMetrics(),
 		epcHistory:               NewEPCHistory(),
 		log:                      logger,
 		logRun:                   runLogger,
@@ -88,6 +100,9 @@ func New(cfg config.Config, logger *log.Logger, runLogger *log.Logger, batchLogg
 		itemChoiceByChat:         make(map[int64]itemChoice),
 		batchChangeMsgByChat:     make(map[int64]int64),
 		batchByChat:              make(map[int64]batchSession),
+		countByChat:              make(map[int64]countState),
+		stations:                 make(map[string]*stationLink),
+		stationByChat:            make(map[int64]string),
 	}
 }
 
@@ -2,7 +2,7 @@ package app
 
 import "context"
 
-func (a *App) startBatchSession(parent context.Context, chatID int64, run func(ctx context.Context)) {
+func (a *App) startBatchSession(parent context.Context, chatID int64, station string, run func(ctx context.Context)) {
 	if chatID == 0 || run == nil {
 		return
 	}
@@ -11,11 +11,14 @@ func (a *App) startBatchSession(parent context.Context, chatID int64, run func(c
 
 	a.batchMu.Lock()
 	a.batchNextID++
-	session := batchSession{id: a.batchNextID, cancel: cancel}
+	session := batchSession{id: a.batchNextID, station: station, cancel: cancel}
 	prev, hasPrev := a.batchByChat[chatID]
 	a.batchByChat[chatID] = session
 	a.batchMu.Unlock()
-	a.syncBatchStateFromSessions(chatID)
+	if hasPrev && prev.station != station {
+		a.syncBatchStateFromSessions(prev.station, chatID)
+	}
+	a.syncBatchStateFromSessions(station, chatID)
 
 	if hasPrev && prev.cancel != nil {
 		prev.cancel()
@@ -29,7 +32,7 @@ func (a *App) startBatchSession(parent context.Context, chatID int64, run func(c
 			delete(a.batchByChat, chatID)
 		}
 		a.batchMu.Unlock()
-		a.syncBatchStateFromSessions(chatID)
+		a.syncBatchStateFromSessions(station, chatID)
 	}(chatID, session.id)
 }
 
@@ -44,7 +47,11 @@ func (a *App) stopBatchSession(chatID int64) bool {
 		delete(a.batchByChat, chatID)
 	}
 	a.batchMu.Unlock()
-	a.syncBatchStateFromSessions(chatID)
+	station := a.stationFor(chatID)
+	if ok {
+		station = s.station
+	}
+	a.syncBatchStateFromSessions(station, chatID)
 
 	if ok && s.cancel != nil {
 		s.cancel()
@@ -53,6 +60,14 @@ func (a *App) stopBatchSession(chatID int64) bool {
 	return false
 }
 
+// sessionStation chat'ning ishlab turgan batch sessiyasi bog'langan stansiya.
+func (a *App) sessionStation(chatID int64) (string, bool) {
+	a.batchMu.Lock()
+	defer a.batchMu.Unlock()
+	s, ok := a.batchByChat[chatID]
+	return s.station, ok
+}
+
 func (a *App) hasBatchSession(chatID int64) bool {
 	if chatID == 0 {
 		return false
@@ -73,7 +88,7 @@ func (a *App) stopAllBatchSessions() {
 	}
 	a.batchByChat = make(map[int64]batchSession)
 	a.batchMu.Unlock()
-	a.setBatchState(false, 0, SelectedContext{})
+	a.resetBatchStates()
 
 	for _, c := range cancels {
 		c()
@@ -1,24 +1,47 @@
 package app
 
-func (a *App) setBatchState(active bool, chatID int64, sel SelectedContext) {
-	if a == nil || a.batchState == nil {
+import corepkg "core"
+
+func (a *App) setBatchState(station string, active bool, chatID int64, sel SelectedContext) {
+	if a == nil || a.bridgeStore == nil {
 		return
 	}
-	if err := a.batchState.Set(active, chatID, sel.ItemCode, sel.ItemName, sel.Warehouse); err != nil {
-		a.logBatch.Printf("batch state write error: %v", err)
+	link := a.station(station)
+	var count corepkg.PieceCounter
+	if active {
+		count = a.counter(chatID)
+	}
+	if err := link.batchState.Set(active, chatID, sel.ItemCode, sel.ItemName, sel.Warehouse, sel.GTIN, count); err != nil {
+		a.logBatch.Printf("batch state write error: station=%s err=%v", link.id, err)
 	}
 }
 
-func (a *App) syncBatchStateFromSessions(chatHint int64) {
+// syncBatchStateFromSessions stansiya batch holatini shu stansiyaga bog'langan
+// sessiyalardan hisoblaydi: kamida bittasi bo'lsa active.
+func (a *App) syncBatchStateFromSessions(station string, chatHint int64) {
 	a.batchMu.Lock()
-	active := len(a.batchByChat) > 0
-	if chatHint == 0 {
-		for chatID := range a.batchByChat {
-			chatHint = chatID
-			break
+	active := false
+	hintActive := false
+	sessions := 0
+	var first int64
+	for chatID, s := range a.batchByChat {
+		if s.station != station {
+			continue
+		}
+		sessions++
+		if !active {
+			first = chatID
+		}
+		active = true
+		if chatID == chatHint {
+			hintActive = true
 		}
 	}
+	if active && !hintActive {
+		chatHint = first
+	}
 	a.batchMu.Unlock()
+	a.metrics.setSessions(station, sessions)
 
 	sel := SelectedContext{}
 	if active && chatHint != 0 {
@@ -26,5 +49,13 @@ func (a *App) syncBatchStateFromSessions(chatHint int64) {
 			sel = got
 		}
 	}
-	a.setBatchState(active, chatHint, sel)
+	a.setBatchState(station, active, chatHint, sel)
+}
+
+// resetBatchStates bot ishga tushganda/to'xtaganda barcha stansiyalarda batch'ni o'chiradi.
+func (a *App) resetBatchStates() {
+	for _, id := range a.knownStations() {
+		a.metrics.setSessions(id, 0)
+		a.setBatchState(id, false, 0, SelectedContext{})
+	}
 }
@@ -1,7 +1,6 @@
 package app
 
 import (
-	bridgestate "bridge/state"
 	"context"
 	"errors"
 	"fmt"
@@ -33,7 +32,7 @@ func (a *App) handleCalibrateCommand(ctx context.Context, chatID int64, text str
 		return a.tg.SendMessage(ctx, chatID, "Batch ishlab turganda calibration mumkin emas. Avval Batch Stop qiling.")
 	}
 
-	device := a.resolveCalibrateDevice(opts.device)
+	device := a.resolveCalibrateDevice(chatID, opts.device)
 	cmds := buildCalibrationCommands(opts.save)
 	if opts.dryRun {
 		lines := []string{
@@ -60,14 +59,13 @@ func (a *App) handleCalibrateCommand(ctx context.Context, chatID int64, text str
 	return a.tg.SendMessage(ctx, chatID, fmt.Sprintf("Calibration tugadi: device=%s time=%s", device, dur))
 }
 
-func (a *App) resolveCalibrateDevice(preferred string) string {
+func (a *App) resolveCalibrateDevice(chatID int64, preferred string) string {
 	if v := strings.TrimSpace(preferred); v != "" {
 		return v
 	}
 
-	bridgePath := strings.TrimSpace(a.cfg.BridgeStateFile)
-	if bridgePath != "" {
-		snap, err := bridgestate.New(bridgePath).Read()
+	if a.bridgeStore != nil {
+		snap, err := a.bridgeStore.Station(a.stationFor(chatID)).Read()
 		if err == nil {
 			if d := strings.TrimSpace(snap.Zebra.DevicePath); d != "" {
 				return d
@@ -2,19 +2,20 @@ package app
 
 import (
 	"context"
+	corepkg "core"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"bot/internal/app/commands"
+	"bot/internal/bridgeclient"
 	"bot/internal/erp"
 	"bot/internal/telegram"
 )
 
 const (
 	epcWaitTimeout      = 6 * time.Second
-	epcWaitGraceTimeout = 1 * time.Second
 	epcWaitPollInterval = 140 * time.Millisecond
 )
 
@@ -130,16 +131,41 @@ func (a *App) handleBatchStartCallback(ctx context.Context, q telegram.CallbackQ
 }
 
 func (a *App) startMaterialIssueBatch(ctx context.Context, chatID int64, sel SelectedContext, statusMessageID int64, note string) int64 {
+	item, err := a.lookupItem(ctx, sel)
+	if err != nil {
+		a.logBatch.Printf("batch item lookup error: chat=%d item=%s err=%v", chatID, strings.TrimSpace(sel.ItemCode), err)
+		if len(a.cfg.CountUOMs) > 0 {
+			note = strings.TrimSpace(note + " | ERP UOM o'qilmadi, qty kg'da: " + err.Error())
+		}
+	}
+	sel.GTIN = itemGTIN(item)
+	a.rememberGTIN(chatID, sel.ItemCode, sel.GTIN)
+	count, err := a.resolveCounter(chatID, sel, item)
+	if err != nil {
+		a.logBatch.Printf("batch count mode error: chat=%d item=%s err=%v", chatID, strings.TrimSpace(sel.ItemCode), err)
+		note = strings.TrimSpace(note + " | Dona og'irligi xato, qty kg'da: " + err.Error())
+	}
+	a.setCounter(chatID, sel.ItemCode, count)
+	if count.UOM != "" {
+		a.logBatch.Printf("batch count mode: chat=%d item=%s uom=%s unit_weight=%s", chatID, strings.TrimSpace(sel.ItemCode), count.UOM, count.String())
+		if count.Ready() {
+			note = strings.TrimSpace(note + " | Dona: " + count.String())
+		} else {
+			note = strings.TrimSpace(note + " | " + countNote(corepkg.ErrNoUnitWeight))
+		}
+	}
 	initial := formatBatchStatusText(sel, 0, "", 0, "", "", "", strings.TrimSpace(note))
 	statusMessageID = a.upsertBatchStatusMessage(ctx, chatID, statusMessageID, initial)
 
-	a.startBatchSession(ctx, chatID, func(batchCtx context.Context) {
-		a.runMaterialIssueBatchLoop(batchCtx, chatID, sel, statusMessageID)
+	station := a.stationFor(chatID)
+	a.startBatchSession(ctx, chatID, station, func(batchCtx context.Context) {
+		a.runMaterialIssueBatchLoop(batchCtx, chatID, station, sel, statusMessageID)
 	})
 	return statusMessageID
 }
 
-func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, sel SelectedContext, statusMessageID int64) {
+func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, station string, sel SelectedContext, statusMessageID int64) {
+	qtyReader := a.station(station).qtyReader
 	draftCount := 0
 	lastEPC := ""
 	// Status matnida har safar oxirgi muvaffaqiyatli draftni ko'rsatamiz.
@@ -151,7 +177,7 @@ func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, sel S
 	lastDraftVerify := "UNKNOWN"
 
 	for {
-		reading, err := a.qtyReader.WaitStablePositiveReading(ctx, 35*time.Second, 220*time.Millisecond)
+		reading, err := qtyReader.WaitStablePositiveReading(ctx, 35*time.Second, 220*time.Millisecond)
 		if err != nil {
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				return
@@ -168,58 +194,94 @@ func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, sel S
 			continue
 		}
 		a.logBatch.Printf(
-			"batch stable qty: chat=%d item=%s warehouse=%s qty=%.3f unit=%s scale_at=%s",
+			"batch stable qty: chat=%d item=%s warehouse=%s qty=%.3f gross=%.3f tare=%.3f unit=%s scale_at=%s",
 			chatID,
 			strings.TrimSpace(sel.ItemCode),
 			strings.TrimSpace(sel.Warehouse),
 			reading.Qty,
+			reading.Gross,
+			reading.Tare,
 			strings.TrimSpace(reading.Unit),
 			reading.UpdatedAt.Format(time.RFC3339Nano),
 		)
 
-		epc := ""
-		epcVerify := "UNKNOWN"
-		epcNote := ""
-		epcReading, err := a.qtyReader.WaitEPCForReading(ctx, epcWaitTimeout, epcWaitPollInterval, reading.UpdatedAt, lastEPC)
-		if err != nil {
-			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-				return
+		// Zero block (scale --zero-policy block): nol noto'g'ri, scale EPC bermaydi.
+		if reading.ZeroBlock {
+			a.logBatch.Printf("batch zero blocked: chat=%d qty=%.3f zero=%s", chatID, reading.Qty, reading.ZeroAlarm)
+			statusMessageID = a.upsertBatchStatusMessage(
+				ctx,
+				chatID,
+				statusMessageID,
+				formatBatchStatusText(sel, draftCount, lastDraftName, lastDraftQty, lastDraftUnit, lastDraftEPC, lastDraftVerify,
+					"Tarozi noli noto'g'ri, draft yaratilmadi: tarozini qayta nollang | "+formatZeroNote(reading)),
+			)
+			if err := qtyReader.WaitForNextCycle(ctx, 10*time.Minute, 220*time.Millisecond, reading.Qty); err != nil {
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return
+				}
 			}
-			if isTimeoutLikeError(err) {
-				// Zebra tez javob beradi, lekin event propagation va polling race tufayli
-				// ba'zida EPC snapshot 6s oynadan ozgina keyin kelishi mumkin.
-				// Katta timeout bermaymiz, faqat qisqa grace-check qilamiz.
-				a.logBatch.Printf(
-					"batch epc wait timeout: chat=%d qty=%.3f timeout=%s, grace=%s",
+			continue
+		}
+
+		// Counting rejimi: ERP qty dona soni, stock UOM'da. Son ishonchsiz bo'lsa scale
+		// ham EPC bermaydi, shuning uchun EPC kutilmaydi.
+		qty, qtyUnit := reading.Qty, reading.Unit
+		if count := a.counter(chatID); count.UOM != "" {
+			pieces, err := count.CountUnit(reading.Qty, reading.Unit)
+			if err != nil {
+				a.logBatch.Printf("batch count skipped: chat=%d qty=%.3f err=%v", chatID, reading.Qty, err)
+				statusMessageID = a.upsertBatchStatusMessage(
+					ctx,
 					chatID,
-					reading.Qty,
-					epcWaitTimeout,
-					epcWaitGraceTimeout,
+					statusMessageID,
+					formatBatchStatusText(sel, draftCount, lastDraftName, lastDraftQty, lastDraftUnit, lastDraftEPC, lastDraftVerify, countNote(err)),
 				)
-				graceReading, graceErr := a.qtyReader.WaitEPCForReading(ctx, epcWaitGraceTimeout, epcWaitPollInterval, reading.UpdatedAt, lastEPC)
-				if graceErr == nil {
-					epc = strings.ToUpper(strings.TrimSpace(graceReading.EPC))
-					epcVerify = strings.ToUpper(strings.TrimSpace(graceReading.Verify))
-					if epcVerify == "" {
-						epcVerify = "UNKNOWN"
-					}
-					a.logBatch.Printf(
-						"batch epc matched (grace): chat=%d qty=%.3f epc=%s verify=%s zebra_at=%s",
-						chatID,
-						reading.Qty,
-						epc,
-						epcVerify,
-						graceReading.UpdatedAt.Format(time.RFC3339Nano),
-					)
-				} else {
-					if errors.Is(graceErr, context.Canceled) || errors.Is(graceErr, context.DeadlineExceeded) {
+				if err := qtyReader.WaitForNextCycle(ctx, 10*time.Minute, 220*time.Millisecond, reading.Qty); err != nil {
+					if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 						return
 					}
-					epcNote = strings.TrimSpace(err.Error() + " | grace: " + graceErr.Error())
 				}
-			} else {
-				epcNote = err.Error()
+				continue
+			}
+			qty, qtyUnit = float64(pieces.Pieces), pieces.UOM
+			a.logBatch.Printf("batch count: chat=%d qty=%.3f pieces=%d exact=%.3f uom=%s", chatID, reading.Qty, pieces.Pieces, pieces.Exact, pieces.UOM)
+		}
+
+		// Check-weigh sinfi scale snapshot'idan: scale qaysi qoida va policy bilan EPC bergan bo'lsa
+		// bot ham shunga amal qiladi.
+		checkNote := ""
+		if reading.Check.Class != "" {
+			checkNote = "Tekshiruv: " + reading.Check.String()
+		}
+		if reading.CheckBlock {
+			// block: scale bu mahsulotga EPC bermaydi, shuning uchun EPC kutilmaydi.
+			a.logBatch.Printf("batch check-weigh blocked: chat=%d qty=%.3f check=%s", chatID, reading.Qty, reading.Check.String())
+			statusMessageID = a.upsertBatchStatusMessage(
+				ctx,
+				chatID,
+				statusMessageID,
+				formatBatchStatusText(sel, draftCount, lastDraftName, lastDraftQty, lastDraftUnit, lastDraftEPC, lastDraftVerify,
+					"Vazn tolerance'dan tashqari, draft yaratilmadi | "+checkNote),
+			)
+			if err := qtyReader.WaitForNextCycle(ctx, 10*time.Minute, 220*time.Millisecond, reading.Qty); err != nil {
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return
+				}
 			}
+			continue
+		}
+
+		epc := ""
+		epcVerify := "UNKNOWN"
+		epcNote := ""
+		epcReading, err := qtyReader.WaitEPCForReading(ctx, epcWaitTimeout, epcWaitPollInterval, reading.UpdatedAt, lastEPC)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return
+			}
+			// Bridge watch push-based: EPC snapshot yozilishi bilan keladi,
+			// shu sabab polling race uchun qo'shimcha grace-check kerak emas.
+			epcNote = err.Error()
 		} else {
 			epc = strings.ToUpper(strings.TrimSpace(epcReading.EPC))
 			epcVerify = strings.ToUpper(strings.TrimSpace(epcReading.Verify))
@@ -235,6 +297,7 @@ func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, sel S
 				epcReading.UpdatedAt.Format(time.RFC3339Nano),
 			)
 		}
+		a.metrics.observeEPC(station, epc, epcVerify)
 		if strings.TrimSpace(epc) != "" && !isRFIDVerifySuccess(epcVerify) {
 			epcNote = strings.TrimSpace(strings.Join([]string{
 				epcNote,
@@ -259,13 +322,16 @@ func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, sel S
 			if strings.TrimSpace(epcNote) != "" {
 				note = note + " | " + strings.TrimSpace(epcNote)
 			}
+			if checkNote != "" {
+				note = note + " | " + checkNote
+			}
 			statusMessageID = a.upsertBatchStatusMessage(
 				ctx,
 				chatID,
 				statusMessageID,
 				formatBatchStatusText(sel, draftCount, lastDraftName, lastDraftQty, lastDraftUnit, lastDraftEPC, lastDraftVerify, note),
 			)
-			if err := a.qtyReader.WaitForNextCycle(ctx, 10*time.Minute, 220*time.Millisecond, reading.Qty); err != nil {
+			if err := qtyReader.WaitForNextCycle(ctx, 10*time.Minute, 220*time.Millisecond, reading.Qty); err != nil {
 				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 					return
 				}
@@ -273,14 +339,16 @@ func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, sel S
 			continue
 		}
 
+		draftStarted := time.Now()
 		draft, err := a.erp.CreateMaterialIssueDraft(ctx, erp.MaterialIssueDraftInput{
 			ItemCode:  sel.ItemCode,
 			Warehouse: sel.Warehouse,
-			Qty:       reading.Qty,
+			Qty:       qty,
 			Barcode:   epc,
 		})
+		a.metrics.observeDraft(station, draftStarted, err)
 		if err != nil {
-			a.logBatch.Printf("batch draft create error: chat=%d qty=%.3f epc=%s err=%v", chatID, reading.Qty, epc, err)
+			a.logBatch.Printf("batch draft create error: chat=%d qty=%.3f epc=%s err=%v", chatID, qty, epc, err)
 			statusMessageID = a.upsertBatchStatusMessage(
 				ctx,
 				chatID,
@@ -298,7 +366,7 @@ func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, sel S
 		}
 		lastDraftName = strings.TrimSpace(draft.Name)
 		lastDraftQty = draft.Qty
-		lastDraftUnit = reading.Unit
+		lastDraftUnit = qtyUnit
 		lastDraftEPC = epc
 		lastDraftVerify = epcVerify
 
@@ -306,6 +374,15 @@ func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, sel S
 		if strings.TrimSpace(epcNote) != "" {
 			note = note + " | EPC ogohlantirish: " + strings.TrimSpace(epcNote)
 		}
+		if checkNote != "" {
+			note = note + " | " + checkNote
+		}
+		if tareNote := formatTareNote(reading); tareNote != "" {
+			note = note + " | " + tareNote
+		}
+		if zeroNote := formatZeroNote(reading); zeroNote != "" {
+			note = note + " | " + zeroNote
+		}
 		statusMessageID = a.upsertBatchStatusMessage(
 			ctx,
 			chatID,
@@ -314,7 +391,7 @@ func (a *App) runMaterialIssueBatchLoop(ctx context.Context, chatID int64, sel S
 		)
 
 		for {
-			err := a.qtyReader.WaitForNextCycle(ctx, 10*time.Minute, 220*time.Millisecond, draft.Qty)
+			err := qtyReader.WaitForNextCycle(ctx, 10*time.Minute, 220*time.Millisecond, reading.Qty)
 			if err == nil {
 				break
 			}
@@ -348,6 +425,26 @@ func (a *App) upsertBatchStatusMessage(ctx context.Context, chatID, messageID in
 	return newID
 }
 
+// formatTareNote tara bo'lsa status uchun: `Brutto 12.500 kg, tara 2.500 kg (preset)`.
+func formatTareNote(r bridgeclient.StableReading) string {
+	if r.Tare <= 0 {
+		return ""
+	}
+	s := fmt.Sprintf("Brutto %.3f %s, tara %.3f %s", r.Gross, r.Unit, r.Tare, r.Unit)
+	if src := strings.TrimSpace(r.TareSource); src != "" {
+		s += " (" + src + ")"
+	}
+	return s
+}
+
+// formatZeroNote scale zero-tracking ogohlantirishi (warn policy'da draft baribir yaratiladi).
+func formatZeroNote(r bridgeclient.StableReading) string {
+	if r.ZeroAlarm == "" {
+		return ""
+	}
+	return "Zero ogohlantirish: " + r.ZeroAlarm
+}
+
 func formatBatchStatusText(sel SelectedContext, draftCount int, draftName string, qty float64, unit, epc, epcVerify, note string) string {
 	lines := []string{
 		"Batch ishlayapti",
@@ -357,12 +454,8 @@ func formatBatchStatusText(sel SelectedContext, draftCount int, draftName string
 	}
 
 	if draftCount > 0 {
-		u := strings.ToLower(strings.TrimSpace(unit))
-		if u == "" {
-			u = "kg"
-		}
 		lines = append(lines, fmt.Sprintf("Oxirgi draft: %s", strings.TrimSpace(draftName)))
-		lines = append(lines, fmt.Sprintf("Oxirgi QTY: %.3f %s", qty, u))
+		lines = append(lines, "Oxirgi QTY: "+formatDraftQty(qty, unit))
 		epc = strings.ToUpper(strings.TrimSpace(epc))
 		if epc == "" {
 			epc = "-"
@@ -379,6 +472,20 @@ func formatBatchStatusText(sel SelectedContext, draftCount int, draftName string
 	return strings.Join(lines, "\n")
 }
 
+// formatDraftQty vazn `1.250 kg`, counting rejimida dona `12 Nos` ko'rinishida.
+func formatDraftQty(qty float64, unit string) string {
+	u := strings.TrimSpace(unit)
+	switch strings.ToLower(u) {
+	case "", "kg", "g", "lb":
+		if u == "" {
+			u = "kg"
+		}
+		return fmt.Sprintf("%.3f %s", qty, strings.ToLower(u))
+	default:
+		return fmt.Sprintf("%.0f %s", qty, u)
+	}
+}
+
 func formatRFIDConfirmLine(epc, verify string) string {
 	verify = strings.ToUpper(strings.TrimSpace(verify))
 	if verify == "" {
@@ -402,17 +509,6 @@ func isRFIDVerifySuccess(verify string) bool {
 	}
 }
 
-func isTimeoutLikeError(err error) bool {
-	if err == nil {
-		return false
-	}
-	if errors.Is(err, context.DeadlineExceeded) {
-		return true
-	}
-	msg := strings.ToLower(strings.TrimSpace(err.Error()))
-	return strings.Contains(msg, "timeout")
-}
-
 func formatSelectedItem(sel SelectedContext) string {
 	code := strings.TrimSpace(sel.ItemCode)
 	name := strings.TrimSpace(sel.ItemName)
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	corepkg "core"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot/internal/erp"
+)
+
+const sampleWaitTimeout = 8 * time.Second
+
+// countState chat'ning counting rejimi: qaysi item uchun va dona og'irligi.
+type countState struct {
+	itemCode string
+	counter  corepkg.PieceCounter
+}
+
+// counter chat batch'i dona bilan berilsa counter (UOM bo'sh = vazn bilan).
+func (a *App) counter(chatID int64) corepkg.PieceCounter {
+	a.countMu.Lock()
+	defer a.countMu.Unlock()
+	return a.countByChat[chatID].counter
+}
+
+func (a *App) setCounter(chatID int64, itemCode string, c corepkg.PieceCounter) {
+	a.countMu.Lock()
+	defer a.countMu.Unlock()
+	if strings.TrimSpace(c.UOM) == "" {
+		delete(a.countByChat, chatID)
+		return
+	}
+	a.countByChat[chatID] = countState{itemCode: strings.TrimSpace(itemCode), counter: c}
+}
+
+// lookupItem batch boshlanishida item'ning ERP ma'lumotlari (stock UOM, dona og'irligi, barcode'lar).
+func (a *App) lookupItem(ctx context.Context, sel SelectedContext) (erp.ItemUOM, error) {
+	if a.erp == nil {
+		return erp.ItemUOM{}, nil
+	}
+	return a.erp.GetItemUOM(ctx, sel.ItemCode)
+}
+
+// resolveCounter item counting rejimidami aniqlaydi (COUNT_UOMS).
+// Dona og'irligi ERP'dan olinadi; shu item uchun avval /sample qilingan bo'lsa u ustun.
+func (a *App) resolveCounter(chatID int64, sel SelectedContext, u erp.ItemUOM) (corepkg.PieceCounter, error) {
+	if len(a.cfg.CountUOMs) == 0 || !a.cfg.IsCountUOM(u.StockUOM) {
+		return corepkg.PieceCounter{}, nil
+	}
+
+	a.countMu.Lock()
+	prev, ok := a.countByChat[chatID]
+	a.countMu.Unlock()
+	if ok && prev.itemCode == strings.TrimSpace(sel.ItemCode) && prev.counter.Source == corepkg.UnitWeightSample && prev.counter.Ready() {
+		prev.counter.UOM = u.StockUOM
+		return prev.counter, nil
+	}
+	if kg, ok := u.WeightPerUnitKg(); ok {
+		return corepkg.NewPieceCounter(kg, corepkg.UnitWeightERP, u.StockUOM)
+	}
+	return corepkg.PieceCounter{UOM: u.StockUOM}, nil
+}
+
+// itemGTIN item barcode'laridan birinchi to'g'ri GTIN (GTIN-14 ko'rinishida); yo'q bo'lsa bo'sh.
+// Scale --epc-scheme sgtin96 bo'lsa EPC shu GTIN'dan yasaladi.
+func itemGTIN(u erp.ItemUOM) string {
+	for _, b := range u.Barcodes {
+		if gtin, err := corepkg.NormalizeGTIN(b); err == nil {
+			return gtin
+		}
+	}
+	return ""
+}
+
+// handleSampleCommand `/sample <n>`: tarozidagi n dona namunadan dona og'irligini o'rganadi.
+func (a *App) handleSampleCommand(ctx context.Context, chatID int64, text string) error {
+	n, err := parseSampleCount(text)
+	if err != nil {
+		return a.tg.SendMessage(ctx, chatID, "Format: /sample <dona soni> (masalan /sample 10)")
+	}
+	current := a.counter(chatID)
+	if strings.TrimSpace(current.UOM) == "" {
+		return a.tg.SendMessage(ctx, chatID, "Counting rejimi yo'q: batch item'i dona bilan berilmaydi (COUNT_UOMS).")
+	}
+
+	station := a.stationFor(chatID)
+	reading, err := a.station(station).qtyReader.WaitStablePositiveReading(ctx, sampleWaitTimeout, 220*time.Millisecond)
+	if err != nil {
+		return a.tg.SendMessage(ctx, chatID, "Namuna olinmadi: "+err.Error())
+	}
+	c, err := corepkg.SamplePieceCounter(reading.Qty, reading.Unit, n, current.UOM)
+	if err != nil {
+		return a.tg.SendMessage(ctx, chatID, "Namuna xato: "+err.Error())
+	}
+
+	a.countMu.Lock()
+	itemCode := a.countByChat[chatID].itemCode
+	a.countMu.Unlock()
+	a.setCounter(chatID, itemCode, c)
+	a.syncBatchStateFromSessions(station, chatID)
+	a.logBatch.Printf("count sample: chat=%d item=%s qty=%.3f pieces=%d unit_weight=%.6f", chatID, itemCode, reading.Qty, n, c.UnitWeight)
+
+	return a.tg.SendMessage(ctx, chatID, fmt.Sprintf("Dona og'irligi: %s\nNamunani olib, mahsulotni qo'ying.", c.String()))
+}
+
+func parseSampleCount(text string) (int, error) {
+	fields := strings.Fields(strings.TrimSpace(text))
+	if len(fields) != 2 {
+		return 0, errors.New("dona soni kerak")
+	}
+	n, err := strconv.Atoi(fields[1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("dona soni noto'g'ri: %q", fields[1])
+	}
+	return n, nil
+}
+
+// countNote counting xatosi uchun status izohi.
+func countNote(err error) string {
+	if errors.Is(err, corepkg.ErrNoUnitWeight) {
+		return "Dona og'irligi noma'lum: N dona qo'yib /sample N yuboring"
+	}
+	return "Dona soni aniqlanmadi, draft yaratilmadi | " + err.Error()
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	corepkg "core"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bot/internal/config"
+	"bot/internal/erp"
+)
+
+func TestResolveCounter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/resource/Item/BOLT":
+			_, _ = w.Write([]byte(`{"data":{"item_code":"BOLT","stock_uom":"Nos","weight_per_unit":25,"weight_uom":"Gram"}}`))
+		case "/api/resource/Item/NUT":
+			_, _ = w.Write([]byte(`{"data":{"item_code":"NUT","stock_uom":"Nos"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":{"stock_uom":"Kg"}}`))
+		}
+	}))
+	defer ts.Close()
+
+	a := &App{
+		cfg:         config.Config{CountUOMs: []string{"Nos"}},
+		erp:         erp.New(ts.URL, "k", "s"),
+		countByChat: make(map[int64]countState),
+	}
+	ctx := context.Background()
+	resolve := func(itemCode string) (corepkg.PieceCounter, error) {
+		sel := SelectedContext{ItemCode: itemCode}
+		u, err := a.lookupItem(ctx, sel)
+		if err != nil {
+			return corepkg.PieceCounter{}, err
+		}
+		return a.resolveCounter(1, sel, u)
+	}
+
+	c, err := resolve("BOLT")
+	if err != nil || c.UOM != "Nos" || c.Source != corepkg.UnitWeightERP || c.UnitWeight != 0.025 {
+		t.Fatalf("erp counter: %+v err=%v", c, err)
+	}
+	if c, err := resolve("FLOUR"); err != nil || c.UOM != "" {
+		t.Fatalf("weight item: %+v err=%v", c, err)
+	}
+	c, err = resolve("NUT")
+	if err != nil || c.UOM != "Nos" || c.Ready() {
+		t.Fatalf("nut without weight: %+v err=%v", c, err)
+	}
+
+	// Shu item uchun o'rganilgan namuna ERP qiymatidan ustun.
+	sample, _ := corepkg.SamplePieceCounter(0.26, "kg", 10, "Nos")
+	a.setCounter(1, "BOLT", sample)
+	if c, err := resolve("BOLT"); err != nil || c.Source != corepkg.UnitWeightSample {
+		t.Fatalf("sample should win: %+v err=%v", c, err)
+	}
+
+	a.cfg.CountUOMs = nil
+	if c, err := resolve("BOLT"); err != nil || c.UOM != "" {
+		t.Fatalf("disabled: %+v err=%v", c, err)
+	}
+}
+
+func TestItemGTIN(t *testing.T) {
+	u := erp.ItemUOM{Barcodes: []string{"BOLT-M8", "0614141123453", "614141123452"}}
+	if got := itemGTIN(u); got != "00614141123452" {
+		t.Fatalf("gtin mismatch: %q", got)
+	}
+	if got := itemGTIN(erp.ItemUOM{Barcodes: []string{"3034257BF7194E406994036B"}}); got != "" {
+		t.Fatalf("non-gtin barcode: %q", got)
+	}
+}
+
+func TestParseSampleCountAndFormatQty(t *testing.T) {
+	if n, err := parseSampleCount("/sample 10"); err != nil || n != 10 {
+		t.Fatalf("parse: %d %v", n, err)
+	}
+	for _, in := range []string{"/sample", "/sample 0", "/sample x", "/sample 1 2"} {
+		if _, err := parseSampleCount(in); err == nil {
+			t.Fatalf("%q should fail", in)
+		}
+	}
+	if got := formatDraftQty(12, "Nos"); got != "12 Nos" {
+		t.Fatalf("count qty: %q", got)
+	}
+	if got := formatDraftQty(1.25, ""); got != "1.250 kg" {
+		t.Fatalf("weight qty: %q", got)
+	}
+}
@@ -2,12 +2,17 @@ package app
 
 import (
 	"context"
+	corepkg "core"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 )
 
-func (a *App) handleEPCCommand(ctx context.Context, chatID int64) error {
+func (a *App) handleEPCCommand(ctx context.Context, chatID int64, text string) error {
+	if fields := strings.Fields(strings.TrimSpace(text)); len(fields) >= 2 {
+		return a.tg.SendMessage(ctx, chatID, a.lookupEPC(fields[1]))
+	}
 	epcs := a.epcHistory.Snapshot()
 	if len(epcs) == 0 {
 		return a.tg.SendMessage(ctx, chatID, "Hozircha draft uchun EPC yozilmagan.")
@@ -27,3 +32,84 @@ func buildEPCDocument(epcs []string, now time.Time) (string, []byte) {
 	}
 	return name, []byte(body)
 }
+
+// lookupEPC `/epc <EPC>` javobi: EPC berilganmi va qaysi item/qty/stansiya uchun.
+func (a *App) lookupEPC(epc string) string {
+	if a.epcRegistry == nil {
+		return "EPC registry sozlanmagan (EPC_REGISTRY_FILE)."
+	}
+	rec, ok, err := a.epcRegistry.Lookup(epc)
+	if err != nil {
+		return "EPC registry xato: " + err.Error()
+	}
+	if !ok {
+		msg := fmt.Sprintf("EPC %s berilmagan (registry'da topilmadi).", strings.ToUpper(strings.TrimSpace(epc)))
+		if note := epcValidationNote(epc, a.cfg.EPCScheme); note != "" {
+			msg += "\n" + note
+		}
+		return msg
+	}
+	return formatEPCRecord(rec)
+}
+
+// epcValidationNote registry'da topilmagan EPC nega topilmaganini taxmin qiladi:
+// qo'lda xato kiritilgan, kesilgan yoki noto'g'ri o'qilgan bo'lsa shuni aytadi.
+// Tekshiruv scale'dagi sxema bo'yicha: time EPC SGTIN-96 deb o'qilib o'tib ketmaydi.
+func epcValidationNote(epc, scheme string) string {
+	_, err := corepkg.ValidateEPCScheme(epc, scheme)
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, corepkg.ErrEPCChecksum):
+		return "Checksum mos emas: EPC qo'lda xato kiritilgan yoki noto'g'ri o'qilgan bo'lishi mumkin."
+	case errors.Is(err, corepkg.ErrEPCTruncated):
+		return "EPC kesilgan (uzunlik 16-bit word'ga bo'linmaydi): PC word noto'g'ri yoki belgi tushib qolgan."
+	default:
+		return "EPC tekshiruvi: " + err.Error()
+	}
+}
+
+func formatEPCRecord(rec corepkg.EPCRecord) string {
+	item := strings.TrimSpace(rec.ItemName)
+	if code := strings.TrimSpace(rec.ItemCode); code != "" && code != item {
+		if item == "" {
+			item = code
+		} else {
+			item = fmt.Sprintf("%s (%s)", item, code)
+		}
+	}
+	if item == "" {
+		item = "-"
+	}
+	unit := strings.TrimSpace(rec.Unit)
+	if unit == "" {
+		unit = "kg"
+	}
+	lines := []string{
+		"EPC: " + rec.EPC,
+		"Item: " + item,
+		fmt.Sprintf("Qty: %.3f %s", rec.Qty, unit),
+		"Stansiya: " + orDash(rec.Station),
+		"Berilgan: " + orDash(rec.IssuedAt),
+	}
+	if rec.Scheme != "" {
+		lines = append(lines, "Sxema: "+rec.Scheme)
+	}
+	switch rec.Status {
+	case "":
+	case corepkg.EPCStatusFailed:
+		lines = append(lines, "Encode: xato ("+orDash(rec.Error)+")")
+	case corepkg.EPCStatusReserved:
+		lines = append(lines, "Encode: natija yozilmagan (band qilingan)")
+	default:
+		lines = append(lines, "Encode: "+rec.Status)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func orDash(v string) string {
+	if v = strings.TrimSpace(v); v == "" {
+		return "-"
+	}
+	return v
+}
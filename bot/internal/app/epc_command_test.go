@@ -0,0 +1,101 @@
+package app
+
+import (
+	corepkg "core"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookupEPC(t *testing.T) {
+	a := &App{}
+	a.cfg.EPCScheme = corepkg.EPCSchemeTime
+	if got := a.lookupEPC("30AA"); !strings.Contains(got, "EPC_REGISTRY_FILE") {
+		t.Fatalf("disabled registry reply: %q", got)
+	}
+
+	reg, err := corepkg.OpenEPCRegistry(filepath.Join(t.TempDir(), "epc.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register(corepkg.EPCRecord{EPC: "30AA", ItemCode: "ITM-1", ItemName: "GRENKI", Qty: 1.25, Unit: "kg", Station: "line-1", IssuedAt: "2026-01-02T03:04:05Z"}); err != nil {
+		t.Fatal(err)
+	}
+	a.epcRegistry = reg
+	want := "EPC: 30AA\nItem: GRENKI (ITM-1)\nQty: 1.250 kg\nStansiya: line-1\nBerilgan: 2026-01-02T03:04:05Z"
+	if got := a.lookupEPC("30aa"); got != want {
+		t.Fatalf("lookup reply:\n%s\nwant:\n%s", got, want)
+	}
+	if got := a.lookupEPC("30BB"); !strings.Contains(got, "topilmadi") {
+		t.Fatalf("missing reply: %q", got)
+	}
+	if err := reg.Settle("30AA", corepkg.EPCStatusFailed, "printer busy", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.lookupEPC("30AA"); !strings.HasSuffix(got, "\nEncode: xato (printer busy)") {
+		t.Fatalf("failed encode reply: %q", got)
+	}
+
+	epc, err := corepkg.NewTimeEPCScheme().Next(time.Unix(1_700_000_000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.lookupEPC(epc); strings.Contains(got, "Checksum") || strings.Contains(got, "kesilgan") {
+		t.Fatalf("valid epc reply: %q", got)
+	}
+	// Qo'shni ikki belgi almashgan (qo'lda kiritishdagi odatiy xato).
+	typo := []byte(epc)
+	for i := 2; i+1 < len(typo); i++ {
+		if typo[i] != typo[i+1] {
+			typo[i], typo[i+1] = typo[i+1], typo[i]
+			break
+		}
+	}
+	if got := a.lookupEPC(string(typo)); !strings.Contains(got, "Checksum mos emas") {
+		t.Fatalf("typo reply: %q", got)
+	}
+	if got := a.lookupEPC(epc[:22]); !strings.Contains(got, "kesilgan") {
+		t.Fatalf("truncated reply: %q", got)
+	}
+}
+
+func TestLookupEPCRejectsCorruptTimeEPCAsSGTIN(t *testing.T) {
+	reg, err := corepkg.OpenEPCRegistry(filepath.Join(t.TempDir(), "epc.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{epcRegistry: reg}
+	a.cfg.EPCScheme = corepkg.EPCSchemeTime
+
+	epc, err := corepkg.NewTimeEPCScheme().Next(time.Unix(1_700_000_000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bitta belgisi buzilgan, lekin ValidateEPC SGTIN-96 deb qabul qiladigan variant.
+	corrupt := ""
+	const hexDigits = "0123456789ABCDEF"
+	for i := 2; i < len(epc) && corrupt == ""; i++ {
+		for _, c := range hexDigits {
+			if byte(c) == epc[i] {
+				continue
+			}
+			cand := epc[:i] + string(c) + epc[i+1:]
+			if chk, err := corepkg.ValidateEPC(cand); err == nil && chk.Kind == corepkg.EPCKindSGTIN96 {
+				corrupt = cand
+				break
+			}
+		}
+	}
+	if corrupt == "" {
+		t.Fatal("SGTIN bo'lib o'tadigan buzilgan time EPC topilmadi")
+	}
+	if got := a.lookupEPC(corrupt); !strings.Contains(got, "Checksum mos emas") {
+		t.Fatalf("corrupt time epc reply: %q", got)
+	}
+
+	a.cfg.EPCScheme = corepkg.EPCSchemeSGTIN96
+	if got := a.lookupEPC(corrupt); strings.Contains(got, "Checksum") {
+		t.Fatalf("sgtin96 scheme reply: %q", got)
+	}
+}
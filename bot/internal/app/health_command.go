@@ -0,0 +1,49 @@
+package app
+
+import (
+	bridgestate "bridge/state"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+func (a *App) handleHealthCommand(ctx context.Context, chatID int64) error {
+	if a.bridgeStore == nil {
+		return a.tg.SendMessage(ctx, chatID, "Bridge state sozlanmagan.")
+	}
+	return a.tg.SendMessage(ctx, chatID, formatBridgeHealth(a.bridgeStore.Health()))
+}
+
+func formatBridgeHealth(h bridgestate.Health) string {
+	lines := []string{
+		"Bridge state: " + h.Summary(),
+		"Fayl: " + safeHealthValue(h.Path),
+	}
+	if h.UpdatedAt != "" {
+		lines = append(lines, fmt.Sprintf("Oxirgi yozuv: %s (schema v%d)", h.UpdatedAt, h.SchemaVersion))
+	}
+	if len(h.Quarantined) > 0 {
+		names := make([]string, 0, len(h.Quarantined))
+		for _, p := range h.Quarantined {
+			names = append(names, filepath.Base(p))
+		}
+		lines = append(lines, fmt.Sprintf("Karantin (%d): %s", len(names), strings.Join(names, ", ")))
+	}
+	if r := h.LastRecovery; r != nil {
+		from := "backup topilmadi, bo'sh holatdan boshlandi"
+		if r.RestoredFrom != "" {
+			from = "tiklandi: " + filepath.Base(r.RestoredFrom)
+		}
+		lines = append(lines, fmt.Sprintf("Oxirgi tiklash: %s, %s", r.At, from))
+		lines = append(lines, "Sabab: "+safeHealthValue(r.Reason))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func safeHealthValue(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "-"
+	}
+	return v
+}
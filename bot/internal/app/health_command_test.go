@@ -0,0 +1,26 @@
+package app
+
+import (
+	bridgestate "bridge/state"
+	"strings"
+	"testing"
+)
+
+func TestFormatBridgeHealth_ShowsRecovery(t *testing.T) {
+	out := formatBridgeHealth(bridgestate.Health{
+		Path:        "/tmp/gscale-zebra/bridge_state.json",
+		OK:          true,
+		Exists:      true,
+		Quarantined: []string{"/tmp/gscale-zebra/bridge_state.json.corrupt-20261017T101010.000000000Z"},
+		LastRecovery: &bridgestate.Recovery{
+			At:           "2026-10-17T10:10:10Z",
+			Reason:       "bridge state buzilgan: json noto'g'ri",
+			RestoredFrom: "/tmp/gscale-zebra/bridge_state.json.bak.1",
+		},
+	})
+	for _, want := range []string{"Karantin (1)", "tiklandi: bridge_state.json.bak.1", "Sabab: bridge state buzilgan"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -76,11 +76,19 @@ func (a *App) handleMessage(ctx context.Context, msg telegram.Message) error {
 	case "/log":
 		return a.handleLogCommand(ctx, msg.Chat.ID)
 	case "/epc":
-		return a.handleEPCCommand(ctx, msg.Chat.ID)
+		return a.handleEPCCommand(ctx, msg.Chat.ID, text)
 	case "/calibrate":
 		return a.handleCalibrateCommand(ctx, msg.Chat.ID, text)
+	case "/health":
+		return a.handleHealthCommand(ctx, msg.Chat.ID)
+	case "/station":
+		return a.handleStationCommand(ctx, msg.Chat.ID, text)
+	case "/sample":
+		return a.handleSampleCommand(ctx, msg.Chat.ID, text)
+	case "/tare", "/zero", "/cleartare":
+		return a.handleScaleCommand(ctx, msg.Chat.ID, cmd)
 	default:
-		return a.tg.SendMessage(ctx, msg.Chat.ID, "Qo'llanadigan buyruqlar: /start, /batch, /log, /epc, /calibrate")
+		return a.tg.SendMessage(ctx, msg.Chat.ID, "Qo'llanadigan buyruqlar: /start, /batch, /log, /epc, /calibrate, /health, /station, /sample, /tare, /zero, /cleartare")
 	}
 }
 
@@ -97,7 +105,7 @@ func (a *App) maybeDeleteCommandMessage(ctx context.Context, msg telegram.Messag
 
 func shouldDeleteUserCommand(cmd string) bool {
 	switch cmd {
-	case "/start", "/batch", "/log", "/epc", "/calibrate":
+	case "/start", "/batch", "/log", "/epc", "/calibrate", "/health", "/station", "/sample", "/tare", "/zero", "/cleartare":
 		return true
 	default:
 		return false
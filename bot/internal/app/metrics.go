@@ -0,0 +1,55 @@
+package app
+
+import (
+	"bridge/metrics"
+	"strings"
+	"time"
+)
+
+// appMetrics bot tomonidagi batch pipeline metrikalari (`METRICS_ADDR` da `/metrics`).
+// Scale metrikalari bilan bir xil `gscale_` prefiksi, `station` label bilan.
+type appMetrics struct {
+	registry       *metrics.Registry
+	draftSeconds   *metrics.Histogram
+	draftErrors    *metrics.Counter
+	sessionsActive *metrics.Gauge
+	epcResults     *metrics.Counter
+}
+
+func newAppMetrics() *appMetrics {
+	r := metrics.NewRegistry(nil)
+	return &appMetrics{
+		registry:       r,
+		draftSeconds:   r.Histogram("gscale_erp_draft_seconds", "ERP material issue draft yaratish vaqti (sekund).", metrics.DefaultLatencyBuckets, "station"),
+		draftErrors:    r.Counter("gscale_erp_draft_errors_total", "ERP draft yaratishdagi xatolar.", "station"),
+		sessionsActive: r.Gauge("gscale_batch_sessions_active", "Stansiyaga bog'langan aktiv batch sessiyalar.", "station"),
+		epcResults:     r.Counter("gscale_batch_epc_total", "Batch o'qishlari uchun olingan EPC natijasi (verify bo'yicha, EPC kelmasa NONE).", "station", "verify"),
+	}
+}
+
+func (m *appMetrics) observeDraft(station string, started time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.draftSeconds.Observe(time.Since(started).Seconds(), station)
+	if err != nil {
+		m.draftErrors.Inc(station)
+	}
+}
+
+func (m *appMetrics) observeEPC(station, epc, verify string) {
+	if m == nil {
+		return
+	}
+	if strings.TrimSpace(epc) == "" {
+		verify = "NONE"
+	}
+	m.epcResults.Inc(station, verify)
+}
+
+func (m *appMetrics) setSessions(station string, n int) {
+	if m == nil {
+		return
+	}
+	m.sessionsActive.Set(float64(n), station)
+}
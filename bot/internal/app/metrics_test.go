@@ -0,0 +1,40 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppMetricsByStation(t *testing.T) {
+	m := newAppMetrics()
+	m.observeEPC("line-1", "3034ABCD", "MATCH")
+	m.observeEPC("line-1", "", "UNKNOWN")
+	m.observeDraft("line-1", time.Now(), errors.New("erp down"))
+	m.setSessions("line-1", 2)
+
+	if got := m.epcResults.Value("line-1", "NONE"); got != 1 {
+		t.Fatalf("NONE=%v, want 1", got)
+	}
+	if got := m.draftErrors.Value("line-1"); got != 1 {
+		t.Fatalf("draft errors=%v, want 1", got)
+	}
+
+	var b strings.Builder
+	if err := m.registry.Write(&b); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	for _, want := range []string{
+		`gscale_batch_sessions_active{station="line-1"} 2`,
+		`gscale_batch_epc_total{station="line-1",verify="MATCH"} 1`,
+		`gscale_erp_draft_seconds_count{station="line-1"} 1`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, b.String())
+		}
+	}
+
+	var nilMetrics *appMetrics
+	nilMetrics.observeEPC("x", "", "")
+}
@@ -1,7 +1,10 @@
 package app
 
 import (
+	"bridge/metrics"
 	"context"
+	corepkg "core"
+	"strings"
 	"time"
 
 	"bot/internal/app/commands"
@@ -9,10 +12,29 @@ import (
 )
 
 func (a *App) Run(ctx context.Context) error {
-	a.setBatchState(false, 0, SelectedContext{})
-	a.logRun.Printf("bot started, ERP=%s bridge_state=%s", a.cfg.ERPURL, a.cfg.BridgeStateFile)
+	a.resetBatchStates()
+	a.logRun.Printf("bot started, ERP=%s bridge_backend=%s bridge_state=%s bridge_journal=%s bridge_socket=%s station=%s", a.cfg.ERPURL, a.cfg.BridgeBackend, a.cfg.BridgeStateFile, a.cfg.BridgeJournalDir, a.cfg.BridgeSocket, a.defaultStation())
+	if h := a.bridgeStore.Health(); !h.OK || h.LastRecovery != nil {
+		a.logRun.Printf("bridge state warning: %s", h.Summary())
+	}
+	if addr := strings.TrimSpace(a.cfg.MetricsAddr); addr != "" {
+		if bound, err := metrics.Start(ctx, addr, a.metrics.registry); err != nil {
+			a.logRun.Printf("metrics warning: %v", err)
+		} else {
+			a.logRun.Printf("metrics started: addr=%s", bound)
+		}
+	}
+	if path := strings.TrimSpace(a.cfg.EPCRegistryFile); path != "" {
+		if reg, err := corepkg.OpenEPCRegistry(path); err != nil {
+			a.logRun.Printf("epc registry warning: %v", err)
+		} else {
+			a.epcRegistry = reg
+			a.logRun.Printf("epc registry: file=%s records=%d", reg.Path(), reg.Len())
+		}
+	}
+	defer a.bridgeStore.Close()
 	defer a.stopAllBatchSessions()
-	defer a.setBatchState(false, 0, SelectedContext{})
+	defer a.resetBatchStates()
 	var offset int64
 
 	for {
@@ -0,0 +1,52 @@
+package app
+
+import (
+	bridgestate "bridge/state"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// scaleCommandOps bot buyrug'i -> indikator buyrug'i.
+var scaleCommandOps = map[string]string{
+	"/tare":      bridgestate.ScaleOpTare,
+	"/zero":      bridgestate.ScaleOpZero,
+	"/cleartare": bridgestate.ScaleOpClearTare,
+}
+
+// handleScaleCommand /tare, /zero, /cleartare: chat stansiyasidagi indikatorga buyruq
+// yuboradi va scale tasdig'ini (bridge snapshot `command`) kutadi.
+func (a *App) handleScaleCommand(ctx context.Context, chatID int64, cmd string) error {
+	op, ok := scaleCommandOps[cmd]
+	if !ok {
+		return fmt.Errorf("scale buyrug'i noma'lum: %s", cmd)
+	}
+	station := a.stationFor(chatID)
+	res, err := a.station(station).qtyReader.RequestScaleCommand(ctx, op, fmt.Sprintf("chat:%d", chatID))
+	if err != nil {
+		a.logBatch.Printf("scale command error: chat=%d station=%s op=%s err=%v", chatID, station, op, err)
+		return a.tg.SendMessage(ctx, chatID, fmt.Sprintf("%s yuborilmadi: %v", scaleCommandTitle(op), err))
+	}
+	a.logBatch.Printf("scale command: chat=%d station=%s op=%s id=%s status=%s err=%s", chatID, station, op, res.ID, res.Status, res.Error)
+	return a.tg.SendMessage(ctx, chatID, formatScaleCommandResult(station, res))
+}
+
+func scaleCommandTitle(op string) string {
+	switch op {
+	case bridgestate.ScaleOpTare:
+		return "Tara"
+	case bridgestate.ScaleOpZero:
+		return "Nol"
+	case bridgestate.ScaleOpClearTare:
+		return "Tarani tozalash"
+	}
+	return op
+}
+
+func formatScaleCommandResult(station string, res bridgestate.ScaleCommandSnapshot) string {
+	title := scaleCommandTitle(res.Op)
+	if res.Status != bridgestate.ScaleCommandOK {
+		return fmt.Sprintf("%s bajarilmadi (%s): %s", title, station, strings.TrimSpace(res.Error))
+	}
+	return fmt.Sprintf("%s bajarildi (%s)", title, station)
+}
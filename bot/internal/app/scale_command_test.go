@@ -0,0 +1,22 @@
+package app
+
+import (
+	bridgestate "bridge/state"
+	"testing"
+)
+
+func TestFormatScaleCommandResult(t *testing.T) {
+	ok := bridgestate.ScaleCommandSnapshot{Op: bridgestate.ScaleOpZero, Status: bridgestate.ScaleCommandOK}
+	if got := formatScaleCommandResult("line-1", ok); got != "Nol bajarildi (line-1)" {
+		t.Fatalf("ok: %q", got)
+	}
+	fail := bridgestate.ScaleCommandSnapshot{Op: bridgestate.ScaleOpTare, Status: bridgestate.ScaleCommandError, Error: "tare: hozir bajarilmaydi (I)"}
+	if got := formatScaleCommandResult("default", fail); got != "Tara bajarilmadi (default): tare: hozir bajarilmaydi (I)" {
+		t.Fatalf("error: %q", got)
+	}
+	for cmd, op := range scaleCommandOps {
+		if scaleCommandTitle(op) == op {
+			t.Fatalf("%s: title missing", cmd)
+		}
+	}
+}
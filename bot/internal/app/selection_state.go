@@ -15,6 +15,16 @@ func (a *App) rememberSelection(chatID int64, itemCode, itemName, warehouse stri
 	a.selectionByChat[chatID] = SelectedContext{ItemCode: itemCode, ItemName: itemName, Warehouse: warehouse}
 }
 
+// rememberGTIN batch boshlanishida ERP'dan olingan GTIN'ni tanlovga qo'shadi (item o'zgarmagan bo'lsa).
+func (a *App) rememberGTIN(chatID int64, itemCode, gtin string) {
+	v, ok := a.selectionByChat[chatID]
+	if !ok || strings.TrimSpace(v.ItemCode) != strings.TrimSpace(itemCode) {
+		return
+	}
+	v.GTIN = strings.TrimSpace(gtin)
+	a.selectionByChat[chatID] = v
+}
+
 func (a *App) rememberItemChoice(chatID int64, itemCode, itemName string) {
 	itemCode = strings.TrimSpace(itemCode)
 	itemName = strings.TrimSpace(itemName)
@@ -0,0 +1,68 @@
+package app
+
+import (
+	bridgestate "bridge/state"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// handleStationCommand: /station - stansiyalar ro'yxati, /station <id> - chat uchun tanlash.
+func (a *App) handleStationCommand(ctx context.Context, chatID int64, text string) error {
+	fields := strings.Fields(strings.TrimSpace(text))
+	if len(fields) < 2 {
+		return a.tg.SendMessage(ctx, chatID, a.formatStationList(chatID))
+	}
+
+	id := bridgestate.NormalizeStationID(fields[1])
+	if err := bridgestate.ValidateStationID(id); err != nil {
+		return a.tg.SendMessage(ctx, chatID, "Stansiya ID noto'g'ri: "+err.Error())
+	}
+	if cur, ok := a.sessionStation(chatID); ok && cur != id {
+		return a.tg.SendMessage(ctx, chatID, fmt.Sprintf("Batch %s stansiyasida ishlayapti. Avval Batch Stop qiling.", cur))
+	}
+	a.selectStation(chatID, id)
+	return a.tg.SendMessage(ctx, chatID, fmt.Sprintf("Stansiya tanlandi: %s", id))
+}
+
+func (a *App) formatStationList(chatID int64) string {
+	current := a.stationFor(chatID)
+	snaps := map[string]bridgestate.Snapshot{}
+	if a.bridgeStore != nil {
+		if list, err := a.bridgeStore.Stations(); err == nil {
+			for _, snap := range list {
+				snaps[snap.StationID] = snap
+			}
+		}
+	}
+
+	lines := []string{"Stansiyalar:"}
+	for _, id := range a.knownStations() {
+		lines = append(lines, formatStationLine(id, snaps[id], id == current))
+	}
+	lines = append(lines, "", "Tanlash: /station <id>")
+	return strings.Join(lines, "\n")
+}
+
+func formatStationLine(id string, snap bridgestate.Snapshot, current bool) string {
+	mark := "  "
+	if current {
+		mark = "> "
+	}
+	qty := "-"
+	if w := snap.Scale.Qty(); w != nil {
+		unit := strings.TrimSpace(snap.Scale.Unit)
+		if unit == "" {
+			unit = "kg"
+		}
+		qty = fmt.Sprintf("%.3f %s", *w, unit)
+	}
+	batch := "stop"
+	if snap.Batch.Active {
+		batch = "active"
+		if name := strings.TrimSpace(snap.Batch.ItemName); name != "" {
+			batch += " (" + name + ")"
+		}
+	}
+	return fmt.Sprintf("%s%s: qty=%s, batch=%s", mark, id, qty, batch)
+}
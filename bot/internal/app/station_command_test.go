@@ -0,0 +1,21 @@
+package app
+
+import (
+	bridgestate "bridge/state"
+	"testing"
+)
+
+func TestFormatStationLine(t *testing.T) {
+	w := 12.5
+	snap := bridgestate.Snapshot{
+		StationID: "line-2",
+		Scale:     bridgestate.ScaleSnapshot{Weight: &w, Unit: "kg"},
+		Batch:     bridgestate.BatchSnapshot{Active: true, ItemName: "GRENKI"},
+	}
+	if got := formatStationLine("line-2", snap, true); got != "> line-2: qty=12.500 kg, batch=active (GRENKI)" {
+		t.Fatalf("line mismatch: %q", got)
+	}
+	if got := formatStationLine("default", bridgestate.Snapshot{}, false); got != "  default: qty=-, batch=stop" {
+		t.Fatalf("empty line mismatch: %q", got)
+	}
+}
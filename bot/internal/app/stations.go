@@ -0,0 +1,93 @@
+package app
+
+import (
+	"bridge/ipc"
+	bridgestate "bridge/state"
+	"sort"
+	"strings"
+
+	"bot/internal/batchstate"
+	"bot/internal/bridgeclient"
+)
+
+// stationLink bitta scale+printer stansiyasi bilan ishlash uchun client'lar.
+type stationLink struct {
+	id         string
+	qtyReader  *bridgeclient.Client
+	batchState *batchstate.Store
+}
+
+// station id bo'yicha link'ni qaytaradi (birinchi murojaatda yaratiladi).
+func (a *App) station(id string) *stationLink {
+	id = bridgestate.NormalizeStationID(id)
+
+	a.stationMu.Lock()
+	defer a.stationMu.Unlock()
+	if l, ok := a.stations[id]; ok {
+		return l
+	}
+
+	store := a.bridgeStore.Station(id)
+	var bus *ipc.Client
+	if sock := strings.TrimSpace(a.cfg.BridgeSocket); sock != "" {
+		bus = ipc.NewClient(ipc.SocketPathForStation(sock, id))
+	}
+	l := &stationLink{
+		id:         id,
+		qtyReader:  bridgeclient.NewFromStore(store, bus),
+		batchState: batchstate.NewFromStore(store, bus),
+	}
+	a.stations[id] = l
+	return l
+}
+
+func (a *App) defaultStation() string {
+	return bridgestate.NormalizeStationID(a.cfg.BridgeStation)
+}
+
+// stationFor chat tanlagan stansiyani, tanlanmagan bo'lsa default'ni qaytaradi.
+func (a *App) stationFor(chatID int64) string {
+	a.stationMu.Lock()
+	defer a.stationMu.Unlock()
+	if id, ok := a.stationByChat[chatID]; ok {
+		return id
+	}
+	return a.defaultStation()
+}
+
+func (a *App) selectStation(chatID int64, id string) {
+	id = bridgestate.NormalizeStationID(id)
+	a.stationMu.Lock()
+	defer a.stationMu.Unlock()
+	if id == a.defaultStation() {
+		delete(a.stationByChat, chatID)
+		return
+	}
+	a.stationByChat[chatID] = id
+}
+
+// knownStations bridge fayldagi va bot ishlatgan barcha stansiyalar.
+func (a *App) knownStations() []string {
+	seen := map[string]bool{a.defaultStation(): true}
+	out := []string{a.defaultStation()}
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	if a.bridgeStore != nil {
+		if list, err := a.bridgeStore.Stations(); err == nil {
+			for _, snap := range list {
+				add(snap.StationID)
+			}
+		}
+	}
+	a.stationMu.Lock()
+	for id := range a.stations {
+		add(id)
+	}
+	a.stationMu.Unlock()
+	sort.Strings(out[1:])
+	return out
+}
@@ -1,13 +1,28 @@
 package batchstate
 
 import (
+	"bridge/ipc"
 	bridgestate "bridge/state"
+	"context"
+	corepkg "core"
+	"errors"
+	"fmt"
+	"os"
 	"strings"
 	"time"
 )
 
+const (
+	busRequestTimeout = 2 * time.Second
+	// casAttempts scale tez-tez yozgani uchun conflict'da qayta urinishlar soni.
+	casAttempts = 8
+)
+
 type Store struct {
 	store *bridgestate.Store
+	// bus bo'lsa batch holati scale'ga bus orqali yuboriladi (scale o'zi yozadi);
+	// ulanish bo'lmasa yoki xato bo'lsa to'g'ridan-to'g'ri faylga yoziladi.
+	bus *ipc.Client
 }
 
 func New(path string) *Store {
@@ -18,7 +33,14 @@ func New(path string) *Store {
 	return &Store{store: bridgestate.New(path)}
 }
 
-func (s *Store) Set(active bool, chatID int64, itemCode, itemName, warehouse string) error {
+func NewFromStore(store *bridgestate.Store, bus *ipc.Client) *Store {
+	return &Store{store: store, bus: bus}
+}
+
+// Set batch holatini yozadi. count.UOM bo'sh bo'lmasa item dona bilan beriladi
+// (counting rejimi); dona og'irligi ham shu yerda scale'ga yetkaziladi. gtin - item GTIN'i
+// (scale SGTIN-96 EPC uchun), bo'sh bo'lishi mumkin.
+func (s *Store) Set(active bool, chatID int64, itemCode, itemName, warehouse, gtin string, count corepkg.PieceCounter) error {
 	if s == nil || s.store == nil || strings.TrimSpace(s.store.Path()) == "" {
 		return nil
 	}
@@ -30,19 +52,64 @@ func (s *Store) Set(active bool, chatID int64, itemCode, itemName, warehouse str
 		itemName = itemCode
 	}
 
-	at := time.Now().UTC().Format(time.RFC3339Nano)
-	return s.store.Update(func(snapshot *bridgestate.Snapshot) {
-		snapshot.Batch.Active = active
-		snapshot.Batch.ChatID = chatID
-		if active {
-			snapshot.Batch.ItemCode = itemCode
-			snapshot.Batch.ItemName = itemName
-			snapshot.Batch.Warehouse = warehouse
-		} else {
-			snapshot.Batch.ItemCode = ""
-			snapshot.Batch.ItemName = ""
-			snapshot.Batch.Warehouse = ""
+	batch := bridgestate.BatchSnapshot{Active: active, ChatID: chatID}
+	if active {
+		batch.ItemCode = itemCode
+		batch.ItemName = itemName
+		batch.Warehouse = warehouse
+		batch.GTIN = strings.TrimSpace(gtin)
+		if uom := strings.TrimSpace(count.UOM); uom != "" {
+			batch.CountUOM = uom
+			batch.UnitWeight = count.UnitWeight
+			batch.UnitWeightSource = count.Source
+		}
+	}
+
+	if s.bus != nil && s.sendBus(batch) == nil {
+		return nil
+	}
+
+	return s.setFile(batch)
+}
+
+// setFile batch bo'limini CompareAndUpdate bilan yozadi. Scale oraliqda faqat
+// scale/zebra bo'limlarini yangilagan bo'lsa qayta uriniladi; batch'ning o'zi
+// boshqa tomonda o'zgargan bo'lsa ErrConflict qaytadi va ustidan yozilmaydi.
+func (s *Store) setFile(batch bridgestate.BatchSnapshot) error {
+	var seen *bridgestate.BatchSnapshot
+	var err error
+	for attempt := 0; attempt < casAttempts; attempt++ {
+		snap, rerr := s.store.Read()
+		if rerr != nil && !errors.Is(rerr, os.ErrNotExist) {
+			return rerr
+		}
+		if seen == nil {
+			b := snap.Batch
+			seen = &b
+		} else if snap.Batch != *seen {
+			return fmt.Errorf("batch holati boshqa process tomonidan o'zgartirildi: %w", bridgestate.ErrConflict)
+		}
+
+		at := time.Now().UTC().Format(time.RFC3339Nano)
+		batch.UpdatedAt = at
+		err = s.store.CompareAndUpdate(snap.Revision, func(snapshot *bridgestate.Snapshot) {
+			snapshot.Batch = batch
+		})
+		if !errors.Is(err, bridgestate.ErrConflict) {
+			return err
 		}
-		snapshot.Batch.UpdatedAt = at
-	})
+	}
+	return err
+}
+
+func (s *Store) sendBus(batch bridgestate.BatchSnapshot) error {
+	ctx, cancel := context.WithTimeout(context.Background(), busRequestTimeout)
+	defer cancel()
+
+	msg := ipc.Message{Type: ipc.TypeBatchStop, Batch: &batch}
+	if batch.Active {
+		msg.Type = ipc.TypeBatchStart
+	}
+	_, err := s.bus.Request(ctx, msg)
+	return err
 }
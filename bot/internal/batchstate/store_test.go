@@ -2,6 +2,7 @@ package batchstate
 
 import (
 	bridgestate "bridge/state"
+	corepkg "core"
 	"path/filepath"
 	"testing"
 )
@@ -11,7 +12,7 @@ func TestSetWritesSnapshot(t *testing.T) {
 	p := filepath.Join(d, "bridge_state.json")
 
 	s := New(p)
-	if err := s.Set(true, 123, "ITM-001", "GRENKI YASHIL", "Stores - A"); err != nil {
+	if err := s.Set(true, 123, "ITM-001", "GRENKI YASHIL", "Stores - A", "", corepkg.PieceCounter{}); err != nil {
 		t.Fatalf("Set error: %v", err)
 	}
 
@@ -39,15 +40,39 @@ func TestSetWritesSnapshot(t *testing.T) {
 	}
 }
 
+func TestSetWritesCountMode(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	s := New(p)
+	count := corepkg.PieceCounter{UOM: "Nos", UnitWeight: 0.125, Source: corepkg.UnitWeightSample}
+	if err := s.Set(true, 123, "ITM-001", "BOLT", "Stores - A", "80614141123458", count); err != nil {
+		t.Fatalf("Set error: %v", err)
+	}
+	got, err := bridgestate.New(p).Read()
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if got.Batch.CountUOM != "Nos" || got.Batch.UnitWeight != 0.125 || got.Batch.UnitWeightSource != corepkg.UnitWeightSample || got.Batch.GTIN != "80614141123458" {
+		t.Fatalf("count fields: %+v", got.Batch)
+	}
+
+	if err := s.Set(false, 123, "", "", "", "80614141123458", count); err != nil {
+		t.Fatalf("Set inactive error: %v", err)
+	}
+	got, _ = bridgestate.New(p).Read()
+	if got.Batch.CountUOM != "" || got.Batch.UnitWeight != 0 || got.Batch.GTIN != "" {
+		t.Fatalf("count fields not cleared: %+v", got.Batch)
+	}
+}
+
 func TestSetInactiveClearsItemFields(t *testing.T) {
 	d := t.TempDir()
 	p := filepath.Join(d, "bridge_state.json")
 
 	s := New(p)
-	if err := s.Set(true, 123, "ITM-001", "ITEM", "Stores - A"); err != nil {
+	if err := s.Set(true, 123, "ITM-001", "ITEM", "Stores - A", "", corepkg.PieceCounter{}); err != nil {
 		t.Fatalf("Set active error: %v", err)
 	}
-	if err := s.Set(false, 123, "", "", ""); err != nil {
+	if err := s.Set(false, 123, "", "", "", "", corepkg.PieceCounter{}); err != nil {
 		t.Fatalf("Set inactive error: %v", err)
 	}
 
@@ -62,3 +87,33 @@ func TestSetInactiveClearsItemFields(t *testing.T) {
 		t.Fatalf("item fields not cleared: %+v", got.Batch)
 	}
 }
+
+func TestSetRetriesWhenOnlyScaleChanged(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	bridge := bridgestate.New(p)
+	s := New(p)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w := 0.0
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			w += 0.001
+			v := w
+			_ = bridge.Update(func(snap *bridgestate.Snapshot) { snap.Scale.Weight = &v })
+		}
+	}()
+	defer func() { close(stop); <-done }()
+
+	for i := 0; i < 20; i++ {
+		if err := s.Set(i%2 == 0, 7, "ITM", "ITEM", "WH", "", corepkg.PieceCounter{}); err != nil {
+			t.Fatalf("Set #%d error: %v", i, err)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package bridgeclient
 
 import (
+	"bridge/ipc"
 	bridgestate "bridge/state"
 	"context"
+	corepkg "core"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
@@ -16,12 +19,26 @@ const nextCycleDeltaEpsilon = 0.005
 
 type Client struct {
 	store *bridgestate.Store
+	// bus ixtiyoriy: ulanish bo'lsa zebra natijalari event sifatida tezroq keladi,
+	// bo'lmasa hammasi bridge state fayli orqali ishlaydi.
+	bus *ipc.Client
 }
 
+// StableReading Qty - netto (ERP qty); tara bo'lmasa Gross bilan bir xil.
 type StableReading struct {
-	Qty       float64
-	Unit      string
-	UpdatedAt time.Time
+	Qty        float64
+	Gross      float64
+	Tare       float64
+	TareSource string
+	Unit       string
+	UpdatedAt  time.Time
+	// ZeroAlarm scale zero-tracking ogohlantirishi; ZeroBlock bo'lsa scale EPC bermaydi.
+	ZeroAlarm string
+	ZeroBlock bool
+	// Check scale check-weigh natijasi (Class bo'sh = item uchun qoida yo'q); CheckBlock bo'lsa
+	// scale EPC bermaydi (--checkweigh-policy block).
+	Check      corepkg.CheckResult
+	CheckBlock bool
 }
 
 type EPCReading struct {
@@ -36,6 +53,10 @@ func New(path string) *Client {
 	return &Client{store: bridgestate.New(path)}
 }
 
+func NewFromStore(store *bridgestate.Store, bus *ipc.Client) *Client {
+	return &Client{store: store, bus: bus}
+}
+
 func (c *Client) WaitStablePositive(ctx context.Context, timeout, pollInterval time.Duration) (float64, string, error) {
 	r, err := c.WaitStablePositiveReading(ctx, timeout, pollInterval)
 	if err != nil {
@@ -54,66 +75,77 @@ func (c *Client) WaitStablePositiveReading(ctx context.Context, timeout, pollInt
 	if pollInterval <= 0 {
 		pollInterval = 220 * time.Millisecond
 	}
+	// Scale ST flag bermasa, qty shu oyna davomida o'zgarmasa stable deb olinadi
+	// (eski polling'dagi "4 ta ketma-ket bir xil o'qish" ga teng).
+	stableHold := 3 * pollInterval
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	updates := c.store.WatchWithFallback(waitCtx, pollInterval)
 
-	deadline := time.Now().Add(timeout)
 	var lastWeight float64
-	var haveLast bool
-	stableCount := 0
+	var lastAt time.Time
+	var heldSince time.Time
+	haveLast := false
 
 	for {
-		if time.Now().After(deadline) {
-			return StableReading{}, fmt.Errorf("scale qty timeout (%s)", timeout)
-		}
-		select {
-		case <-ctx.Done():
-			return StableReading{}, ctx.Err()
-		default:
-		}
-
-		snap, err := c.store.Read()
+		snap, err := nextSnapshot(ctx, waitCtx, updates)
 		if err != nil {
-			time.Sleep(pollInterval)
-			continue
+			if isWaitTimeout(ctx, err) {
+				return StableReading{}, fmt.Errorf("scale qty timeout (%s)", timeout)
+			}
+			return StableReading{}, err
 		}
+
 		s := snap.Scale
-		if strings.TrimSpace(s.Error) != "" {
-			haveLast = false
-			stableCount = 0
-			time.Sleep(pollInterval)
-			continue
-		}
-		if s.Weight == nil || *s.Weight <= 0 {
+		qty := s.Qty()
+		if strings.TrimSpace(s.Error) != "" || qty == nil || *qty <= 0 {
 			haveLast = false
-			stableCount = 0
-			time.Sleep(pollInterval)
 			continue
 		}
 		updatedAt, ok := parseSnapshotTime(s.UpdatedAt)
 		if !ok || !isFreshTime(updatedAt, 4*time.Second) {
-			time.Sleep(pollInterval)
+			continue
+		}
+		// Zebra yozuvlari ham snapshot'ni yangilaydi; faqat yangi scale o'qishini hisoblaymiz.
+		if haveLast && updatedAt.Equal(lastAt) {
 			continue
 		}
 
-		w := *s.Weight
+		w := *qty
 		if s.Stable != nil && *s.Stable {
-			return StableReading{Qty: w, Unit: normalizeUnit(s.Unit), UpdatedAt: updatedAt}, nil
+			return stableReadingOf(s, w, updatedAt), nil
 		}
 
-		if haveLast && almostEqual(lastWeight, w, 0.001) {
-			stableCount++
-		} else {
-			stableCount = 1
+		if !haveLast || !almostEqual(lastWeight, w, 0.001) {
+			heldSince = updatedAt
 		}
 		haveLast = true
 		lastWeight = w
+		lastAt = updatedAt
 
-		if stableCount >= 4 {
-			return StableReading{Qty: w, Unit: normalizeUnit(s.Unit), UpdatedAt: updatedAt}, nil
+		if updatedAt.Sub(heldSince) >= stableHold {
+			return stableReadingOf(s, w, updatedAt), nil
 		}
-		time.Sleep(pollInterval)
 	}
 }
 
+func stableReadingOf(s bridgestate.ScaleSnapshot, qty float64, at time.Time) StableReading {
+	out := StableReading{Qty: qty, Gross: qty, TareSource: s.TareSource, Unit: normalizeUnit(s.Unit), UpdatedAt: at,
+		ZeroAlarm: strings.TrimSpace(s.ZeroAlarm), ZeroBlock: s.ZeroBlock}
+	if s.Gross != nil {
+		out.Gross = *s.Gross
+	}
+	if s.Tare != nil {
+		out.Tare = *s.Tare
+	}
+	if s.Check != nil {
+		out.Check = corepkg.CheckResult{Class: corepkg.CheckClass(s.Check.Class), Nominal: s.Check.Nominal, Deviation: s.Check.Deviation}
+		out.CheckBlock = s.Check.Block
+	}
+	return out
+}
+
 func (c *Client) WaitEPCForReading(ctx context.Context, timeout, pollInterval time.Duration, after time.Time, lastEPC string) (EPCReading, error) {
 	if c == nil || c.store == nil || strings.TrimSpace(c.store.Path()) == "" {
 		return EPCReading{}, fmt.Errorf("bridge state path bo'sh")
@@ -126,54 +158,76 @@ func (c *Client) WaitEPCForReading(ctx context.Context, timeout, pollInterval ti
 	}
 	lastEPC = strings.ToUpper(strings.TrimSpace(lastEPC))
 
-	deadline := time.Now().Add(timeout)
-	for {
-		if time.Now().After(deadline) {
-			return EPCReading{}, fmt.Errorf("epc timeout (%s)", timeout)
-		}
-		select {
-		case <-ctx.Done():
-			return EPCReading{}, ctx.Err()
-		default:
-		}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	updates := c.store.WatchWithFallback(waitCtx, pollInterval)
 
-		snap, err := c.store.Read()
-		if err != nil {
-			time.Sleep(pollInterval)
-			continue
-		}
-
-		epc := strings.ToUpper(strings.TrimSpace(snap.Zebra.LastEPC))
-		if epc == "" || epc == lastEPC {
-			time.Sleep(pollInterval)
-			continue
-		}
+	// Bus bo'lsa zebra_result event'lari ham tinglanadi; qaysi biri birinchi kelsa o'sha olinadi.
+	var events <-chan ipc.Message
+	if ch, unsubscribe, err := c.bus.Subscribe(8); err == nil {
+		defer unsubscribe()
+		events = ch
+	}
 
-		epcAt, ok := parseSnapshotTime(snap.Zebra.UpdatedAt)
-		if ok {
-			if !isFreshTime(epcAt, 15*time.Second) {
-				time.Sleep(pollInterval)
+	for {
+		var z bridgestate.ZebraSnapshot
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return EPCReading{}, ctx.Err()
+			}
+			return EPCReading{}, fmt.Errorf("epc timeout (%s)", timeout)
+		case msg, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if msg.Type != ipc.TypeZebraResult || msg.Zebra == nil {
 				continue
 			}
-			if !after.IsZero() && epcAt.Before(after.Add(-300*time.Millisecond)) {
-				time.Sleep(pollInterval)
+			z = *msg.Zebra
+		case snap, ok := <-updates:
+			if !ok {
+				updates = nil
 				continue
 			}
+			z = snap.Zebra
+		}
+		if r, ok := matchEPC(z, after, lastEPC); ok {
+			return r, nil
 		}
+	}
+}
 
-		verify := strings.ToUpper(strings.TrimSpace(snap.Zebra.Verify))
-		if verify == "" {
-			verify = "UNKNOWN"
+// matchEPC zebra snapshot'i after dan keyingi yangi EPC ekanini tekshiradi.
+func matchEPC(z bridgestate.ZebraSnapshot, after time.Time, lastEPC string) (EPCReading, bool) {
+	epc := strings.ToUpper(strings.TrimSpace(z.LastEPC))
+	if epc == "" || epc == lastEPC {
+		return EPCReading{}, false
+	}
+
+	epcAt, ok := parseSnapshotTime(z.UpdatedAt)
+	if ok {
+		if !isFreshTime(epcAt, 15*time.Second) {
+			return EPCReading{}, false
 		}
+		if !after.IsZero() && epcAt.Before(after.Add(-300*time.Millisecond)) {
+			return EPCReading{}, false
+		}
+	}
 
-		return EPCReading{
-			EPC:       epc,
-			Verify:    verify,
-			ReadLine1: strings.TrimSpace(snap.Zebra.ReadLine1),
-			ReadLine2: strings.TrimSpace(snap.Zebra.ReadLine2),
-			UpdatedAt: epcAt,
-		}, nil
+	verify := strings.ToUpper(strings.TrimSpace(z.Verify))
+	if verify == "" {
+		verify = "UNKNOWN"
 	}
+
+	return EPCReading{
+		EPC:       epc,
+		Verify:    verify,
+		ReadLine1: strings.TrimSpace(z.ReadLine1),
+		ReadLine2: strings.TrimSpace(z.ReadLine2),
+		UpdatedAt: epcAt,
+	}, true
 }
 
 // WaitForNextCycle returns when scale goes to reset (<=0) OR weight
@@ -189,37 +243,51 @@ func (c *Client) WaitForNextCycle(ctx context.Context, timeout, pollInterval tim
 		pollInterval = 220 * time.Millisecond
 	}
 
-	deadline := time.Now().Add(timeout)
-	for {
-		if time.Now().After(deadline) {
-			return fmt.Errorf("scale next-cycle timeout (%s)", timeout)
-		}
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	updates := c.store.WatchWithFallback(waitCtx, pollInterval)
 
-		snap, err := c.store.Read()
+	for {
+		snap, err := nextSnapshot(ctx, waitCtx, updates)
 		if err != nil {
-			time.Sleep(pollInterval)
-			continue
+			if isWaitTimeout(ctx, err) {
+				return fmt.Errorf("scale next-cycle timeout (%s)", timeout)
+			}
+			return err
 		}
 		s := snap.Scale
 		if !isFreshSnapshot(s.UpdatedAt, 4*time.Second) {
-			time.Sleep(pollInterval)
 			continue
 		}
-		if s.Weight == nil || *s.Weight <= 0 {
+		qty := s.Qty()
+		if qty == nil || *qty <= 0 {
 			return nil
 		}
 		// Oxirgi qty dan ma'noli og'ish bo'lsa yangi sikl boshlangan deb olamiz.
-		if lastQty > 0 && math.Abs(*s.Weight-lastQty) > nextCycleDeltaEpsilon {
+		if lastQty > 0 && math.Abs(*qty-lastQty) > nextCycleDeltaEpsilon {
 			return nil
 		}
+	}
+}
 
-		time.Sleep(pollInterval)
+// nextSnapshot watch kanalidan keyingi snapshot'ni oladi.
+// Kanal yopilsa (waitCtx tugagan) mos context xatosi qaytadi.
+func nextSnapshot(ctx, waitCtx context.Context, updates <-chan bridgestate.Snapshot) (bridgestate.Snapshot, error) {
+	select {
+	case <-waitCtx.Done():
+	case snap, ok := <-updates:
+		if ok {
+			return snap, nil
+		}
 	}
+	if err := ctx.Err(); err != nil {
+		return bridgestate.Snapshot{}, err
+	}
+	return bridgestate.Snapshot{}, context.DeadlineExceeded
+}
+
+func isWaitTimeout(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded)
 }
 
 func isFreshSnapshot(updated string, maxAge time.Duration) bool {
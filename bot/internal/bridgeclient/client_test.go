@@ -1,6 +1,7 @@
 package bridgeclient
 
 import (
+	"bridge/ipc"
 	bridgestate "bridge/state"
 	"context"
 	"path/filepath"
@@ -37,6 +38,38 @@ func TestWaitStablePositiveReading_Stable(t *testing.T) {
 	}
 }
 
+func TestWaitStablePositiveReading_UsesNet(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	s := bridgestate.New(p)
+	gross, tare, net := 12.5, 2.5, 10.0
+	st := true
+	if err := s.Update(func(snapshot *bridgestate.Snapshot) {
+		snapshot.Scale.Weight = &gross
+		snapshot.Scale.Gross = &gross
+		snapshot.Scale.Tare = &tare
+		snapshot.Scale.Net = &net
+		snapshot.Scale.TareSource = "button"
+		snapshot.Scale.ZeroAlarm = "DRIFT +0.012 (limit 0.005)"
+		snapshot.Scale.ZeroBlock = true
+		snapshot.Scale.Check = &bridgestate.ScaleCheck{Class: "OVER", Nominal: 9.5, Deviation: 0.5, Block: true}
+		snapshot.Scale.Stable = &st
+		snapshot.Scale.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(p).WaitStablePositiveReading(context.Background(), 2*time.Second, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Qty != 10 || r.Gross != 12.5 || r.Tare != 2.5 || r.TareSource != "button" || !r.ZeroBlock || r.ZeroAlarm == "" {
+		t.Fatalf("reading mismatch: %+v", r)
+	}
+	if !r.CheckBlock || r.Check.String() != "OVER +0.500 (nominal 9.500)" {
+		t.Fatalf("check mismatch: %+v", r)
+	}
+}
+
 func TestWaitEPCForReading(t *testing.T) {
 	d := t.TempDir()
 	p := filepath.Join(d, "bridge_state.json")
@@ -130,3 +163,83 @@ func TestWaitForNextCycle_IgnoresTinyJitter(t *testing.T) {
 		t.Fatal("expected timeout, got nil")
 	}
 }
+
+func TestWaitEPCForReading_FromBusEvent(t *testing.T) {
+	d := t.TempDir()
+	srv, err := ipc.Listen(filepath.Join(d, "bridge.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = srv.Serve(ctx) }()
+
+	bus := ipc.NewClient(srv.Path())
+	defer bus.Close()
+	// Bridge state fayli umuman yo'q: EPC faqat bus event'idan kelishi kerak.
+	c := NewFromStore(bridgestate.New(filepath.Join(d, "bridge_state.json")), bus)
+
+	go func() {
+		for i := 0; i < 20; i++ {
+			time.Sleep(25 * time.Millisecond)
+			srv.Publish(ipc.Message{Type: ipc.TypeZebraResult, Zebra: &bridgestate.ZebraSnapshot{
+				LastEPC:   "3034257BF7194E406994036C",
+				Verify:    "MATCH",
+				Action:    "encode",
+				UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+			}})
+		}
+	}()
+
+	got, err := c.WaitEPCForReading(ctx, time.Second, time.Hour, time.Now().Add(-time.Second), "")
+	if err != nil {
+		t.Fatalf("WaitEPCForReading error: %v", err)
+	}
+	if got.EPC != "3034257BF7194E406994036C" || got.Verify != "MATCH" {
+		t.Fatalf("epc mismatch: %+v", got)
+	}
+}
+
+func TestRequestScaleCommand_FileAck(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	s := bridgestate.New(p)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Scale tomoni: pending buyruqni ko'rib natija yozadi.
+	go func() {
+		for snap := range s.WatchWithFallback(ctx, 20*time.Millisecond) {
+			if cmd := snap.Command; cmd.Pending() {
+				done := *cmd
+				done.Status = bridgestate.ScaleCommandOK
+				done.DoneAt = time.Now().UTC().Format(time.RFC3339Nano)
+				_ = s.Update(func(sn *bridgestate.Snapshot) { sn.Command = &done })
+			}
+		}
+	}()
+
+	res, err := New(p).RequestScaleCommand(ctx, bridgestate.ScaleOpZero, "chat:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != bridgestate.ScaleCommandOK || res.Op != bridgestate.ScaleOpZero || res.RequestedBy != "chat:1" || res.DoneAt == "" {
+		t.Fatalf("result: %+v", res)
+	}
+}
+
+func TestRequestScaleCommand_ExpiresWhenUnanswered(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	if _, err := New(p).RequestScaleCommand(ctx, bridgestate.ScaleOpZero, "chat:1"); err == nil {
+		t.Fatal("unanswered command must fail")
+	}
+	snap, err := bridgestate.New(p).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Command == nil || snap.Command.Status != bridgestate.ScaleCommandExpired || snap.Command.DoneAt == "" {
+		t.Fatalf("command: %+v", snap.Command)
+	}
+}
@@ -0,0 +1,80 @@
+package bridgeclient
+
+import (
+	"bridge/ipc"
+	bridgestate "bridge/state"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var scaleCommandSeq atomic.Uint64
+
+// RequestScaleCommand indikatorga tare/zero/clear_tare so'raydi. Bus bo'lsa so'rov bus orqali,
+// bo'lmasa bridge state'ga pending buyruq yoziladi va scale snapshot'da tasdiqlashini kutadi.
+// Qaytgan natijada Status ok yoki error.
+func (c *Client) RequestScaleCommand(ctx context.Context, op, requestedBy string) (bridgestate.ScaleCommandSnapshot, error) {
+	if c == nil || c.store == nil || strings.TrimSpace(c.store.Path()) == "" {
+		return bridgestate.ScaleCommandSnapshot{}, fmt.Errorf("bridge state path bo'sh")
+	}
+	now := time.Now().UTC()
+	cmd := bridgestate.ScaleCommandSnapshot{
+		ID:          fmt.Sprintf("bot-%d-%d", now.UnixNano(), scaleCommandSeq.Add(1)),
+		Op:          strings.TrimSpace(op),
+		RequestedBy: strings.TrimSpace(requestedBy),
+		Status:      bridgestate.ScaleCommandPending,
+		RequestedAt: now.Format(time.RFC3339Nano),
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, bridgestate.ScaleCommandTimeout)
+	defer cancel()
+
+	if c.bus != nil {
+		resp, err := c.bus.Request(waitCtx, ipc.Message{Type: ipc.TypeScaleCommand, Command: &cmd})
+		switch {
+		case err == nil && resp.Command != nil:
+			return *resp.Command, nil
+		case err == nil:
+			return bridgestate.ScaleCommandSnapshot{}, fmt.Errorf("scale javobi bo'sh: %s", resp.Error)
+		case !errors.Is(err, ipc.ErrNotConnected):
+			return bridgestate.ScaleCommandSnapshot{}, err
+		}
+	}
+
+	updates := c.store.WatchWithFallback(waitCtx, 150*time.Millisecond)
+	if err := c.store.Update(func(s *bridgestate.Snapshot) { s.Command = &cmd }); err != nil {
+		return bridgestate.ScaleCommandSnapshot{}, err
+	}
+	for {
+		snap, err := nextSnapshot(ctx, waitCtx, updates)
+		if err != nil {
+			// Kutish tugadi: buyruq kech bajarilmasin (operator natijani ko'rmaydi).
+			c.expireScaleCommand(cmd.ID, err)
+			if isWaitTimeout(ctx, err) {
+				return bridgestate.ScaleCommandSnapshot{}, fmt.Errorf("scale buyruqni tasdiqlamadi (%s)", bridgestate.ScaleCommandTimeout)
+			}
+			return bridgestate.ScaleCommandSnapshot{}, err
+		}
+		if got := snap.Command; got != nil && got.ID == cmd.ID && !got.Pending() {
+			return *got, nil
+		}
+	}
+}
+
+// expireScaleCommand id hali pending bo'lsa expired deb belgilaydi (scale bajarib ulgurgan
+// bo'lsa natijasi o'zgarmaydi).
+func (c *Client) expireScaleCommand(id string, cause error) {
+	_ = c.store.Update(func(s *bridgestate.Snapshot) {
+		if s.Command == nil || s.Command.ID != id || !s.Command.Pending() {
+			return
+		}
+		done := *s.Command
+		done.Status = bridgestate.ScaleCommandExpired
+		done.Error = "bot kutishni to'xtatdi: " + cause.Error()
+		done.DoneAt = time.Now().UTC().Format(time.RFC3339Nano)
+		s.Command = &done
+	})
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	bridgestate "bridge/state"
 	"bufio"
+	corepkg "core"
 	"errors"
 	"fmt"
 	"net/url"
@@ -10,7 +12,11 @@ import (
 	"strings"
 )
 
-const defaultBridgeStateFile = "/tmp/gscale-zebra/bridge_state.json"
+const (
+	defaultBridgeStateFile = "/tmp/gscale-zebra/bridge_state.json"
+	defaultBridgeSocket    = "/tmp/gscale-zebra/bridge.sock"
+	defaultBridgeStation   = "default"
+)
 
 type Config struct {
 	TelegramBotToken string
@@ -18,6 +24,21 @@ type Config struct {
 	ERPAPIKey        string
 	ERPAPISecret     string
 	BridgeStateFile  string
+	BridgeJournalDir string
+	// BridgeBackend bridge state saqlash turi: file yoki sqlite (scale --bridge-backend bilan bir xil).
+	BridgeBackend string
+	// BridgeSocket scale IPC bus'i; "off" bo'lsa faqat bridge state fayli ishlatiladi.
+	BridgeSocket string
+	// BridgeStation chat /station bilan boshqasini tanlamaguncha ishlatiladigan stansiya.
+	BridgeStation string
+	// MetricsAddr bo'sh bo'lmasa Prometheus `/metrics` shu manzilda ochiladi.
+	MetricsAddr string
+	// EPCRegistryFile scale --epc-registry bilan bir xil fayl: `/epc <EPC>` shu yerdan qidiradi.
+	EPCRegistryFile string
+	// EPCScheme scale --epc-scheme bilan bir xil: `/epc` tekshiruvi shu sxema bo'yicha.
+	EPCScheme string
+	// CountUOMs shu stock UOM'li item'lar dona bilan beriladi (counting rejimi; bo'sh = o'chirilgan).
+	CountUOMs []string
 }
 
 func Load(envPath string) (Config, error) {
@@ -57,6 +78,44 @@ func Load(envPath string) (Config, error) {
 			fileVals["BRIDGE_STATE_FILE"],
 			defaultBridgeStateFile,
 		),
+		BridgeJournalDir: firstNonEmpty(
+			os.Getenv("BRIDGE_JOURNAL_DIR"),
+			fileVals["BRIDGE_JOURNAL_DIR"],
+		),
+		BridgeBackend: bridgestate.NormalizeBackend(firstNonEmpty(
+			os.Getenv("BRIDGE_BACKEND"),
+			fileVals["BRIDGE_BACKEND"],
+		)),
+		BridgeSocket: firstNonEmpty(
+			os.Getenv("BRIDGE_SOCKET"),
+			fileVals["BRIDGE_SOCKET"],
+			defaultBridgeSocket,
+		),
+		BridgeStation: firstNonEmpty(
+			os.Getenv("BRIDGE_STATION"),
+			fileVals["BRIDGE_STATION"],
+			defaultBridgeStation,
+		),
+		MetricsAddr: firstNonEmpty(
+			os.Getenv("METRICS_ADDR"),
+			fileVals["METRICS_ADDR"],
+		),
+		EPCRegistryFile: firstNonEmpty(
+			os.Getenv("EPC_REGISTRY_FILE"),
+			fileVals["EPC_REGISTRY_FILE"],
+		),
+		EPCScheme: strings.ToLower(strings.TrimSpace(firstNonEmpty(
+			os.Getenv("EPC_SCHEME"),
+			fileVals["EPC_SCHEME"],
+			corepkg.EPCSchemeTime,
+		))),
+	}
+	cfg.CountUOMs = splitList(firstNonEmpty(
+		os.Getenv("COUNT_UOMS"),
+		fileVals["COUNT_UOMS"],
+	))
+	if strings.EqualFold(strings.TrimSpace(cfg.BridgeSocket), "off") {
+		cfg.BridgeSocket = ""
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -67,6 +126,30 @@ func Load(envPath string) (Config, error) {
 	return cfg, nil
 }
 
+// IsCountUOM item stock UOM'i counting rejimiga tegishlimi (katta-kichik harf farqsiz).
+func (c Config) IsCountUOM(uom string) bool {
+	uom = strings.TrimSpace(uom)
+	if uom == "" {
+		return false
+	}
+	for _, v := range c.CountUOMs {
+		if strings.EqualFold(v, uom) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func (c Config) Validate() error {
 	if strings.TrimSpace(c.TelegramBotToken) == "" {
 		return errors.New("TELEGRAM_BOT_TOKEN bo'sh")
@@ -80,9 +163,20 @@ func (c Config) Validate() error {
 	if strings.TrimSpace(c.ERPAPISecret) == "" {
 		return errors.New("ERP_API_SECRET bo'sh")
 	}
+	if err := bridgestate.ValidateBackend(c.BridgeBackend); err != nil {
+		return fmt.Errorf("BRIDGE_BACKEND: %w", err)
+	}
+	if err := bridgestate.ValidateStationID(c.BridgeStation); err != nil {
+		return fmt.Errorf("BRIDGE_STATION: %w", err)
+	}
 	if strings.TrimSpace(c.BridgeStateFile) == "" {
 		return errors.New("BRIDGE_STATE_FILE bo'sh")
 	}
+	switch c.EPCScheme {
+	case corepkg.EPCSchemeTime, corepkg.EPCSchemeSGTIN96:
+	default:
+		return fmt.Errorf("EPC_SCHEME noto'g'ri: %q (time|sgtin96)", c.EPCScheme)
+	}
 
 	u, err := url.Parse(strings.TrimSpace(c.ERPURL))
 	if err != nil || u.Scheme == "" || u.Host == "" {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -33,6 +34,12 @@ func TestLoadSupportsColonAndAliasKeys(t *testing.T) {
 	if cfg.BridgeStateFile != defaultBridgeStateFile {
 		t.Fatalf("BridgeStateFile mismatch: %q", cfg.BridgeStateFile)
 	}
+	if cfg.BridgeBackend != "file" {
+		t.Fatalf("BridgeBackend mismatch: %q", cfg.BridgeBackend)
+	}
+	if cfg.EPCScheme != "time" {
+		t.Fatalf("EPCScheme default mismatch: %q", cfg.EPCScheme)
+	}
 }
 
 func TestLoadSupportsBridgeOverride(t *testing.T) {
@@ -43,7 +50,10 @@ func TestLoadSupportsBridgeOverride(t *testing.T) {
 		"ERP_URL=https://erp.accord.uz\n" +
 		"ERP_API_KEY=abc\n" +
 		"ERP_API_SECRET=def\n" +
-		"BRIDGE_STATE_FILE=/tmp/custom-bridge.json\n"
+		"BRIDGE_STATE_FILE=/tmp/custom-bridge.json\n" +
+		"BRIDGE_BACKEND=SQLite\n" +
+		"COUNT_UOMS=Nos, pcs\n" +
+		"EPC_SCHEME=SGTIN96\n"
 	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
 		t.Fatal(err)
 	}
@@ -55,4 +65,29 @@ func TestLoadSupportsBridgeOverride(t *testing.T) {
 	if cfg.BridgeStateFile != "/tmp/custom-bridge.json" {
 		t.Fatalf("BridgeStateFile mismatch: %q", cfg.BridgeStateFile)
 	}
+	if cfg.BridgeBackend != "sqlite" {
+		t.Fatalf("BridgeBackend mismatch: %q", cfg.BridgeBackend)
+	}
+	if !cfg.IsCountUOM("nos") || !cfg.IsCountUOM("PCS") || cfg.IsCountUOM("Kg") {
+		t.Fatalf("CountUOMs mismatch: %q", cfg.CountUOMs)
+	}
+	if cfg.EPCScheme != "sgtin96" {
+		t.Fatalf("EPCScheme mismatch: %q", cfg.EPCScheme)
+	}
+}
+
+func TestLoadRejectsUnknownEPCScheme(t *testing.T) {
+	d := t.TempDir()
+	p := filepath.Join(d, ".env")
+	data := "TELEGRAM_BOT_TOKEN=123:XYZ\n" +
+		"ERP_URL=https://erp.accord.uz\n" +
+		"ERP_API_KEY=abc\n" +
+		"ERP_API_SECRET=def\n" +
+		"EPC_SCHEME=gtin\n"
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(p); err == nil || !strings.Contains(err.Error(), "EPC_SCHEME") {
+		t.Fatalf("Load error = %v, want EPC_SCHEME", err)
+	}
 }
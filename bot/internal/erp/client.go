@@ -29,6 +29,33 @@ type Item struct {
 	ItemName string
 }
 
+// ItemUOM item'ning stock UOM va ERP'dagi dona og'irligi (counting rejimi uchun).
+// Barcodes - Item Barcode jadvali (GTIN shulardan tanlanadi).
+type ItemUOM struct {
+	ItemCode      string
+	StockUOM      string
+	WeightPerUnit float64
+	WeightUOM     string
+	Barcodes      []string
+}
+
+// WeightPerUnitKg dona og'irligi kg'da; og'irlik yo'q yoki UOM noma'lum bo'lsa false.
+func (u ItemUOM) WeightPerUnitKg() (float64, bool) {
+	if u.WeightPerUnit <= 0 {
+		return 0, false
+	}
+	switch strings.ToLower(strings.TrimSpace(u.WeightUOM)) {
+	case "", "kg", "kilogram", "kgs":
+		return u.WeightPerUnit, true
+	case "g", "gram", "gramm", "gr":
+		return u.WeightPerUnit / 1000, true
+	case "lb", "lbs", "pound":
+		return u.WeightPerUnit * 0.45359237, true
+	default:
+		return 0, false
+	}
+}
+
 type WarehouseStock struct {
 	Warehouse string
 	ActualQty float64
@@ -42,6 +69,18 @@ type listItemsResponse struct {
 	} `json:"data"`
 }
 
+type getItemUOMResponse struct {
+	Data struct {
+		ItemCode      string  `json:"item_code"`
+		StockUOM      string  `json:"stock_uom"`
+		WeightPerUnit float64 `json:"weight_per_unit"`
+		WeightUOM     string  `json:"weight_uom"`
+		Barcodes      []struct {
+			Barcode string `json:"barcode"`
+		} `json:"barcodes"`
+	} `json:"data"`
+}
+
 type listBinsResponse struct {
 	Data []struct {
 		Warehouse string  `json:"warehouse"`
@@ -230,6 +269,53 @@ func (c *Client) SearchItemWarehouses(ctx context.Context, itemCode, query strin
 	return stocks, nil
 }
 
+// GetItemUOM item'ning stock_uom, weight_per_unit/weight_uom va barcodes maydonlarini o'qiydi.
+func (c *Client) GetItemUOM(ctx context.Context, itemCode string) (ItemUOM, error) {
+	itemCode = strings.TrimSpace(itemCode)
+	if itemCode == "" {
+		return ItemUOM{}, fmt.Errorf("item code bo'sh")
+	}
+
+	endpoint := c.baseURL + "/api/resource/Item/" + url.PathEscape(itemCode)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return ItemUOM{}, err
+	}
+	c.setAuthHeader(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return ItemUOM{}, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 256*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return ItemUOM{}, fmt.Errorf("erp item http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload getItemUOMResponse
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ItemUOM{}, fmt.Errorf("erp item json parse xato: %w", err)
+	}
+	code := strings.TrimSpace(payload.Data.ItemCode)
+	if code == "" {
+		code = itemCode
+	}
+	u := ItemUOM{
+		ItemCode:      code,
+		StockUOM:      strings.TrimSpace(payload.Data.StockUOM),
+		WeightPerUnit: payload.Data.WeightPerUnit,
+		WeightUOM:     strings.TrimSpace(payload.Data.WeightUOM),
+	}
+	for _, b := range payload.Data.Barcodes {
+		if v := strings.TrimSpace(b.Barcode); v != "" {
+			u.Barcodes = append(u.Barcodes, v)
+		}
+	}
+	return u, nil
+}
+
 func (c *Client) setAuthHeader(req *http.Request) {
 	req.Header.Set("Authorization", fmt.Sprintf("token %s:%s", c.apiKey, c.apiSecret))
 }
@@ -90,3 +90,29 @@ func TestSearchItemWarehouses(t *testing.T) {
 		t.Fatalf("stocks[1] mismatch: %+v", stocks[1])
 	}
 }
+
+func TestGetItemUOM(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/resource/Item/BOLT M8" {
+			t.Fatalf("path mismatch: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"item_code":"BOLT M8","stock_uom":"Nos","weight_per_unit":12.5,"weight_uom":"Gram","barcodes":[{"barcode":"BOLT-M8"},{"barcode":" 80614141123458 "}]}}`))
+	}))
+	defer ts.Close()
+
+	c := New(ts.URL, "k", "s")
+	u, err := c.GetItemUOM(context.Background(), "BOLT M8")
+	if err != nil {
+		t.Fatalf("GetItemUOM error: %v", err)
+	}
+	if u.StockUOM != "Nos" || u.WeightUOM != "Gram" || len(u.Barcodes) != 2 || u.Barcodes[1] != "80614141123458" {
+		t.Fatalf("uom mismatch: %+v", u)
+	}
+	if kg, ok := u.WeightPerUnitKg(); !ok || kg != 0.0125 {
+		t.Fatalf("weight per unit kg: %v %v", kg, ok)
+	}
+	if _, ok := (ItemUOM{WeightPerUnit: 1, WeightUOM: "Box"}).WeightPerUnitKg(); ok {
+		t.Fatal("unknown weight uom should not convert")
+	}
+}
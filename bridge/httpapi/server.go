@@ -0,0 +1,208 @@
+// Package httpapi bridge state'ni tashqi vositalar (zal displeylari, QA
+// skriptlar) uchun faqat o'qish rejimidagi HTTP API va SSE oqimi sifatida beradi.
+package httpapi
+
+import (
+	bridgestate "bridge/state"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// sseKeepAlive proxy'lar bo'sh ulanishni uzib qo'ymasligi uchun izoh yuboriladi.
+	sseKeepAlive    = 15 * time.Second
+	shutdownTimeout = 2 * time.Second
+)
+
+// Server bridge state ustidagi read-only HTTP handler.
+//
+//	GET /state            joriy Snapshot
+//	GET /scale            faqat scale bo'limi
+//	GET /zebra            faqat zebra bo'limi
+//	GET /batch            faqat batch bo'limi
+//	GET /stations         barcha stansiyalar
+//	GET /health           state fayl holati
+//	GET /events           o'zgarishlar SSE oqimi (event: snapshot)
+//
+// Barcha endpoint'lar `?station=<id>` bilan boshqa stansiyani tanlashi mumkin.
+type Server struct {
+	store *bridgestate.Store
+	mux   *http.ServeMux
+}
+
+func New(store *bridgestate.Store) *Server {
+	s := &Server{store: store, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /state", s.handleState)
+	s.mux.HandleFunc("GET /scale", s.handleSection(func(snap bridgestate.Snapshot) any { return snap.Scale }))
+	s.mux.HandleFunc("GET /zebra", s.handleSection(func(snap bridgestate.Snapshot) any { return snap.Zebra }))
+	s.mux.HandleFunc("GET /batch", s.handleSection(func(snap bridgestate.Snapshot) any { return snap.Batch }))
+	s.mux.HandleFunc("GET /stations", s.handleStations)
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("GET /events", s.handleEvents)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Start addr'da tinglashni boshlaydi va server ctx tugaguncha fonda ishlaydi.
+// Listener ochilmasa xato darhol qaytadi; haqiqiy manzil (":0" uchun) qaytariladi.
+func Start(ctx context.Context, addr string, store *bridgestate.Store) (net.Addr, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return nil, errors.New("http addr bo'sh")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("http listen: %w", err)
+	}
+
+	srv := &http.Server{
+		Handler:           New(store),
+		ReadHeaderTimeout: 5 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() { _ = srv.Serve(ln) }()
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+	return ln.Addr(), nil
+}
+
+func (s *Server) stationStore(r *http.Request) (*bridgestate.Store, error) {
+	id := strings.TrimSpace(r.URL.Query().Get("station"))
+	if id == "" {
+		return s.store, nil
+	}
+	if err := bridgestate.ValidateStationID(id); err != nil {
+		return nil, err
+	}
+	return s.store.Station(id), nil
+}
+
+func (s *Server) read(w http.ResponseWriter, r *http.Request) (bridgestate.Snapshot, bool) {
+	store, err := s.stationStore(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return bridgestate.Snapshot{}, false
+	}
+	snap, err := store.Read()
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return bridgestate.Snapshot{}, false
+	}
+	return snap, true
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	if snap, ok := s.read(w, r); ok {
+		writeJSON(w, http.StatusOK, snap)
+	}
+}
+
+func (s *Server) handleSection(pick func(bridgestate.Snapshot) any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if snap, ok := s.read(w, r); ok {
+			writeJSON(w, http.StatusOK, pick(snap))
+		}
+	}
+}
+
+func (s *Server) handleStations(w http.ResponseWriter, r *http.Request) {
+	list, err := s.store.Stations()
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	h := s.store.Health()
+	status := http.StatusOK
+	if !h.OK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, h)
+}
+
+// handleEvents har state o'zgarishida `event: snapshot` yuboradi.
+// Birinchi event joriy holat; id - hujjat revision'i.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	store, err := s.stationStore(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming qo'llab-quvvatlanmaydi"))
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	updates := store.Watch(ctx)
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case snap, ok := <-updates:
+			if !ok {
+				return
+			}
+			b, err := json.Marshal(snap)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: snapshot\nid: %d\ndata: %s\n\n", snap.Revision, b); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func statusFor(err error) int {
+	// State fayl hali yozilmagan bo'lsa (scale endi ishga tushgan) 404.
+	if errors.Is(err, fs.ErrNotExist) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,142 @@
+package httpapi
+
+import (
+	bridgestate "bridge/state"
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *bridgestate.Store {
+	t.Helper()
+	store := bridgestate.New(filepath.Join(t.TempDir(), "bridge_state.json"))
+	w := 12.5
+	if err := store.Update(func(s *bridgestate.Snapshot) {
+		s.Scale.Weight = &w
+		s.Scale.Unit = "kg"
+		s.Batch.Active = true
+		s.Batch.ItemName = "GRENKI"
+	}); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	return store
+}
+
+func TestSectionEndpoints(t *testing.T) {
+	srv := httptest.NewServer(New(newTestStore(t)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/scale")
+	if err != nil {
+		t.Fatalf("GET /scale error: %v", err)
+	}
+	defer resp.Body.Close()
+	var scale bridgestate.ScaleSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&scale); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || scale.Weight == nil || *scale.Weight != 12.5 {
+		t.Fatalf("scale mismatch: status=%d %+v", resp.StatusCode, scale)
+	}
+
+	resp2, err := http.Get(srv.URL + "/state")
+	if err != nil {
+		t.Fatalf("GET /state error: %v", err)
+	}
+	defer resp2.Body.Close()
+	var snap bridgestate.Snapshot
+	if err := json.NewDecoder(resp2.Body).Decode(&snap); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if !snap.Batch.Active || snap.Batch.ItemName != "GRENKI" || snap.Revision != 1 {
+		t.Fatalf("state mismatch: %+v", snap)
+	}
+}
+
+func TestReadOnlyAndMissingState(t *testing.T) {
+	store := bridgestate.New(filepath.Join(t.TempDir(), "missing.json"))
+	srv := httptest.NewServer(New(store))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/state", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status=%d, want 405", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/batch")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing state status=%d, want 404", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/state?station=bad/id")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad station status=%d, want 400", resp.StatusCode)
+	}
+}
+
+func TestEventsStreamsChanges(t *testing.T) {
+	store := newTestStore(t)
+	srv := httptest.NewServer(New(store))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events error: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content-type=%q", ct)
+	}
+
+	events := make(chan bridgestate.Snapshot, 4)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			line := sc.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var snap bridgestate.Snapshot
+			if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &snap) == nil {
+				events <- snap
+			}
+		}
+	}()
+
+	first := <-events
+	if first.Revision != 1 {
+		t.Fatalf("first event revision=%d, want 1", first.Revision)
+	}
+	if err := store.Update(func(s *bridgestate.Snapshot) { s.Zebra.LastEPC = "3034ABCD" }); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	select {
+	case snap := <-events:
+		if snap.Zebra.LastEPC != "3034ABCD" || snap.Revision != 2 {
+			t.Fatalf("event mismatch: %+v", snap)
+		}
+	case <-ctx.Done():
+		t.Fatal("SSE event kelmadi")
+	}
+}
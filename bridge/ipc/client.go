@@ -0,0 +1,239 @@
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotConnected bus'ga ulanib bo'lmaganda qaytadi; chaqiruvchi
+// odatda shu holatda file store fallback'ga o'tadi.
+var ErrNotConnected = errors.New("ipc: ulanish yo'q")
+
+const redialBackoff = 2 * time.Second
+
+// Client bus'ga lazy ulanadi va uzilganda keyingi chaqiruvda qayta ulanadi.
+type Client struct {
+	path string
+
+	mu       sync.Mutex
+	conn     *clientConn
+	nextDial time.Time
+}
+
+type clientConn struct {
+	c    net.Conn
+	wmu  sync.Mutex
+	done chan struct{}
+	once sync.Once
+
+	mu      sync.Mutex
+	pending map[string]chan Message
+	subs    map[chan Message]struct{}
+}
+
+func NewClient(path string) *Client {
+	return &Client{path: strings.TrimSpace(path)}
+}
+
+func (c *Client) Path() string {
+	if c == nil {
+		return ""
+	}
+	return c.path
+}
+
+// Connected hozir ulanish bormi (yoki qayta ulanish mumkinmi) tekshiradi.
+func (c *Client) Connected() bool {
+	_, err := c.get()
+	return err == nil
+}
+
+// Request msg ni yuboradi va unga tegishli javobni kutadi.
+func (c *Client) Request(ctx context.Context, msg Message) (Message, error) {
+	cc, err := c.get()
+	if err != nil {
+		return Message{}, err
+	}
+	if msg.ID == "" {
+		msg.ID = newID()
+	}
+	if msg.At == "" {
+		msg.At = now()
+	}
+
+	ch := make(chan Message, 1)
+	cc.mu.Lock()
+	cc.pending[msg.ID] = ch
+	cc.mu.Unlock()
+	defer func() {
+		cc.mu.Lock()
+		delete(cc.pending, msg.ID)
+		cc.mu.Unlock()
+	}()
+
+	if err := cc.write(msg); err != nil {
+		return Message{}, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	case <-cc.done:
+		return Message{}, ErrNotConnected
+	case resp := <-ch:
+		if strings.TrimSpace(resp.Error) != "" {
+			return resp, errors.New(resp.Error)
+		}
+		return resp, nil
+	}
+}
+
+// Subscribe server Publish qilgan event'lar oqimini qaytaradi.
+// Ulanish uzilsa kanal yopiladi. cancel obunani bekor qiladi.
+func (c *Client) Subscribe(buffer int) (<-chan Message, func(), error) {
+	cc, err := c.get()
+	if err != nil {
+		return nil, func() {}, err
+	}
+	if buffer <= 0 {
+		buffer = 16
+	}
+	ch := make(chan Message, buffer)
+
+	cc.mu.Lock()
+	select {
+	case <-cc.done:
+		cc.mu.Unlock()
+		return nil, func() {}, ErrNotConnected
+	default:
+	}
+	cc.subs[ch] = struct{}{}
+	cc.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			cc.mu.Lock()
+			if _, ok := cc.subs[ch]; ok {
+				delete(cc.subs, ch)
+				close(ch)
+			}
+			cc.mu.Unlock()
+		})
+	}
+	return ch, cancel, nil
+}
+
+func (c *Client) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	cc := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+	if cc != nil {
+		cc.close()
+	}
+	return nil
+}
+
+func (c *Client) get() (*clientConn, error) {
+	if c == nil || c.path == "" {
+		return nil, ErrNotConnected
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		select {
+		case <-c.conn.done:
+			c.conn = nil
+		default:
+			return c.conn, nil
+		}
+	}
+	if time.Now().Before(c.nextDial) {
+		return nil, ErrNotConnected
+	}
+
+	nc, err := net.DialTimeout("unix", c.path, 500*time.Millisecond)
+	if err != nil {
+		c.nextDial = time.Now().Add(redialBackoff)
+		return nil, fmt.Errorf("%w: %v", ErrNotConnected, err)
+	}
+	cc := &clientConn{
+		c:       nc,
+		done:    make(chan struct{}),
+		pending: make(map[string]chan Message),
+		subs:    make(map[chan Message]struct{}),
+	}
+	go cc.readLoop()
+	c.conn = cc
+	return cc, nil
+}
+
+func (cc *clientConn) write(msg Message) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("ipc marshal: %w", err)
+	}
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+	_ = cc.c.SetWriteDeadline(time.Now().Add(2 * time.Second))
+	if _, err := cc.c.Write(append(b, '\n')); err != nil {
+		cc.close()
+		return fmt.Errorf("%w: %v", ErrNotConnected, err)
+	}
+	return nil
+}
+
+func (cc *clientConn) readLoop() {
+	defer cc.close()
+	r := bufio.NewScanner(cc.c)
+	r.Buffer(make([]byte, 0, 16<<10), 1<<20)
+	for r.Scan() {
+		var msg Message
+		if err := json.Unmarshal(r.Bytes(), &msg); err != nil {
+			continue
+		}
+
+		cc.mu.Lock()
+		if msg.IsReply() {
+			if ch, ok := cc.pending[msg.ReplyTo]; ok {
+				select {
+				case ch <- msg:
+				default:
+				}
+			}
+		} else {
+			for ch := range cc.subs {
+				select {
+				case ch <- msg:
+				default:
+				}
+			}
+		}
+		cc.mu.Unlock()
+	}
+}
+
+func (cc *clientConn) close() {
+	cc.once.Do(func() {
+		_ = cc.c.Close()
+		cc.mu.Lock()
+		close(cc.done)
+		for ch := range cc.subs {
+			close(ch)
+		}
+		cc.subs = map[chan Message]struct{}{}
+		cc.mu.Unlock()
+	})
+}
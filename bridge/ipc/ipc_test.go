@@ -0,0 +1,130 @@
+package ipc
+
+import (
+	bridgestate "bridge/state"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func startTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "bridge.sock")
+	srv, err := Listen(p)
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = srv.Serve(ctx) }()
+	return srv, p
+}
+
+func TestRequestGetsReply(t *testing.T) {
+	srv, p := startTestServer(t)
+	srv.Handle(TypeScaleCommand, func(ctx context.Context, req Message) Message {
+		resp := Reply(req, TypeScaleCommandAck)
+		done := *req.Command
+		done.Status = bridgestate.ScaleCommandOK
+		resp.Command = &done
+		return resp
+	})
+
+	c := NewClient(p)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := c.Request(ctx, Message{Type: TypeScaleCommand, Command: &bridgestate.ScaleCommandSnapshot{ID: "bot-1", Op: bridgestate.ScaleOpZero}})
+	if err != nil {
+		t.Fatalf("Request error: %v", err)
+	}
+	if resp.Type != TypeScaleCommandAck || resp.Command == nil || resp.Command.ID != "bot-1" || resp.Command.Status != bridgestate.ScaleCommandOK {
+		t.Fatalf("reply mismatch: %+v", resp)
+	}
+}
+
+func TestRequestUnknownTypeFails(t *testing.T) {
+	_, p := startTestServer(t)
+	c := NewClient(p)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := c.Request(ctx, Message{Type: TypeBatchStart}); err == nil {
+		t.Fatal("expected error for unhandled request type")
+	}
+}
+
+func TestPublishReachesSubscribers(t *testing.T) {
+	srv, p := startTestServer(t)
+	c := NewClient(p)
+	defer c.Close()
+
+	events, cancel, err := c.Subscribe(4)
+	if err != nil {
+		t.Fatalf("Subscribe error: %v", err)
+	}
+	defer cancel()
+
+	// Server ulanishni ro'yxatga olguncha kutamiz.
+	deadline := time.Now().Add(time.Second)
+	for {
+		srv.mu.Lock()
+		n := len(srv.conns)
+		srv.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server did not register connection")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	w := 1.25
+	srv.Publish(Message{Type: TypeScaleReading, Scale: &bridgestate.ScaleSnapshot{Weight: &w}})
+
+	select {
+	case msg := <-events:
+		if msg.Type != TypeScaleReading || msg.Scale == nil || *msg.Scale.Weight != 1.25 {
+			t.Fatalf("event mismatch: %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event timeout")
+	}
+}
+
+func TestClientWithoutServerIsNotConnected(t *testing.T) {
+	c := NewClient(filepath.Join(t.TempDir(), "missing.sock"))
+	if _, err := c.Request(context.Background(), Message{Type: TypeBatchStop}); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected, got %v", err)
+	}
+	if c.Connected() {
+		t.Fatal("client should not be connected")
+	}
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge.sock")
+	ln, err := net.Listen("unix", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Crash imitatsiyasi: listener yopiladi, socket fayl esa qoladi.
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = ln.Close()
+	if _, err := os.Lstat(p); err != nil {
+		t.Fatalf("socket file should remain: %v", err)
+	}
+
+	srv, err := Listen(p)
+	if err != nil {
+		t.Fatalf("Listen over stale socket error: %v", err)
+	}
+	_ = srv.Close()
+}
@@ -0,0 +1,86 @@
+package ipc
+
+import (
+	bridgestate "bridge/state"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSocketPath scale hosts qiladigan unix socket.
+const DefaultSocketPath = "/tmp/gscale-zebra/bridge.sock"
+
+// SocketPathForStation default stansiya uchun base'ni, boshqalari uchun nomiga
+// "-<station>" qo'shilgan yo'lni qaytaradi (bridge.sock -> bridge-line-2.sock).
+func SocketPathForStation(base, stationID string) string {
+	base = strings.TrimSpace(base)
+	stationID = bridgestate.NormalizeStationID(stationID)
+	if base == "" || stationID == bridgestate.DefaultStationID {
+		return base
+	}
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-" + stationID + ext
+}
+
+type MessageType string
+
+const (
+	// Scale -> hammaga: har yangi scale o'qishi.
+	TypeScaleReading MessageType = "scale_reading"
+	// Scale -> hammaga: Zebra encode/read natijasi.
+	TypeZebraResult MessageType = "zebra_result"
+	// Bot -> scale: batch boshlash/to'xtatish (javob: TypeAck).
+	TypeBatchStart MessageType = "batch_start"
+	TypeBatchStop  MessageType = "batch_stop"
+	// Bot -> scale: indikatorga tare/zero/clear_tare (javob: TypeScaleCommandAck, Command natija bilan).
+	TypeScaleCommand    MessageType = "scale_command"
+	TypeScaleCommandAck MessageType = "scale_command_ack"
+	// Umumiy javob (xato bo'lsa Error to'ldiriladi).
+	TypeAck MessageType = "ack"
+)
+
+// Message bus'dagi bitta JSON qator.
+// ID request'ni, ReplyTo esa javob qaysi request'ga tegishli ekanini bildiradi.
+type Message struct {
+	Type    MessageType                       `json:"type"`
+	ID      string                            `json:"id,omitempty"`
+	ReplyTo string                            `json:"reply_to,omitempty"`
+	At      string                            `json:"at,omitempty"`
+	Scale   *bridgestate.ScaleSnapshot        `json:"scale,omitempty"`
+	Zebra   *bridgestate.ZebraSnapshot        `json:"zebra,omitempty"`
+	Batch   *bridgestate.BatchSnapshot        `json:"batch,omitempty"`
+	Command *bridgestate.ScaleCommandSnapshot `json:"command,omitempty"`
+	Error   string                            `json:"error,omitempty"`
+}
+
+func (m Message) IsReply() bool {
+	return m.ReplyTo != ""
+}
+
+// Reply req ga javob xabarini tayyorlaydi.
+func Reply(req Message, t MessageType) Message {
+	return Message{Type: t, ReplyTo: req.ID, At: now()}
+}
+
+var idSeq atomic.Uint64
+var idPrefix = newIDPrefix()
+
+func newID() string {
+	return fmt.Sprintf("%s-%d", idPrefix, idSeq.Add(1))
+}
+
+func newIDPrefix() string {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%08x", uint32(time.Now().UnixNano()))
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,257 @@
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const connSendBuffer = 64
+
+// Handler request'ni qayta ishlaydi va javob qaytaradi.
+// Javobdagi ReplyTo server tomonidan to'ldiriladi.
+type Handler func(ctx context.Context, req Message) Message
+
+// Server unix socket'da ishlaydigan bus.
+// Publish barcha ulangan client'larga event yuboradi,
+// Handle qilingan turdagi request'larga esa javob qaytariladi.
+type Server struct {
+	path string
+	ln   net.Listener
+
+	mu       sync.Mutex
+	conns    map[*serverConn]struct{}
+	handlers map[MessageType]Handler
+	closed   bool
+}
+
+type serverConn struct {
+	c    net.Conn
+	out  chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func Listen(path string) (*Server, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("ipc socket path bo'sh")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir ipc dir: %w", err)
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("ipc listen: %w", err)
+	}
+	return &Server{
+		path:     path,
+		ln:       ln,
+		conns:    make(map[*serverConn]struct{}),
+		handlers: make(map[MessageType]Handler),
+	}, nil
+}
+
+func (s *Server) Path() string {
+	if s == nil {
+		return ""
+	}
+	return s.path
+}
+
+func (s *Server) Handle(t MessageType, h Handler) {
+	if s == nil || h == nil {
+		return
+	}
+	s.mu.Lock()
+	s.handlers[t] = h
+	s.mu.Unlock()
+}
+
+// Serve ctx tugaguncha ulanishlarni qabul qiladi.
+func (s *Server) Serve(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	go func() {
+		<-ctx.Done()
+		_ = s.Close()
+	}()
+
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || s.isClosed() {
+				return nil
+			}
+			return fmt.Errorf("ipc accept: %w", err)
+		}
+		sc := &serverConn{c: c, out: make(chan []byte, connSendBuffer), done: make(chan struct{})}
+		s.mu.Lock()
+		s.conns[sc] = struct{}{}
+		s.mu.Unlock()
+
+		go sc.writeLoop()
+		go s.readLoop(ctx, sc)
+	}
+}
+
+// Publish event'ni barcha client'larga yuboradi.
+// Sekin client bloklamaydi: uning navbati to'lsa event tashlab yuboriladi.
+func (s *Server) Publish(msg Message) {
+	if s == nil {
+		return
+	}
+	if msg.At == "" {
+		msg.At = now()
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sc := range s.conns {
+		sc.send(b)
+	}
+}
+
+func (s *Server) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	conns := make([]*serverConn, 0, len(s.conns))
+	for sc := range s.conns {
+		conns = append(conns, sc)
+	}
+	s.mu.Unlock()
+
+	err := s.ln.Close()
+	for _, sc := range conns {
+		sc.close()
+	}
+	_ = os.Remove(s.path)
+	return err
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) readLoop(ctx context.Context, sc *serverConn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, sc)
+		s.mu.Unlock()
+		sc.close()
+	}()
+
+	r := bufio.NewScanner(sc.c)
+	r.Buffer(make([]byte, 0, 16<<10), 1<<20)
+	for r.Scan() {
+		var req Message
+		if err := json.Unmarshal(r.Bytes(), &req); err != nil {
+			continue
+		}
+		if req.ID == "" {
+			// Javob kutilmaydigan xabarlarni server e'tiborsiz qoldiradi.
+			continue
+		}
+
+		s.mu.Lock()
+		h := s.handlers[req.Type]
+		s.mu.Unlock()
+
+		go func(req Message) {
+			var resp Message
+			if h == nil {
+				resp = Reply(req, TypeAck)
+				resp.Error = fmt.Sprintf("noma'lum xabar turi: %s", req.Type)
+			} else {
+				resp = h(ctx, req)
+			}
+			resp.ReplyTo = req.ID
+			if resp.At == "" {
+				resp.At = now()
+			}
+			b, err := json.Marshal(resp)
+			if err != nil {
+				return
+			}
+			sc.send(append(b, '\n'))
+		}(req)
+	}
+}
+
+func (sc *serverConn) send(b []byte) {
+	select {
+	case <-sc.done:
+	case sc.out <- b:
+	default:
+	}
+}
+
+func (sc *serverConn) writeLoop() {
+	for {
+		select {
+		case <-sc.done:
+			return
+		case b := <-sc.out:
+			if _, err := sc.c.Write(b); err != nil {
+				sc.close()
+				return
+			}
+		}
+	}
+}
+
+func (sc *serverConn) close() {
+	sc.once.Do(func() {
+		close(sc.done)
+		_ = sc.c.Close()
+	})
+}
+
+// removeStaleSocket oldingi process crash bo'lib qoldirgan socket faylini o'chiradi.
+// Agar socket'da kimdir tinglayotgan bo'lsa xato qaytaradi.
+func removeStaleSocket(path string) error {
+	st, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("stat ipc socket: %w", err)
+	}
+	if st.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("ipc socket path socket emas: %s", path)
+	}
+	if c, err := net.Dial("unix", path); err == nil {
+		_ = c.Close()
+		return fmt.Errorf("ipc socket band: %s", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove stale ipc socket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+// Package metrics Prometheus text formatidagi (0.0.4) kichik exporter.
+// Tashqi client kutubxonasisiz: scale va bot faqat counter, gauge va
+// histogram ishlatadi, ularni shu yerda yozish yetarli.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets sekundlarda: ERP/HTTP chaqiruvlari uchun.
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Registry metrikalar to'plami. constLabels har bir qatorga qo'shiladi
+// (masalan station), shunda bir nechta stansiya bitta Prometheus'da ajraladi.
+type Registry struct {
+	mu          sync.Mutex
+	constLabels []labelPair
+	metrics     []metric
+	names       map[string]bool
+}
+
+type labelPair struct {
+	name  string
+	value string
+}
+
+type metric interface {
+	name() string
+	write(w io.Writer, constLabels []labelPair) error
+}
+
+func NewRegistry(constLabels map[string]string) *Registry {
+	r := &Registry{names: map[string]bool{}}
+	keys := make([]string, 0, len(constLabels))
+	for k := range constLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.constLabels = append(r.constLabels, labelPair{name: k, value: constLabels[k]})
+	}
+	return r
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[m.name()] {
+		panic(fmt.Sprintf("metrics: %q ikki marta ro'yxatdan o'tkazildi", m.name()))
+	}
+	r.names[m.name()] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// Counter faqat oshadigan qiymat.
+func (r *Registry) Counter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{family: newFamily(name, help, "counter", labelNames)}
+	r.register(c)
+	return c
+}
+
+// Gauge ixtiyoriy qiymat (masalan aktiv sessiyalar soni).
+func (r *Registry) Gauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{family: newFamily(name, help, "gauge", labelNames)}
+	r.register(g)
+	return g
+}
+
+// Histogram qiymatlar taqsimoti; buckets o'sish tartibida bo'lishi kerak.
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	h := &Histogram{family: newFamily(name, help, "histogram", labelNames), buckets: b}
+	r.register(h)
+	return h
+}
+
+// Write barcha metrikalarni text formatda yozadi.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	list := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+	for _, m := range list {
+		if err := m.write(w, r.constLabels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler `/metrics` uchun http.Handler.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.Write(w)
+	})
+}
+
+// Start `/metrics` ni addr'da ochadi; server ctx tugaganda yopiladi.
+// Haqiqiy manzil (":0" uchun) qaytariladi.
+func Start(ctx context.Context, addr string, r *Registry) (net.Addr, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return nil, errors.New("metrics addr bo'sh")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics listen: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", r.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+	return ln.Addr(), nil
+}
+
+type family struct {
+	fname      string
+	help       string
+	kind       string
+	labelNames []string
+}
+
+func newFamily(name, help, kind string, labelNames []string) family {
+	return family{fname: name, help: help, kind: kind, labelNames: append([]string(nil), labelNames...)}
+}
+
+func (f family) name() string { return f.fname }
+
+func (f family) key(values []string) string {
+	if len(values) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: %s uchun %d ta label kerak, %d berildi", f.fname, len(f.labelNames), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func (f family) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.fname, escapeHelp(f.help), f.fname, f.kind)
+	return err
+}
+
+func (f family) labels(constLabels []labelPair, values []string, extra ...labelPair) string {
+	pairs := append([]labelPair(nil), constLabels...)
+	for i, n := range f.labelNames {
+		pairs = append(pairs, labelPair{name: n, value: values[i]})
+	}
+	pairs = append(pairs, extra...)
+	if len(pairs) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		parts = append(parts, p.name+`="`+escapeLabel(p.value)+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+type series struct {
+	values []string
+	value  float64
+}
+
+type seriesSet struct {
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func (s *seriesSet) get(key string, values []string) *series {
+	if s.series == nil {
+		s.series = map[string]*series{}
+	}
+	cur, ok := s.series[key]
+	if !ok {
+		cur = &series{values: append([]string(nil), values...)}
+		s.series[key] = cur
+	}
+	return cur
+}
+
+func (s *seriesSet) sorted() []series {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.series))
+	for k := range s.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]series, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, *s.series[k])
+	}
+	return out
+}
+
+func (f family) writeSeries(w io.Writer, constLabels []labelPair, set *seriesSet) error {
+	if err := f.header(w); err != nil {
+		return err
+	}
+	for _, s := range set.sorted() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", f.fname, f.labels(constLabels, s.values), formatFloat(s.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Counter struct {
+	family
+	set seriesSet
+}
+
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add manfiy qiymatni e'tiborsiz qoldiradi: counter kamaymaydi.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if c == nil || v < 0 {
+		return
+	}
+	key := c.key(labelValues)
+	c.set.mu.Lock()
+	c.set.get(key, labelValues).value += v
+	c.set.mu.Unlock()
+}
+
+// Value test va diagnostika uchun joriy qiymat.
+func (c *Counter) Value(labelValues ...string) float64 {
+	if c == nil {
+		return 0
+	}
+	key := c.key(labelValues)
+	c.set.mu.Lock()
+	defer c.set.mu.Unlock()
+	if s, ok := c.set.series[key]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (c *Counter) write(w io.Writer, constLabels []labelPair) error {
+	return c.writeSeries(w, constLabels, &c.set)
+}
+
+type Gauge struct {
+	family
+	set seriesSet
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	if g == nil {
+		return
+	}
+	key := g.key(labelValues)
+	g.set.mu.Lock()
+	g.set.get(key, labelValues).value = v
+	g.set.mu.Unlock()
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	if g == nil {
+		return
+	}
+	key := g.key(labelValues)
+	g.set.mu.Lock()
+	g.set.get(key, labelValues).value += v
+	g.set.mu.Unlock()
+}
+
+func (g *Gauge) Value(labelValues ...string) float64 {
+	if g == nil {
+		return 0
+	}
+	key := g.key(labelValues)
+	g.set.mu.Lock()
+	defer g.set.mu.Unlock()
+	if s, ok := g.set.series[key]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (g *Gauge) write(w io.Writer, constLabels []labelPair) error {
+	return g.writeSeries(w, constLabels, &g.set)
+}
+
+type Histogram struct {
+	family
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histSeries
+}
+
+type histSeries struct {
+	values []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	if h == nil {
+		return
+	}
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.series == nil {
+		h.series = map[string]*histSeries{}
+	}
+	s, ok := h.series[key]
+	if !ok {
+		s = &histSeries{values: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w io.Writer, constLabels []labelPair) error {
+	if err := h.header(w); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	snap := make([]histSeries, 0, len(keys))
+	for _, k := range keys {
+		s := *h.series[k]
+		s.counts = append([]uint64(nil), s.counts...)
+		snap = append(snap, s)
+	}
+	h.mu.Unlock()
+
+	for _, s := range snap {
+		for i, b := range h.buckets {
+			lbl := h.labels(constLabels, s.values, labelPair{name: "le", value: formatFloat(b)})
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.fname, lbl, s.counts[i]); err != nil {
+				return err
+			}
+		}
+		lbl := h.labels(constLabels, s.values, labelPair{name: "le", value: "+Inf"})
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.fname, lbl, s.count); err != nil {
+			return err
+		}
+		base := h.labels(constLabels, s.values)
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", h.fname, base, formatFloat(s.sum), h.fname, base, s.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
+
+func escapeHelp(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
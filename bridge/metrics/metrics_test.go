@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteTextFormat(t *testing.T) {
+	r := NewRegistry(map[string]string{"station": "line-1"})
+	enc := r.Counter("gscale_zebra_encode_total", "Encode urinishlari.", "verify")
+	active := r.Gauge("gscale_batch_sessions_active", "Aktiv batch sessiyalar.")
+	lat := r.Histogram("gscale_erp_draft_seconds", "ERP draft latency.", []float64{0.5, 1})
+
+	enc.Inc("MATCH")
+	enc.Inc("MATCH")
+	enc.Inc(`NO "TAG"`)
+	active.Set(2)
+	lat.Observe(0.3)
+	lat.Observe(0.8)
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE gscale_zebra_encode_total counter\n",
+		`gscale_zebra_encode_total{station="line-1",verify="MATCH"} 2` + "\n",
+		`gscale_zebra_encode_total{station="line-1",verify="NO \"TAG\""} 1` + "\n",
+		`gscale_batch_sessions_active{station="line-1"} 2` + "\n",
+		`gscale_erp_draft_seconds_bucket{station="line-1",le="0.5"} 1` + "\n",
+		`gscale_erp_draft_seconds_bucket{station="line-1",le="+Inf"} 2` + "\n",
+		`gscale_erp_draft_seconds_count{station="line-1"} 2` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("content-type=%q", ct)
+	}
+	if got := enc.Value("MATCH"); got != 2 {
+		t.Fatalf("Value=%v, want 2", got)
+	}
+}
+
+func TestCounterIgnoresNegativeAndNil(t *testing.T) {
+	r := NewRegistry(nil)
+	c := r.Counter("x_total", "x")
+	c.Add(-1)
+	c.Inc()
+	if got := c.Value(); got != 1 {
+		t.Fatalf("Value=%v, want 1", got)
+	}
+	var nilCounter *Counter
+	nilCounter.Inc()
+}
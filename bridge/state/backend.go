@@ -0,0 +1,105 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// BackendFile JSON fayl (default): flock + rename, backup va karantin bilan.
+	BackendFile = "file"
+	// BackendSQLite embedded SQLite (pure-Go): snapshot + tarix jadvallari, reboot'dan keyin saqlanadi.
+	BackendSQLite = "sqlite"
+)
+
+// Backend bridge state hujjati qayerda saqlanishini belgilaydi.
+// Store stansiya ko'rinishi, revision/CompareAndUpdate va journal'ni boshqaradi;
+// Backend faqat hujjatni o'qish va lock ostida atomik almashtirish uchun javob beradi.
+type Backend interface {
+	// Path Watch kuzatadigan fayl: har yozuvda shu fayl o'zgaradi.
+	Path() string
+	// Load joriy hujjatni o'qiydi. Hali yozilmagan bo'lsa os.ErrNotExist qaytadi.
+	Load() (Document, error)
+	// Update lock ostida joriy hujjatni (yo'q bo'lsa bo'sh hujjat) mutate'ga beradi.
+	// mutate xato qaytarsa hech narsa yozilmaydi. commit (nil bo'lmasa) hujjat saqlanishidan
+	// oldin, lock ostida chaqiriladi (journal tartibi uchun); commit xato qaytarsa hujjat yozilmaydi.
+	Update(mutate func(*Document) error, commit func(Document) error) error
+	// Health saqlash joyining holati; hech narsani o'zgartirmaydi.
+	Health() Health
+	Close() error
+}
+
+// HistoryBackend o'z tarixini saqlaydigan backend (SQLite bridge_history).
+type HistoryBackend interface {
+	// History stansiyaning since dan keyingi snapshot'larini revision tartibida qaytaradi.
+	History(station string, since time.Time, limit int) ([]Snapshot, error)
+}
+
+// ErrNoHistory backend tarix saqlamasa va journal ham o'chirilgan bo'lsa qaytadi.
+var ErrNoHistory = errors.New("bridge state tarixi yo'q (sqlite backend yoki journal kerak)")
+
+// DefaultHistoryLimit Store.History uchun limit berilmaganda.
+const DefaultHistoryLimit = 1000
+
+// History Store stansiyasining since dan keyingi snapshot'lari: SQLite backend'da
+// bridge_history jadvalidan, aks holda journal'dan.
+func (s *Store) History(since time.Time, limit int) ([]Snapshot, error) {
+	if s == nil || s.backend == nil {
+		return nil, ErrNoHistory
+	}
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	if hb, ok := s.backend.(HistoryBackend); ok {
+		return hb.History(s.station, since, limit)
+	}
+	if s.journal == nil {
+		return nil, ErrNoHistory
+	}
+	var out []Snapshot
+	errStop := errors.New("stop")
+	err := s.journal.Replay(func(rec JournalRecord) error {
+		// Tarixga faqat shu stansiya o'zgargan yozuvlar tushadi.
+		if rec.StationID() != s.station {
+			return nil
+		}
+		if at, err := time.Parse(time.RFC3339Nano, rec.At); err != nil || at.Before(since) {
+			return nil
+		}
+		snap, _ := rec.StationSnapshot(s.station)
+		out = append(out, snap)
+		if len(out) >= limit {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NormalizeBackend bo'sh qiymatni BackendFile ga aylantiradi.
+func NormalizeBackend(kind string) string {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		return BackendFile
+	}
+	return kind
+}
+
+// ValidateBackend faqat ma'lum backend nomlarini qabul qiladi.
+func ValidateBackend(kind string) error {
+	switch NormalizeBackend(kind) {
+	case BackendFile, BackendSQLite:
+		return nil
+	default:
+		return fmt.Errorf("bridge backend noma'lum: %q (file yoki sqlite)", kind)
+	}
+}
+
+func newDocument() Document {
+	return Document{SchemaVersion: CurrentSchemaVersion, Stations: map[string]StationState{}}
+}
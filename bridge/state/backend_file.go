@@ -0,0 +1,110 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// fileBackend JSON fayl: yozish `.lock` flock ostida `.tmp` + rename bilan,
+// buzilgan fayl karantinga olinib `.bak.N` dan tiklanadi.
+type fileBackend struct {
+	path           string
+	journal        *Journal
+	backups        int
+	backupInterval time.Duration
+}
+
+func newFileBackend(path string, journal *Journal, opts Options) *fileBackend {
+	b := &fileBackend{
+		path:           path,
+		journal:        journal,
+		backups:        opts.Backups,
+		backupInterval: opts.BackupInterval,
+	}
+	if b.backups == 0 {
+		b.backups = DefaultBackups
+	}
+	if b.backupInterval <= 0 {
+		b.backupInterval = DefaultBackupInterval
+	}
+	return b
+}
+
+func (s *fileBackend) Path() string { return s.path }
+
+func (s *fileBackend) Close() error { return nil }
+
+func (s *fileBackend) Load() (Document, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return Document{}, err
+	}
+	doc, err := decodeDocument(b)
+	if errors.Is(err, ErrCorrupt) {
+		// Buzilgan fayl: lock ostida karantin + backup'dan tiklash.
+		unlock, lerr := lockFile(s.path + ".lock")
+		if lerr != nil {
+			return Document{}, err
+		}
+		defer unlock()
+		doc, _, err = s.loadLocked()
+	}
+	return doc, err
+}
+
+func (s *fileBackend) Update(mutate func(*Document) error, commit func(Document) error) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("mkdir bridge dir: %w", err)
+	}
+
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock bridge state: %w", err)
+	}
+	defer unlock()
+
+	// Yangiroq sxemadagi faylni ustidan yozmaymiz: boshqa release'dagi binary
+	// yozgan maydonlar jimgina yo'qolmasin. Buzilgan fayl esa karantinga olinib,
+	// oxirgi sog'lom backup'dan tiklanadi.
+	doc, good, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	if err := mutate(&doc); err != nil {
+		return err
+	}
+
+	// Journal birinchi: append xato bersa fayl o'zgarmaydi va caller'ning qayta urinishi
+	// allaqachon saqlangan yozuvni takrorlamaydi.
+	if commit != nil {
+		if err := commit(doc); err != nil {
+			return err
+		}
+	}
+	if good {
+		if err := s.rotateBackupsLocked(time.Now()); err != nil {
+			return err
+		}
+	}
+	return s.writeLocked(doc)
+}
+
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	unlock := func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}
+	return unlock, nil
+}
@@ -0,0 +1,260 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// DefaultSQLiteHistory bridge_history jadvalida saqlanadigan oxirgi revision'lar soni.
+const DefaultSQLiteHistory = 200000
+
+// sqliteSchema: bridge_document - to'liq hujjat (Load shu yerdan o'qiydi),
+// bridge_station - har stansiyaning oxirgi holati (SQL bilan so'rash uchun),
+// bridge_history - har yozuvda o'zgargan stansiya snapshot'i.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS bridge_document (
+	id             INTEGER PRIMARY KEY CHECK (id = 1),
+	schema_version INTEGER NOT NULL,
+	revision       INTEGER NOT NULL,
+	updated_at     TEXT NOT NULL,
+	document       TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS bridge_station (
+	station    TEXT PRIMARY KEY,
+	revision   INTEGER NOT NULL,
+	updated_at TEXT NOT NULL,
+	scale      TEXT NOT NULL,
+	zebra      TEXT NOT NULL,
+	batch      TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS bridge_history (
+	revision INTEGER PRIMARY KEY,
+	station  TEXT NOT NULL,
+	at       TEXT NOT NULL,
+	at_ns    INTEGER NOT NULL,
+	snapshot TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS bridge_history_station_at ON bridge_history (station, at_ns);
+`
+
+// sqliteBackend embedded SQLite (modernc.org/sqlite, cgo'siz).
+// Yozuvlar BEGIN IMMEDIATE tranzaksiyada, shuning uchun scale va bot
+// bir faylga parallel yozsa ham biri ikkinchisini kutadi.
+type sqliteBackend struct {
+	path    string
+	history int
+
+	mu sync.Mutex
+	db *sql.DB
+}
+
+func newSQLiteBackend(path string) *sqliteBackend {
+	return &sqliteBackend{path: path, history: DefaultSQLiteHistory}
+}
+
+func (s *sqliteBackend) Path() string { return s.path }
+
+// open ulanishni va jadvallarni birinchi kerak bo'lganda yaratadi (s.mu ostida).
+func (s *sqliteBackend) open() (*sql.DB, error) {
+	if s.db != nil {
+		return s.db, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir bridge dir: %w", err)
+	}
+	// journal_mode=DELETE: commit asosiy faylni o'zgartiradi, Watch (inotify) shuni ushlaydi.
+	dsn := "file:" + s.path + "?_txlock=immediate&_pragma=busy_timeout(5000)&_pragma=journal_mode(DELETE)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open bridge sqlite: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		// Ulanish saqlanmaydi: vaqtinchalik xato (masalan lock) keyingi chaqiruvda qayta uriniladi.
+		return nil, fmt.Errorf("bridge sqlite schema: %w", err)
+	}
+	s.db = db
+	return db, nil
+}
+
+func (s *sqliteBackend) Load() (Document, error) {
+	if _, err := os.Stat(s.path); err != nil {
+		return Document{}, err
+	}
+	s.mu.Lock()
+	db, err := s.open()
+	s.mu.Unlock()
+	if err != nil {
+		return Document{}, err
+	}
+	var raw string
+	err = db.QueryRow(`SELECT document FROM bridge_document WHERE id = 1`).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Document{}, fmt.Errorf("bridge sqlite hali yozilmagan: %w", os.ErrNotExist)
+	}
+	if err != nil {
+		return Document{}, fmt.Errorf("read bridge sqlite: %w", err)
+	}
+	return decodeDocument([]byte(raw))
+}
+
+func (s *sqliteBackend) Update(mutate func(*Document) error, commit func(Document) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("lock bridge sqlite: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	doc := newDocument()
+	var raw string
+	switch err := tx.QueryRow(`SELECT document FROM bridge_document WHERE id = 1`).Scan(&raw); {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("read bridge sqlite: %w", err)
+	default:
+		// Yangiroq sxema yoki buzilgan hujjat ustidan yozilmaydi.
+		if doc, err = decodeDocument([]byte(raw)); err != nil {
+			return err
+		}
+	}
+
+	if err := mutate(&doc); err != nil {
+		return err
+	}
+	if err := s.writeTx(tx, doc); err != nil {
+		return err
+	}
+	// commit (journal) tranzaksiya ichida: IMMEDIATE lock boshqa process'larni
+	// ham to'xtatib turadi, shuning uchun journal tartibi buzilmaydi.
+	if commit != nil {
+		if err := commit(doc); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit bridge sqlite: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteBackend) writeTx(tx *sql.Tx, doc Document) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("marshal bridge state: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO bridge_document (id, schema_version, revision, updated_at, document)
+		VALUES (1, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET schema_version = excluded.schema_version, revision = excluded.revision,
+			updated_at = excluded.updated_at, document = excluded.document`,
+		doc.SchemaVersion, int64(doc.Revision), doc.UpdatedAt, string(b)); err != nil {
+		return fmt.Errorf("write bridge sqlite: %w", err)
+	}
+
+	for id, st := range doc.Stations {
+		scale, _ := json.Marshal(st.Scale)
+		zebra, _ := json.Marshal(st.Zebra)
+		batch, _ := json.Marshal(st.Batch)
+		if _, err := tx.Exec(`INSERT INTO bridge_station (station, revision, updated_at, scale, zebra, batch)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (station) DO UPDATE SET revision = excluded.revision, updated_at = excluded.updated_at,
+				scale = excluded.scale, zebra = excluded.zebra, batch = excluded.batch`,
+			id, int64(st.Revision), st.UpdatedAt, string(scale), string(zebra), string(batch)); err != nil {
+			return fmt.Errorf("write bridge sqlite station: %w", err)
+		}
+		// Tarixga faqat shu yozuvda o'zgargan stansiya tushadi.
+		if st.Revision != doc.Revision {
+			continue
+		}
+		at, _ := time.Parse(time.RFC3339Nano, doc.UpdatedAt)
+		snap, _ := json.Marshal(doc.snapshot(id))
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO bridge_history (revision, station, at, at_ns, snapshot) VALUES (?, ?, ?, ?, ?)`,
+			int64(doc.Revision), id, doc.UpdatedAt, at.UnixNano(), string(snap)); err != nil {
+			return fmt.Errorf("write bridge sqlite history: %w", err)
+		}
+	}
+	if s.history > 0 && doc.Revision > uint64(s.history) {
+		if _, err := tx.Exec(`DELETE FROM bridge_history WHERE revision <= ?`, int64(doc.Revision)-int64(s.history)); err != nil {
+			return fmt.Errorf("prune bridge sqlite history: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *sqliteBackend) Health() Health {
+	h := Health{Path: s.path, Backend: BackendSQLite}
+	if _, err := os.Stat(s.path); err != nil {
+		if os.IsNotExist(err) {
+			h.OK = true
+		} else {
+			h.Error = err.Error()
+		}
+		return h
+	}
+	h.Exists = true
+	doc, err := s.Load()
+	switch {
+	case err == nil:
+		h.OK = true
+		h.SchemaVersion = doc.SchemaVersion
+		h.UpdatedAt = doc.UpdatedAt
+	case errors.Is(err, os.ErrNotExist):
+		h.OK = true
+	default:
+		h.Error = err.Error()
+	}
+	return h
+}
+
+func (s *sqliteBackend) History(station string, since time.Time, limit int) ([]Snapshot, error) {
+	s.mu.Lock()
+	db, err := s.open()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT snapshot FROM bridge_history WHERE station = ? AND at_ns >= ? ORDER BY revision LIMIT ?`,
+		NormalizeStationID(station), since.UnixNano(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query bridge sqlite history: %w", err)
+	}
+	defer rows.Close()
+	var out []Snapshot
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var snap Snapshot
+		if err := json.Unmarshal([]byte(raw), &snap); err != nil {
+			return nil, fmt.Errorf("%w: tarix qatori: %v", ErrCorrupt, err)
+		}
+		out = append(out, snap)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteBackend) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
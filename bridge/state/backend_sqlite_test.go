@@ -0,0 +1,118 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newSQLiteTestStore(t *testing.T, path string, station string) *Store {
+	t.Helper()
+	s := NewWithOptions(path, Options{Backend: BackendSQLite, StationID: station})
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestSQLiteBackendPersistsAcrossReopen(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "state", "bridge_state.db")
+	s := newSQLiteTestStore(t, p, "")
+
+	if _, err := s.Read(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("empty Read error=%v, want ErrNotExist", err)
+	}
+	w := 4.25
+	if err := s.Update(func(snap *Snapshot) { snap.Scale.Weight = &w; snap.Batch.Active = true }); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if err := s.CompareAndUpdate(1, func(snap *Snapshot) { snap.Zebra.LastEPC = "3034AA" }); err != nil {
+		t.Fatalf("CompareAndUpdate error: %v", err)
+	}
+	if err := s.CompareAndUpdate(1, func(snap *Snapshot) {}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("stale CompareAndUpdate error=%v, want ErrConflict", err)
+	}
+	_ = s.Close()
+
+	reopened := newSQLiteTestStore(t, p, "")
+	got, err := reopened.Read()
+	if err != nil {
+		t.Fatalf("Read after reopen error: %v", err)
+	}
+	if got.Revision != 2 || got.Scale.Weight == nil || *got.Scale.Weight != 4.25 || got.Zebra.LastEPC != "3034AA" || !got.Batch.Active {
+		t.Fatalf("snapshot mismatch: %+v", got)
+	}
+	if h := reopened.Health(); !h.OK || !h.Exists || h.Backend != BackendSQLite {
+		t.Fatalf("health mismatch: %+v", h)
+	}
+}
+
+func TestSQLiteHistoryPerStation(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.db")
+	line1 := newSQLiteTestStore(t, p, "line-1")
+	line2 := line1.Station("line-2")
+	start := time.Now().Add(-time.Second)
+
+	for _, epc := range []string{"A1", "A2"} {
+		if err := line1.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = epc }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := line2.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "B1" }); err != nil {
+		t.Fatal(err)
+	}
+
+	hist, err := line1.History(start, 0)
+	if err != nil {
+		t.Fatalf("History error: %v", err)
+	}
+	if len(hist) != 2 || hist[0].Zebra.LastEPC != "A1" || hist[1].Zebra.LastEPC != "A2" || hist[1].Revision != 2 {
+		t.Fatalf("line-1 history mismatch: %+v", hist)
+	}
+	hist2, err := line2.History(start, 0)
+	if err != nil || len(hist2) != 1 || hist2[0].StationID != "line-2" {
+		t.Fatalf("line-2 history mismatch: %+v err=%v", hist2, err)
+	}
+	if later, err := line1.History(time.Now().Add(time.Hour), 0); err != nil || len(later) != 0 {
+		t.Fatalf("future history = %+v err=%v", later, err)
+	}
+}
+
+func TestSQLiteWatchSeesUpdates(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.db")
+	writer := newSQLiteTestStore(t, p, "")
+	if err := writer.Update(func(snap *Snapshot) {}); err != nil {
+		t.Fatal(err)
+	}
+	reader := newSQLiteTestStore(t, p, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch := reader.Watch(ctx)
+	<-ch
+
+	if err := writer.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "C1" }); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		select {
+		case snap := <-ch:
+			if snap.Zebra.LastEPC == "C1" {
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("Watch sqlite o'zgarishini bermadi")
+		}
+	}
+}
+
+func TestHistoryWithoutSource(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "bridge_state.json"))
+	if _, err := s.History(time.Time{}, 0); !errors.Is(err, ErrNoHistory) {
+		t.Fatalf("History error=%v, want ErrNoHistory", err)
+	}
+	if err := ValidateBackend("redis"); err == nil {
+		t.Fatal("unknown backend should fail validation")
+	}
+}
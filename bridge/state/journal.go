@@ -0,0 +1,391 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	journalRecordVersion       = 1
+	journalSegmentPrefix       = "journal-"
+	journalSegmentSuffix       = ".jsonl"
+	defaultJournalSegmentBytes = 4 << 20
+	defaultJournalMaxSegments  = 64
+	journalTailProbeBytes      = 64 << 10
+)
+
+var ErrJournalRevisionNotFound = errors.New("journal: revision topilmadi")
+
+// Journal bridge state'ning append-only tarixi.
+// Har Store.Update to'liq hujjat (barcha stansiyalar) bilan bitta versiyalangan yozuv qo'shadi; yozuvlar
+// journal-00000001.jsonl, journal-00000002.jsonl ... segmentlarida saqlanadi.
+// Append faqat Store lock ostida chaqiriladi, shu sabab bir nechta process
+// bitta journal'ga xavfsiz yozadi.
+type Journal struct {
+	dir          string
+	segmentBytes int64
+	maxSegments  int
+}
+
+// JournalRecord Rev - Document.Revision, Station - shu yozuvda o'zgargan stansiya.
+type JournalRecord struct {
+	Version  int       `json:"v"`
+	Rev      uint64    `json:"rev"`
+	At       string    `json:"at"`
+	Station  string    `json:"station,omitempty"`
+	Document *Document `json:"document,omitempty"`
+}
+
+// StationID yozuvda o'zgargan stansiya.
+func (r JournalRecord) StationID() string {
+	return NormalizeStationID(r.Station)
+}
+
+// StationSnapshot yozuv paytida id stansiyasi qanday ko'rinishda edi. Hujjatda bu stansiya
+// bo'lmasa ok=false.
+func (r JournalRecord) StationSnapshot(id string) (Snapshot, bool) {
+	if r.Document == nil {
+		return Snapshot{}, false
+	}
+	id = NormalizeStationID(id)
+	_, ok := r.Document.Stations[id]
+	return r.Document.snapshot(id), ok
+}
+
+// FullDocument yozuv paytidagi to'liq hujjat.
+func (r JournalRecord) FullDocument() Document {
+	if r.Document != nil {
+		return *r.Document
+	}
+	doc := newDocument()
+	doc.Revision = r.Rev
+	return doc
+}
+
+func OpenJournal(dir string, segmentBytes int64, maxSegments int) *Journal {
+	if segmentBytes <= 0 {
+		segmentBytes = defaultJournalSegmentBytes
+	}
+	if maxSegments <= 0 {
+		maxSegments = defaultJournalMaxSegments
+	}
+	return &Journal{dir: strings.TrimSpace(dir), segmentBytes: segmentBytes, maxSegments: maxSegments}
+}
+
+func (j *Journal) Dir() string {
+	if j == nil {
+		return ""
+	}
+	return j.dir
+}
+
+// Since rev dan keyingi (rev o'zi kirmaydi) barcha yozuvlarni qaytaradi.
+func (j *Journal) Since(rev uint64) ([]JournalRecord, error) {
+	out := make([]JournalRecord, 0, 64)
+	err := j.Replay(func(rec JournalRecord) error {
+		if rec.Rev > rev {
+			out = append(out, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// At aynan rev revision'dagi to'liq hujjatni (barcha stansiyalar) qayta tiklaydi. Journal
+// hujjatdan oldin yoziladi: hujjat saqlanmay qolgan yozuvdan keyin xuddi shu rev qayta
+// yozilishi mumkin, oxirgisi olinadi.
+func (j *Journal) At(rev uint64) (Document, error) {
+	var out JournalRecord
+	found := false
+	err := j.Replay(func(rec JournalRecord) error {
+		if rec.Rev == rev {
+			out = rec
+			found = true
+		}
+		return nil
+	})
+	if err != nil {
+		return Document{}, err
+	}
+	if !found {
+		return Document{}, fmt.Errorf("%w: %d", ErrJournalRevisionNotFound, rev)
+	}
+	return out.FullDocument(), nil
+}
+
+// AsOf t vaqtida bridge qanday ko'rinishda bo'lganini qaytaradi:
+// t dan oldin yoki t paytida yozilgan oxirgi yozuv.
+func (j *Journal) AsOf(t time.Time) (JournalRecord, bool, error) {
+	var out JournalRecord
+	found := false
+	err := j.Replay(func(rec JournalRecord) error {
+		at, perr := time.Parse(time.RFC3339Nano, rec.At)
+		if perr != nil {
+			return nil
+		}
+		if at.After(t) {
+			return io.EOF
+		}
+		out = rec
+		found = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, io.EOF) {
+		return JournalRecord{}, false, err
+	}
+	return out, found, nil
+}
+
+// Replay barcha yozuvlarni revision tartibida fn ga beradi.
+// fn xato qaytarsa replay to'xtaydi va shu xato qaytadi.
+// Yarim yozilgan (crash paytida uzilgan) qatorlar tashlab ketiladi.
+func (j *Journal) Replay(fn func(JournalRecord) error) error {
+	if j == nil || j.dir == "" {
+		return fmt.Errorf("journal dir bo'sh")
+	}
+	segments, err := j.segments()
+	if err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		if err := replaySegment(seg, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *Journal) LastRev() (uint64, error) {
+	if j == nil || j.dir == "" {
+		return 0, fmt.Errorf("journal dir bo'sh")
+	}
+	segments, err := j.segments()
+	if err != nil {
+		return 0, err
+	}
+	for i := len(segments) - 1; i >= 0; i-- {
+		rec, ok, err := lastRecord(segments[i])
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return rec.Rev, nil
+		}
+	}
+	return 0, nil
+}
+
+func (j *Journal) append(doc Document, station string, at time.Time) (uint64, error) {
+	if j == nil || j.dir == "" {
+		return 0, nil
+	}
+	if err := os.MkdirAll(j.dir, 0o755); err != nil {
+		return 0, fmt.Errorf("mkdir journal dir: %w", err)
+	}
+
+	rev := doc.Revision
+	if rev == 0 {
+		last, err := j.LastRev()
+		if err != nil {
+			return 0, err
+		}
+		rev = last + 1
+	}
+	rec := JournalRecord{
+		Version:  journalRecordVersion,
+		Rev:      rev,
+		At:       at.UTC().Format(time.RFC3339Nano),
+		Station:  NormalizeStationID(station),
+		Document: &doc,
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return 0, fmt.Errorf("marshal journal record: %w", err)
+	}
+
+	seg, err := j.activeSegment()
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(seg, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("open journal segment: %w", err)
+	}
+	defer f.Close()
+
+	// Oldingi yozuv crash tufayli yarim qolgan bo'lsa yangi yozuv unga yopishmasin.
+	if st, err := f.Stat(); err == nil && st.Size() > 0 && !endsWithNewline(seg, st.Size()) {
+		b = append([]byte{'\n'}, b...)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return 0, fmt.Errorf("write journal record: %w", err)
+	}
+	return rec.Rev, nil
+}
+
+func (j *Journal) activeSegment() (string, error) {
+	segments, err := j.segments()
+	if err != nil {
+		return "", err
+	}
+	if len(segments) == 0 {
+		return j.segmentPath(1), nil
+	}
+	last := segments[len(segments)-1]
+	st, err := os.Stat(last)
+	if err != nil {
+		return "", fmt.Errorf("stat journal segment: %w", err)
+	}
+	if st.Size() < j.segmentBytes {
+		return last, nil
+	}
+
+	next := j.segmentPath(segmentNumber(last) + 1)
+	j.prune(append(segments, next))
+	return next, nil
+}
+
+// prune eng eski segmentlarni o'chiradi, toki maxSegments dan oshmasin.
+func (j *Journal) prune(segments []string) {
+	for len(segments) > j.maxSegments {
+		_ = os.Remove(segments[0])
+		segments = segments[1:]
+	}
+}
+
+func (j *Journal) segments() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(j.dir, journalSegmentPrefix+"*"+journalSegmentSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("list journal segments: %w", err)
+	}
+	sort.Slice(matches, func(a, b int) bool {
+		return segmentNumber(matches[a]) < segmentNumber(matches[b])
+	})
+	return matches, nil
+}
+
+func (j *Journal) segmentPath(n int) string {
+	return filepath.Join(j.dir, fmt.Sprintf("%s%08d%s", journalSegmentPrefix, n, journalSegmentSuffix))
+}
+
+func segmentNumber(path string) int {
+	name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), journalSegmentPrefix), journalSegmentSuffix)
+	n := 0
+	for _, ch := range name {
+		if ch < '0' || ch > '9' {
+			return 0
+		}
+		n = n*10 + int(ch-'0')
+	}
+	return n
+}
+
+func replaySegment(path string, fn func(JournalRecord) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Prune parallel ravishda o'chirgan bo'lishi mumkin.
+			return nil
+		}
+		return fmt.Errorf("open journal segment: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), 4<<20)
+	for sc.Scan() {
+		rec, ok := decodeJournalLine(sc.Bytes())
+		if !ok {
+			continue
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read journal segment: %w", err)
+	}
+	return nil
+}
+
+func lastRecord(path string) (JournalRecord, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return JournalRecord{}, false, nil
+		}
+		return JournalRecord{}, false, fmt.Errorf("open journal segment: %w", err)
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return JournalRecord{}, false, fmt.Errorf("stat journal segment: %w", err)
+	}
+	size := st.Size()
+	probe := int64(journalTailProbeBytes)
+	for {
+		if probe > size {
+			probe = size
+		}
+		buf := make([]byte, probe)
+		if _, err := f.ReadAt(buf, size-probe); err != nil && !errors.Is(err, io.EOF) {
+			return JournalRecord{}, false, fmt.Errorf("read journal tail: %w", err)
+		}
+		lines := bytes.Split(buf, []byte{'\n'})
+		// Birinchi bo'lak to'liq qator bo'lmasligi mumkin (probe boshidan kesilgan).
+		first := 1
+		if probe == size {
+			first = 0
+		}
+		for i := len(lines) - 1; i >= first; i-- {
+			if rec, ok := decodeJournalLine(lines[i]); ok {
+				return rec, true, nil
+			}
+		}
+		if probe == size {
+			return JournalRecord{}, false, nil
+		}
+		probe *= 2
+	}
+}
+
+func decodeJournalLine(line []byte) (JournalRecord, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return JournalRecord{}, false
+	}
+	var rec JournalRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return JournalRecord{}, false
+	}
+	if rec.Version <= 0 || rec.Rev == 0 || rec.Document == nil {
+		return JournalRecord{}, false
+	}
+	return rec, true
+}
+
+func endsWithNewline(path string, size int64) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	b := make([]byte, 1)
+	if _, err := f.ReadAt(b, size-1); err != nil {
+		return true
+	}
+	return b[0] == '\n'
+}
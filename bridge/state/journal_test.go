@@ -0,0 +1,205 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalRecordsEveryUpdate(t *testing.T) {
+	d := t.TempDir()
+	s := NewWithOptions(filepath.Join(d, "bridge_state.json"), Options{JournalDir: filepath.Join(d, "journal")})
+
+	for i := 1; i <= 3; i++ {
+		w := float64(i)
+		if err := s.Update(func(snap *Snapshot) {
+			snap.Scale.Weight = &w
+		}); err != nil {
+			t.Fatalf("Update error: %v", err)
+		}
+	}
+	if err := s.Update(func(snap *Snapshot) {
+		snap.Batch.Active = true
+		snap.Batch.ItemCode = "ITM-001"
+	}); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+
+	j := s.Journal()
+	last, err := j.LastRev()
+	if err != nil {
+		t.Fatalf("LastRev error: %v", err)
+	}
+	if last != 4 {
+		t.Fatalf("last rev mismatch: got=%d want=4", last)
+	}
+
+	recs, err := j.Since(2)
+	if err != nil {
+		t.Fatalf("Since error: %v", err)
+	}
+	if len(recs) != 2 || recs[0].Rev != 3 || recs[1].Rev != 4 {
+		t.Fatalf("since mismatch: %+v", recs)
+	}
+	if recs[1].Version != journalRecordVersion {
+		t.Fatalf("record version mismatch: %d", recs[1].Version)
+	}
+
+	doc, err := j.At(2)
+	if err != nil {
+		t.Fatalf("At error: %v", err)
+	}
+	snap := doc.snapshot(DefaultStationID)
+	if snap.Scale.Weight == nil || *snap.Scale.Weight != 2 {
+		t.Fatalf("rev 2 weight mismatch: %+v", snap.Scale.Weight)
+	}
+	if snap.Batch.Active {
+		t.Fatalf("rev 2 should be before batch start")
+	}
+
+	if _, err := j.At(99); err == nil {
+		t.Fatal("missing revision should fail")
+	}
+}
+
+func TestJournalAsOf(t *testing.T) {
+	d := t.TempDir()
+	s := NewWithOptions(filepath.Join(d, "bridge_state.json"), Options{JournalDir: d})
+
+	if err := s.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "AAAA0001" }); err != nil {
+		t.Fatal(err)
+	}
+	mid := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	if err := s.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "AAAA0002" }); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, ok, err := s.Journal().AsOf(mid)
+	if err != nil || !ok {
+		t.Fatalf("AsOf error: ok=%v err=%v", ok, err)
+	}
+	snap, ok := rec.StationSnapshot(DefaultStationID)
+	if !ok || snap.Zebra.LastEPC != "AAAA0001" {
+		t.Fatalf("AsOf epc mismatch: ok=%v %q", ok, snap.Zebra.LastEPC)
+	}
+
+	if _, ok, _ := s.Journal().AsOf(mid.Add(-time.Hour)); ok {
+		t.Fatal("AsOf before first record should not match")
+	}
+}
+
+func TestJournalRotatesAndPrunesSegments(t *testing.T) {
+	d := t.TempDir()
+	j := OpenJournal(d, 64, 2)
+	for i := 0; i < 6; i++ {
+		if _, err := j.append(Document{UpdatedAt: "x"}, "", time.Now()); err != nil {
+			t.Fatalf("append error: %v", err)
+		}
+	}
+
+	segs, err := j.segments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 2 {
+		t.Fatalf("segment count mismatch: got=%d", len(segs))
+	}
+	last, err := j.LastRev()
+	if err != nil || last != 6 {
+		t.Fatalf("last rev mismatch: got=%d err=%v", last, err)
+	}
+}
+
+func TestJournalSkipsTornTail(t *testing.T) {
+	d := t.TempDir()
+	j := OpenJournal(d, 0, 0)
+	if _, err := j.append(Document{}, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.OpenFile(j.segmentPath(1), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"v":1,"rev":2,"docu`)
+	_ = f.Close()
+
+	rev, err := j.append(Document{}, "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev != 2 {
+		t.Fatalf("rev after torn tail mismatch: got=%d want=2", rev)
+	}
+
+	count := 0
+	if err := j.Replay(func(JournalRecord) error { count++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("replay count mismatch: got=%d want=2", count)
+	}
+}
+
+func TestJournalFailureLeavesStateUntouched(t *testing.T) {
+	d := t.TempDir()
+	journalDir := filepath.Join(d, "journal")
+	s := NewWithOptions(filepath.Join(d, "bridge_state.json"), Options{JournalDir: journalDir})
+
+	w := 1.0
+	if err := s.Update(func(snap *Snapshot) { snap.Scale.Weight = &w }); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+
+	// Journal papkasi o'rnida fayl: append xato beradi.
+	if err := os.RemoveAll(journalDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(journalDir, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w2 := 2.0
+	if err := s.Update(func(snap *Snapshot) { snap.Scale.Weight = &w2 }); err == nil {
+		t.Fatal("journal failure should fail Update")
+	}
+
+	snap, err := s.Read()
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if snap.Revision != 1 || snap.Scale.Weight == nil || *snap.Scale.Weight != 1 {
+		t.Fatalf("state changed despite journal failure: rev=%d weight=%v", snap.Revision, snap.Scale.Weight)
+	}
+}
+
+func TestJournalKeepsEveryStation(t *testing.T) {
+	d := t.TempDir()
+	path := filepath.Join(d, "bridge_state.json")
+	a := NewWithOptions(path, Options{JournalDir: filepath.Join(d, "journal")})
+	b := a.Station("line-2")
+
+	if err := a.Update(func(snap *Snapshot) { snap.Batch.Active = true; snap.Batch.ItemCode = "A" }); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "BB01" }); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := a.Journal().At(2)
+	if err != nil {
+		t.Fatalf("At error: %v", err)
+	}
+	if len(doc.Stations) != 2 || !doc.Stations[DefaultStationID].Batch.Active || doc.Stations["line-2"].Zebra.LastEPC != "BB01" {
+		t.Fatalf("rev 2 should hold both stations: %+v", doc.Stations)
+	}
+
+	hist, err := b.History(time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hist) != 1 || hist[0].Zebra.LastEPC != "BB01" {
+		t.Fatalf("line-2 history should only hold its own writes: %+v", hist)
+	}
+}
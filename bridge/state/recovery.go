@@ -0,0 +1,292 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBackups saqlanadigan backup avlodlari soni (bak.1 - eng yangisi).
+	DefaultBackups = 3
+	// DefaultBackupInterval backup'lar shu oraliqdan tez aylantirilmaydi:
+	// scale sekundiga bir necha marta yozadi, avlodlar bir-biridan farq qilishi kerak.
+	DefaultBackupInterval = 30 * time.Second
+
+	corruptTimeLayout = "20060102T150405.000000000Z"
+)
+
+// Recovery oxirgi karantin/tiklash hodisasi. State yonidagi
+// `.recovery.json` faylida saqlanadi, shunda restartdan keyin ham ko'rinadi.
+type Recovery struct {
+	At           string `json:"at"`
+	Reason       string `json:"reason"`
+	Quarantined  string `json:"quarantined,omitempty"`
+	RestoredFrom string `json:"restored_from,omitempty"`
+}
+
+// Health bridge state faylining holati (TUI va bot ko'rsatishi uchun).
+type Health struct {
+	Path          string    `json:"path"`
+	Backend       string    `json:"backend,omitempty"`
+	OK            bool      `json:"ok"`
+	Exists        bool      `json:"exists"`
+	SchemaVersion int       `json:"schema_version,omitempty"`
+	UpdatedAt     string    `json:"updated_at,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Backups       []string  `json:"backups,omitempty"`
+	Quarantined   []string  `json:"quarantined,omitempty"`
+	LastRecovery  *Recovery `json:"last_recovery,omitempty"`
+}
+
+// Summary bir qatorli qisqa holat matni.
+func (h Health) Summary() string {
+	if !h.OK {
+		return "xato: " + safeHealthText(h.Error)
+	}
+	parts := []string{"ok"}
+	if !h.Exists {
+		parts = []string{"ok (hali yozilmagan)"}
+	}
+	parts = append(parts, fmt.Sprintf("backup=%d", len(h.Backups)))
+	if h.LastRecovery != nil {
+		from := "bo'sh holat"
+		if h.LastRecovery.RestoredFrom != "" {
+			from = filepath.Base(h.LastRecovery.RestoredFrom)
+		}
+		parts = append(parts, fmt.Sprintf("tiklangan %s (%s)", shortTime(h.LastRecovery.At), from))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Health diskdagi holatni tekshiradi; hech narsani o'zgartirmaydi.
+func (s *Store) Health() Health {
+	if s == nil || s.backend == nil || s.Path() == "" {
+		return Health{Error: "bridge state path bo'sh"}
+	}
+	return s.backend.Health()
+}
+
+func (s *fileBackend) Health() Health {
+	h := Health{Path: s.path, Backend: BackendFile}
+
+	b, err := os.ReadFile(s.path)
+	switch {
+	case err == nil:
+		h.Exists = true
+		doc, derr := decodeDocument(b)
+		if derr != nil {
+			h.Error = derr.Error()
+		} else {
+			h.OK = true
+			h.SchemaVersion = doc.SchemaVersion
+			h.UpdatedAt = doc.UpdatedAt
+		}
+	case os.IsNotExist(err):
+		h.OK = true
+	default:
+		h.Error = err.Error()
+	}
+
+	for n := 1; n <= s.backups; n++ {
+		p := s.backupPath(n)
+		if bb, err := os.ReadFile(p); err == nil {
+			if _, err := decodeDocument(bb); err == nil {
+				h.Backups = append(h.Backups, p)
+			}
+		}
+	}
+	if matches, err := filepath.Glob(s.path + ".corrupt-*"); err == nil {
+		sort.Strings(matches)
+		h.Quarantined = matches
+	}
+	if rb, err := os.ReadFile(s.recoveryPath()); err == nil {
+		var rec Recovery
+		if json.Unmarshal(rb, &rec) == nil {
+			h.LastRecovery = &rec
+		}
+	}
+	return h
+}
+
+// loadLocked joriy snapshot'ni o'qiydi (lock ushlangan holda chaqiriladi).
+// Fayl buzilgan bo'lsa karantinga olinadi va backup'dan tiklanadi.
+// good=true bo'lsa diskdagi fayl sog'lom va backup sifatida ishlatilishi mumkin.
+func (s *fileBackend) loadLocked() (doc Document, good bool, err error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newDocument(), false, nil
+		}
+		return Document{}, false, fmt.Errorf("read bridge state: %w", err)
+	}
+	doc, err = decodeDocument(b)
+	if err == nil {
+		return doc, true, nil
+	}
+	if !errors.Is(err, ErrCorrupt) {
+		return Document{}, false, err
+	}
+	doc, err = s.recoverLocked(b, err)
+	return doc, err == nil, err
+}
+
+// recoverLocked buzilgan faylni `.corrupt-<vaqt>` ga ko'chiradi, eng yangi
+// sog'lom backup'ni tiklaydi (bo'lmasa bo'sh holat) va hodisani qayd qiladi.
+func (s *fileBackend) recoverLocked(corrupt []byte, cause error) (Document, error) {
+	at := time.Now().UTC()
+	rec := Recovery{
+		At:          at.Format(time.RFC3339Nano),
+		Reason:      cause.Error(),
+		Quarantined: fmt.Sprintf("%s.corrupt-%s", s.path, at.Format(corruptTimeLayout)),
+	}
+	if err := os.Rename(s.path, rec.Quarantined); err != nil {
+		return Document{}, fmt.Errorf("quarantine bridge state: %w", err)
+	}
+
+	doc := newDocument()
+	for n := 1; n <= s.backups; n++ {
+		p := s.backupPath(n)
+		b, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		decoded, err := decodeDocument(b)
+		if err != nil {
+			continue
+		}
+		doc = decoded
+		rec.RestoredFrom = p
+		break
+	}
+
+	// Revision orqaga ketmasligi kerak: aks holda eski revision'ni ushlab turgan
+	// CompareAndUpdate tiklangan holat ustidan yozib yuborishi mumkin.
+	rev := max(doc.Revision, salvageRevision(corrupt))
+	if s.journal != nil {
+		if last, err := s.journal.LastRev(); err == nil {
+			rev = max(rev, last)
+		}
+	}
+	doc.Revision = rev + 1
+	doc.SchemaVersion = CurrentSchemaVersion
+	doc.UpdatedAt = at.Format(time.RFC3339Nano)
+	for id, st := range doc.Stations {
+		// Watch'lar tiklangan holatni sezishi uchun stansiya vaqtlari ham yangilanadi.
+		st.UpdatedAt = doc.UpdatedAt
+		st.Revision = doc.Revision
+		doc.Stations[id] = st
+	}
+	if err := s.writeLocked(doc); err != nil {
+		return Document{}, err
+	}
+	if b, err := json.Marshal(rec); err == nil {
+		_ = os.WriteFile(s.recoveryPath(), append(b, '\n'), 0o644)
+	}
+	return doc, nil
+}
+
+// rotateBackupsLocked joriy sog'lom faylni bak.1 ga oladi, eskilarini bittaga suradi.
+// bak.1 BackupInterval dan yangi bo'lsa hech narsa qilinmaydi.
+func (s *fileBackend) rotateBackupsLocked(now time.Time) error {
+	if s.backups <= 0 {
+		return nil
+	}
+	if st, err := os.Stat(s.backupPath(1)); err == nil && now.Sub(st.ModTime()) < s.backupInterval {
+		return nil
+	}
+
+	_ = os.Remove(s.backupPath(s.backups))
+	for n := s.backups - 1; n >= 1; n-- {
+		if err := os.Rename(s.backupPath(n), s.backupPath(n+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("rotate bridge backup: %w", err)
+		}
+	}
+	// State fayl rename bilan almashtiriladi, shuning uchun hard link eski
+	// avlodni nusxalashsiz saqlab qoladi.
+	if err := os.Link(s.path, s.backupPath(1)); err != nil {
+		if err := copyFile(s.path, s.backupPath(1)); err != nil {
+			return fmt.Errorf("bridge backup: %w", err)
+		}
+	}
+	return os.Chtimes(s.backupPath(1), now, now)
+}
+
+func (s *fileBackend) writeLocked(doc Document) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("marshal bridge state: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write temp bridge state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("rename bridge state: %w", err)
+	}
+	return nil
+}
+
+func (s *fileBackend) backupPath(n int) string {
+	return fmt.Sprintf("%s.bak.%d", s.path, n)
+}
+
+func (s *fileBackend) recoveryPath() string {
+	return s.path + ".recovery.json"
+}
+
+var revisionPattern = regexp.MustCompile(`"revision"\s*:\s*(\d+)`)
+
+// salvageRevision buzilgan fayldan revision'ni topishga urinadi
+// (maydon boshida yoziladi, kesilgan faylda ham odatda saqlanib qoladi).
+func salvageRevision(b []byte) uint64 {
+	m := revisionPattern.FindSubmatch(b)
+	if m == nil {
+		return 0
+	}
+	v, err := strconv.ParseUint(string(m[1]), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func shortTime(v string) string {
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return v
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func safeHealthText(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "noma'lum"
+	}
+	return v
+}
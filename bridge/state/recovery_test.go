@@ -0,0 +1,89 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateQuarantinesCorruptFileAndRestoresBackup(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	s := NewWithOptions(p, Options{Backups: 2, BackupInterval: time.Nanosecond})
+
+	if err := s.Update(func(snap *Snapshot) { snap.Batch.Active = true; snap.Batch.ChatID = 9 }); err != nil {
+		t.Fatal(err)
+	}
+	// Ikkinchi yozuv birinchisini bak.1 ga oladi.
+	if err := s.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "AA01" }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(p + ".bak.1"); err != nil {
+		t.Fatalf("backup missing: %v", err)
+	}
+
+	// Unclean shutdown imitatsiyasi: fayl kesilgan.
+	if err := os.WriteFile(p, []byte(`{"batch":{"act`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Read()
+	if err != nil {
+		t.Fatalf("Read after corruption error: %v", err)
+	}
+	if !got.Batch.Active || got.Batch.ChatID != 9 {
+		t.Fatalf("batch should be restored from backup: %+v", got.Batch)
+	}
+
+	h := s.Health()
+	if !h.OK || len(h.Quarantined) != 1 || h.LastRecovery == nil {
+		t.Fatalf("health mismatch: %+v", h)
+	}
+	if !strings.Contains(h.Quarantined[0], ".corrupt-") || h.LastRecovery.RestoredFrom != p+".bak.1" {
+		t.Fatalf("recovery mismatch: %+v", h.LastRecovery)
+	}
+	b, err := os.ReadFile(h.Quarantined[0])
+	if err != nil || string(b) != `{"batch":{"act` {
+		t.Fatalf("quarantined copy mismatch: %q %v", b, err)
+	}
+	if !strings.Contains(h.Summary(), "tiklangan") {
+		t.Fatalf("summary should mention recovery: %q", h.Summary())
+	}
+}
+
+func TestUpdateRecoversToEmptyWithoutBackups(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	if err := os.WriteFile(p, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewWithOptions(p, Options{Backups: -1})
+	if err := s.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "BB01" }); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	got, err := s.Read()
+	if err != nil || got.Zebra.LastEPC != "BB01" {
+		t.Fatalf("read mismatch: %+v %v", got, err)
+	}
+	h := s.Health()
+	if h.LastRecovery == nil || h.LastRecovery.RestoredFrom != "" {
+		t.Fatalf("expected recovery without backup: %+v", h.LastRecovery)
+	}
+}
+
+func TestBackupsRotateOnInterval(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	s := NewWithOptions(p, Options{Backups: 2, BackupInterval: time.Hour})
+	for i := 0; i < 5; i++ {
+		if err := s.Update(func(snap *Snapshot) { snap.Batch.ChatID++ }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Interval o'tmagan: faqat bitta avlod olingan.
+	if _, err := os.Stat(p + ".bak.2"); !os.IsNotExist(err) {
+		t.Fatalf("bak.2 should not exist yet: %v", err)
+	}
+	if got := len(s.Health().Backups); got != 1 {
+		t.Fatalf("expected 1 backup, got %d", got)
+	}
+}
@@ -0,0 +1,154 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// CurrentSchemaVersion shu binary yozadigan va tushunadigan snapshot sxemasi.
+// schema_version maydoni yo'q eski fayllar 0-versiya hisoblanadi.
+const CurrentSchemaVersion = 3
+
+// ErrSchemaTooNew faylni yangiroq release yozgan bo'lsa qaytadi.
+// Bunday faylni eski binary o'zgartirmasligi kerak, aks holda yangi maydonlar yo'qoladi.
+var ErrSchemaTooNew = errors.New("bridge state sxemasi bu binary'dan yangiroq")
+
+// ErrCorrupt fayl JSON sifatida o'qilmasa (kesilgan, bo'sh, buzilgan) qaytadi.
+// Store bunday faylni karantinga olib, backup'dan tiklaydi.
+var ErrCorrupt = errors.New("bridge state buzilgan")
+
+// ErrConflict CompareAndUpdate kutilgan revision eskirgan bo'lsa qaytadi.
+var ErrConflict = errors.New("bridge state revision conflict")
+
+// ConflictError kutilgan va diskdagi revision'ni ko'rsatadi.
+type ConflictError struct {
+	Expected uint64
+	Actual   uint64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("bridge state boshqa process tomonidan o'zgartirilgan: kutilgan revision=%d, hozirgi=%d", e.Expected, e.Actual)
+}
+
+func (e *ConflictError) Unwrap() error { return ErrConflict }
+
+// SchemaError qaysi versiya topilgani va qaysi biri qo'llab-quvvatlanishini aytadi.
+type SchemaError struct {
+	Found     int
+	Supported int
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("bridge state schema_version=%d, bu binary faqat <=%d ni tushunadi: scale va bot'ni bir xil release'ga yangilang", e.Found, e.Supported)
+}
+
+func (e *SchemaError) Unwrap() error { return ErrSchemaTooNew }
+
+// Migration JSON hujjatni bitta versiyaga ko'taradi (from -> from+1).
+// Hujjat xom ko'rinishda beriladi, shunda Document struct'ida yo'q maydonlar ham ko'chiriladi.
+type Migration func(doc map[string]json.RawMessage) error
+
+// migrations: kalit - qaysi versiyadan ko'tarilishi.
+// Yangi sxema qo'shilganda CurrentSchemaVersion oshiriladi va shu yerga qadam qo'shiladi.
+var migrations = map[int]Migration{
+	0: migrateV0ToV1,
+	1: migrateV1ToV2,
+	2: migrateV2ToV3,
+}
+
+// migrateV0ToV1: v1 faqat schema_version maydonini qo'shdi, tuzilma o'zgarmagan.
+func migrateV0ToV1(doc map[string]json.RawMessage) error {
+	return nil
+}
+
+// migrateV1ToV2: v2 revision maydonini qo'shdi. v1 fayllar 0-revision'dan boshlaydi;
+// versiya oshirilgani sababli eski binary revision'ni tashlab yubora olmaydi.
+func migrateV1ToV2(doc map[string]json.RawMessage) error {
+	return nil
+}
+
+// migrateV2ToV3: v3 da stansiyalar paydo bo'ldi. Yuqori darajadagi
+// scale/zebra/batch bo'limlari stations.default ichiga ko'chiriladi.
+func migrateV2ToV3(doc map[string]json.RawMessage) error {
+	station := map[string]json.RawMessage{}
+	for _, key := range []string{"scale", "zebra", "batch"} {
+		if v, ok := doc[key]; ok {
+			station[key] = v
+			delete(doc, key)
+		}
+	}
+	if v, ok := doc["updated_at"]; ok {
+		station["updated_at"] = v
+	}
+	stationRaw, err := json.Marshal(station)
+	if err != nil {
+		return err
+	}
+	stations, err := json.Marshal(map[string]json.RawMessage{DefaultStationID: stationRaw})
+	if err != nil {
+		return err
+	}
+	doc["stations"] = stations
+	return nil
+}
+
+// decodeDocument faylni o'qiydi, kerak bo'lsa migratsiya qiladi va
+// natijani CurrentSchemaVersion ko'rinishida qaytaradi.
+func decodeDocument(b []byte) (Document, error) {
+	doc := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return Document{}, fmt.Errorf("%w: json noto'g'ri: %v", ErrCorrupt, err)
+	}
+
+	version, err := schemaVersionOf(doc)
+	if err != nil {
+		return Document{}, err
+	}
+	if version > CurrentSchemaVersion {
+		return Document{}, &SchemaError{Found: version, Supported: CurrentSchemaVersion}
+	}
+	for v := version; v < CurrentSchemaVersion; v++ {
+		m, ok := migrations[v]
+		if !ok {
+			return Document{}, fmt.Errorf("bridge state migratsiyasi topilmadi: v%d -> v%d", v, v+1)
+		}
+		if err := m(doc); err != nil {
+			return Document{}, fmt.Errorf("bridge state migratsiyasi v%d -> v%d: %w", v, v+1, err)
+		}
+	}
+
+	delete(doc, "schema_version")
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return Document{}, fmt.Errorf("bridge state migratsiyasi: %w", err)
+	}
+	var out Document
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return Document{}, fmt.Errorf("%w: json noto'g'ri: %v", ErrCorrupt, err)
+	}
+	out.SchemaVersion = CurrentSchemaVersion
+	if out.Stations == nil {
+		out.Stations = map[string]StationState{}
+	}
+	// Stansiya revision'idan oldingi fayllar: stansiya hujjat revision'ida yozilgan deb olinadi.
+	for id, st := range out.Stations {
+		if st.Revision == 0 {
+			st.Revision = out.Revision
+			out.Stations[id] = st
+		}
+	}
+	return out, nil
+}
+
+func schemaVersionOf(doc map[string]json.RawMessage) (int, error) {
+	raw, ok := doc["schema_version"]
+	if !ok || string(raw) == "null" {
+		return 0, nil
+	}
+	var v int
+	if err := json.Unmarshal(raw, &v); err != nil || v < 0 {
+		return 0, fmt.Errorf("%w: schema_version noto'g'ri: %s", ErrCorrupt, string(raw))
+	}
+	return v, nil
+}
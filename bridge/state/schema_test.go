@@ -0,0 +1,77 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMigratesUnversionedFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	if err := os.WriteFile(p, []byte(`{"batch":{"active":true,"chat_id":5},"zebra":{"last_epc":"AA01"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(p)
+
+	got, err := s.Read()
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if got.SchemaVersion != CurrentSchemaVersion {
+		t.Fatalf("schema version mismatch: %d", got.SchemaVersion)
+	}
+	if !got.Batch.Active || got.Batch.ChatID != 5 || got.Zebra.LastEPC != "AA01" {
+		t.Fatalf("migrated snapshot mismatch: %+v", got)
+	}
+
+	if err := s.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "AA02" }); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	got, _ = s.Read()
+	if !got.Batch.Active || got.Zebra.LastEPC != "AA02" {
+		t.Fatalf("update after migration lost data: %+v", got)
+	}
+}
+
+func TestUpdateRefusesNewerSchema(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	raw := []byte(`{"schema_version":99,"batch":{"active":true},"future":{"x":1}}`)
+	if err := os.WriteFile(p, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(p)
+
+	if _, err := s.Read(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("Read: expected ErrSchemaTooNew, got %v", err)
+	}
+	err := s.Update(func(snap *Snapshot) { snap.Batch.Active = false })
+	var se *SchemaError
+	if !errors.As(err, &se) || se.Found != 99 || se.Supported != CurrentSchemaVersion {
+		t.Fatalf("Update: expected SchemaError, got %v", err)
+	}
+
+	b, _ := os.ReadFile(p)
+	if string(b) != string(raw) {
+		t.Fatalf("newer file must stay untouched, got %s", b)
+	}
+}
+
+func TestReadMigratesV2IntoDefaultStation(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	raw := `{"schema_version":2,"revision":7,"batch":{"active":true,"item_code":"X"},"updated_at":"2026-10-17T10:00:00Z"}`
+	if err := os.WriteFile(p, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := New(p).ReadDocument()
+	if err != nil {
+		t.Fatalf("ReadDocument error: %v", err)
+	}
+	st, ok := doc.Stations[DefaultStationID]
+	if !ok || !st.Batch.Active || st.Batch.ItemCode != "X" || st.UpdatedAt != "2026-10-17T10:00:00Z" {
+		t.Fatalf("default station mismatch: %+v", doc.Stations)
+	}
+	if doc.Revision != 7 {
+		t.Fatalf("revision lost in migration: %d", doc.Revision)
+	}
+}
@@ -1,97 +1,218 @@
 package state
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"sort"
 	"strings"
-	"syscall"
 	"time"
 )
 
 type Store struct {
-	path string
+	station string
+	journal *Journal
+	backend Backend
+}
+
+// Options Store uchun ixtiyoriy sozlamalar.
+type Options struct {
+	// JournalDir bo'sh bo'lmasa har Update append-only journal'ga ham yoziladi.
+	JournalDir string
+	// JournalSegmentBytes bitta journal segmentining maksimal hajmi.
+	JournalSegmentBytes int64
+	// JournalMaxSegments saqlanadigan segmentlar soni; eskilari o'chiriladi.
+	JournalMaxSegments int
+	// Backups buzilgan faylni tiklash uchun saqlanadigan avlodlar soni
+	// (0 = DefaultBackups, manfiy = o'chirilgan).
+	Backups int
+	// BackupInterval backup'lar orasidagi minimal vaqt (0 = DefaultBackupInterval).
+	BackupInterval time.Duration
+	// StationID Store qaysi stansiya bo'limini o'qib-yozishi (bo'sh = DefaultStationID).
+	StationID string
+	// Backend saqlash turi: BackendFile (bo'sh) yoki BackendSQLite.
+	// Path SQLite uchun ma'lumotlar bazasi fayli bo'ladi.
+	Backend string
 }
 
 func New(path string) *Store {
-	return &Store{path: strings.TrimSpace(path)}
+	return NewWithOptions(path, Options{})
+}
+
+func NewWithOptions(path string, opts Options) *Store {
+	path = strings.TrimSpace(path)
+	s := NewWithBackend(nil, opts)
+	switch NormalizeBackend(opts.Backend) {
+	case BackendSQLite:
+		s.backend = newSQLiteBackend(path)
+	default:
+		s.backend = newFileBackend(path, s.journal, opts)
+	}
+	return s
+}
+
+// NewWithBackend tayyor Backend ustida Store yaratadi (masalan testlar yoki
+// tashqi saqlash joylari uchun).
+func NewWithBackend(backend Backend, opts Options) *Store {
+	s := &Store{station: NormalizeStationID(opts.StationID), backend: backend}
+	if dir := strings.TrimSpace(opts.JournalDir); dir != "" {
+		s.journal = OpenJournal(dir, opts.JournalSegmentBytes, opts.JournalMaxSegments)
+	}
+	return s
 }
 
 func (s *Store) Path() string {
-	if s == nil {
+	if s == nil || s.backend == nil {
 		return ""
 	}
-	return strings.TrimSpace(s.path)
+	return strings.TrimSpace(s.backend.Path())
 }
 
-func (s *Store) Read() (Snapshot, error) {
-	if s == nil || strings.TrimSpace(s.path) == "" {
-		return Snapshot{}, fmt.Errorf("bridge state path bo'sh")
+// Backend Store ishlatayotgan saqlash joyi.
+func (s *Store) Backend() Backend {
+	if s == nil {
+		return nil
 	}
-	b, err := os.ReadFile(s.path)
-	if err != nil {
-		return Snapshot{}, err
+	return s.backend
+}
+
+// Close backend resurslarini bo'shatadi (SQLite ulanishi). Station() nusxalari
+// bir xil backend'ni bo'lishadi, shuning uchun uni faqat egasi yopadi.
+func (s *Store) Close() error {
+	if s == nil || s.backend == nil {
+		return nil
 	}
-	var out Snapshot
-	if err := json.Unmarshal(b, &out); err != nil {
-		return Snapshot{}, err
+	return s.backend.Close()
+}
+
+// StationID Store bog'langan stansiya.
+func (s *Store) StationID() string {
+	if s == nil {
+		return DefaultStationID
 	}
-	return out, nil
+	return s.station
 }
 
-func (s *Store) Update(mutator func(*Snapshot)) error {
-	if s == nil || strings.TrimSpace(s.path) == "" {
+// Station xuddi shu fayl (journal, backup sozlamalari bilan) ustida
+// boshqa stansiyaga bog'langan Store qaytaradi.
+func (s *Store) Station(id string) *Store {
+	if s == nil {
 		return nil
 	}
+	cp := *s
+	cp.station = NormalizeStationID(id)
+	return &cp
+}
 
-	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
-		return fmt.Errorf("mkdir bridge dir: %w", err)
+// NormalizeStationID bo'sh ID ni DefaultStationID ga aylantiradi.
+func NormalizeStationID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return DefaultStationID
 	}
+	return id
+}
 
-	unlock, err := lockFile(s.path + ".lock")
-	if err != nil {
-		return fmt.Errorf("lock bridge state: %w", err)
+// ValidateStationID ID fayl/socket nomlarida ishlatilishi mumkinligini tekshiradi.
+func ValidateStationID(id string) error {
+	id = NormalizeStationID(id)
+	if len(id) > 64 {
+		return fmt.Errorf("station id juda uzun: %q", id)
 	}
-	defer unlock()
-
-	cur := Snapshot{}
-	if b, err := os.ReadFile(s.path); err == nil {
-		_ = json.Unmarshal(b, &cur)
+	for _, r := range id {
+		ok := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.'
+		if !ok {
+			return fmt.Errorf("station id noto'g'ri: %q (faqat harf, raqam, '-', '_', '.')", id)
+		}
 	}
+	return nil
+}
 
-	if mutator != nil {
-		mutator(&cur)
+// Journal yoqilgan bo'lsa journal'ni, aks holda nil qaytaradi.
+func (s *Store) Journal() *Journal {
+	if s == nil {
+		return nil
 	}
-	cur.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	return s.journal
+}
 
-	b, err := json.Marshal(cur)
+func (s *Store) Read() (Snapshot, error) {
+	doc, err := s.ReadDocument()
 	if err != nil {
-		return fmt.Errorf("marshal bridge state: %w", err)
+		return Snapshot{}, err
 	}
+	return doc.snapshot(s.station), nil
+}
 
-	tmp := s.path + ".tmp"
-	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
-		return fmt.Errorf("write temp bridge state: %w", err)
+// ReadDocument barcha stansiyalar bilan to'liq hujjatni qaytaradi.
+func (s *Store) ReadDocument() (Document, error) {
+	if s == nil || s.Path() == "" {
+		return Document{}, fmt.Errorf("bridge state path bo'sh")
 	}
-	if err := os.Rename(tmp, s.path); err != nil {
-		return fmt.Errorf("rename bridge state: %w", err)
-	}
-	return nil
+	return s.backend.Load()
 }
 
-func lockFile(path string) (func(), error) {
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+// Stations fayldagi barcha stansiyalarni ID bo'yicha tartiblab qaytaradi.
+func (s *Store) Stations() ([]Snapshot, error) {
+	doc, err := s.ReadDocument()
 	if err != nil {
 		return nil, err
 	}
-	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
-		_ = f.Close()
-		return nil, err
+	ids := make([]string, 0, len(doc.Stations))
+	for id := range doc.Stations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := make([]Snapshot, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, doc.snapshot(id))
+	}
+	return out, nil
+}
+
+func (s *Store) Update(mutator func(*Snapshot)) error {
+	return s.update(nil, mutator)
+}
+
+// CompareAndUpdate faqat Store stansiyasining diskdagi Revision'i expectedRev ga teng bo'lsa
+// yozadi: boshqa stansiyalarning yozuvlari konflikt emas. Aks holda *ConflictError
+// (errors.Is(err, ErrConflict)) qaytadi va fayl o'zgarmaydi. Stansiya hali yo'q bo'lsa revision 0.
+func (s *Store) CompareAndUpdate(expectedRev uint64, mutator func(*Snapshot)) error {
+	if s == nil || s.Path() == "" {
+		return fmt.Errorf("bridge state path bo'sh")
+	}
+	return s.update(&expectedRev, mutator)
+}
+
+func (s *Store) update(expectedRev *uint64, mutator func(*Snapshot)) error {
+	if s == nil || s.Path() == "" {
+		return nil
+	}
+
+	var now time.Time
+	mutate := func(doc *Document) error {
+		if rev := doc.Stations[s.station].Revision; expectedRev != nil && rev != *expectedRev {
+			return &ConflictError{Expected: *expectedRev, Actual: rev}
+		}
+		cur := doc.snapshot(s.station)
+		if mutator != nil {
+			mutator(&cur)
+		}
+		now = time.Now().UTC()
+		cur.UpdatedAt = now.Format(time.RFC3339Nano)
+		doc.Revision++
+		cur.Revision = doc.Revision
+		doc.Stations[s.station] = cur.stationState()
+		doc.SchemaVersion = CurrentSchemaVersion
+		doc.UpdatedAt = cur.UpdatedAt
+		return nil
 	}
-	unlock := func() {
-		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
-		_ = f.Close()
+	var commit func(Document) error
+	if s.journal != nil {
+		commit = func(doc Document) error {
+			if _, err := s.journal.append(doc, s.station, now); err != nil {
+				return fmt.Errorf("append bridge journal: %w", err)
+			}
+			return nil
+		}
 	}
-	return unlock, nil
+	return s.backend.Update(mutate, commit)
 }
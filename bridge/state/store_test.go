@@ -1,9 +1,11 @@
 package state
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestStoreUpdateAndRead(t *testing.T) {
@@ -44,3 +46,141 @@ func TestStoreUpdateAndRead(t *testing.T) {
 		t.Fatalf("state file missing: %v", err)
 	}
 }
+
+func TestCompareAndUpdate(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	s := New(p)
+
+	if err := s.CompareAndUpdate(0, func(snap *Snapshot) { snap.Batch.Active = true }); err != nil {
+		t.Fatalf("CAS on missing file error: %v", err)
+	}
+	got, err := s.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Revision != 1 {
+		t.Fatalf("revision mismatch: %d", got.Revision)
+	}
+
+	// Boshqa process yozdi: revision 2 ga oshdi.
+	if err := s.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "AA01" }); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.CompareAndUpdate(got.Revision, func(snap *Snapshot) { snap.Batch.Active = false })
+	var ce *ConflictError
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &ce) || ce.Expected != 1 || ce.Actual != 2 {
+		t.Fatalf("expected conflict 1/2, got %v", err)
+	}
+	after, _ := s.Read()
+	if !after.Batch.Active || after.Revision != 2 {
+		t.Fatalf("conflicting write must not apply: %+v", after)
+	}
+
+	if err := s.CompareAndUpdate(after.Revision, func(snap *Snapshot) { snap.Batch.Active = false }); err != nil {
+		t.Fatalf("CAS with fresh revision error: %v", err)
+	}
+}
+
+func TestRecoveryKeepsRevisionMonotonic(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	s := NewWithOptions(p, Options{Backups: 1, BackupInterval: time.Hour})
+	for i := 0; i < 5; i++ {
+		if err := s.Update(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// bak.1 revision=1 da qolgan; kesilgan faylda revision=5 saqlangan.
+	if err := os.WriteFile(p, []byte(`{"schema_version":2,"revision":5,"scale":{`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Revision <= 5 {
+		t.Fatalf("revision went backwards after recovery: %d", got.Revision)
+	}
+}
+
+func TestStationsAreIsolated(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	a := New(p)
+	b := NewWithOptions(p, Options{StationID: "line-2"})
+
+	if err := a.Update(func(snap *Snapshot) { snap.Batch.Active = true; snap.Batch.ItemCode = "A" }); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "BB01" }); err != nil {
+		t.Fatal(err)
+	}
+
+	gotA, _ := a.Read()
+	gotB, _ := b.Read()
+	if gotA.StationID != DefaultStationID || !gotA.Batch.Active || gotA.Zebra.LastEPC != "" {
+		t.Fatalf("default station mismatch: %+v", gotA)
+	}
+	if gotB.StationID != "line-2" || gotB.Batch.Active || gotB.Zebra.LastEPC != "BB01" {
+		t.Fatalf("line-2 station mismatch: %+v", gotB)
+	}
+	if gotA.Revision != 1 || gotB.Revision != 2 || gotA.DocumentRevision != 2 {
+		t.Fatalf("revision should be per station: a=%d b=%d doc=%d", gotA.Revision, gotB.Revision, gotA.DocumentRevision)
+	}
+	// line-2 yozuvi default stansiyaning CompareAndUpdate'iga konflikt emas.
+	if err := a.CompareAndUpdate(gotA.Revision, func(snap *Snapshot) { snap.Batch.ItemCode = "A2" }); err != nil {
+		t.Fatalf("other station write should not conflict: %v", err)
+	}
+
+	stations, err := a.Stations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stations) != 2 || stations[0].StationID != DefaultStationID || stations[1].StationID != "line-2" {
+		t.Fatalf("stations mismatch: %+v", stations)
+	}
+	if got, _ := a.Station("line-2").Read(); got.Zebra.LastEPC != "BB01" {
+		t.Fatalf("Station(id) view mismatch: %+v", got)
+	}
+}
+
+func TestValidateStationID(t *testing.T) {
+	for _, id := range []string{"", "default", "line-2", "Ombor_1.a"} {
+		if err := ValidateStationID(id); err != nil {
+			t.Fatalf("%q should be valid: %v", id, err)
+		}
+	}
+	for _, id := range []string{"a/b", "line 2", "../x"} {
+		if err := ValidateStationID(id); err == nil {
+			t.Fatalf("%q should be invalid", id)
+		}
+	}
+}
+
+func TestScaleSnapshotQtyPrefersNet(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "bridge_state.json"))
+	gross, tare, net := 12.5, 2.5, 10.0
+	if err := s.Update(func(snap *Snapshot) {
+		snap.Scale.Weight = &gross
+		snap.Scale.Gross = &gross
+		snap.Scale.Tare = &tare
+		snap.Scale.Net = &net
+		snap.Scale.TareSource = "preset"
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := got.Scale.Qty(); q == nil || *q != 10 {
+		t.Fatalf("qty=%v want net 10", q)
+	}
+	if got.Scale.TareSource != "preset" || *got.Scale.Tare != 2.5 {
+		t.Fatalf("tare mismatch: %+v", got.Scale)
+	}
+
+	old := ScaleSnapshot{Weight: &gross}
+	if q := old.Qty(); q == nil || *q != 12.5 {
+		t.Fatalf("legacy qty=%v want weight", q)
+	}
+}
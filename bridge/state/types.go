@@ -1,20 +1,119 @@
 package state
 
+import "time"
+
+// DefaultStationID bitta stansiyali o'rnatishlar va stansiyasiz eski fayllar uchun.
+const DefaultStationID = "default"
+
+// Document diskdagi to'liq bridge state: har scale+printer stansiyasi o'z bo'limida.
+// Revision butun hujjat bo'yicha har yozuvda oshadi (journal va tarix tartibi uchun).
+type Document struct {
+	SchemaVersion int                     `json:"schema_version"`
+	Revision      uint64                  `json:"revision"`
+	Stations      map[string]StationState `json:"stations"`
+	UpdatedAt     string                  `json:"updated_at,omitempty"`
+}
+
+// StationState bitta stansiyaning diskdagi bo'limi. Revision - shu stansiya oxirgi marta
+// yozilgandagi Document.Revision: boshqa stansiyalarning yozuvlari uni o'zgartirmaydi.
+type StationState struct {
+	Revision uint64        `json:"revision,omitempty"`
+	Scale    ScaleSnapshot `json:"scale"`
+	Zebra    ZebraSnapshot `json:"zebra"`
+	Batch    BatchSnapshot `json:"batch"`
+	// Command oxirgi indikator buyrug'i (tare/zero) va uning natijasi.
+	Command   *ScaleCommandSnapshot `json:"command,omitempty"`
+	UpdatedAt string                `json:"updated_at,omitempty"`
+}
+
+// Snapshot Store bog'langan stansiyaning ko'rinishi.
+// SchemaVersion va DocumentRevision butun hujjatga tegishli.
 type Snapshot struct {
-	Scale     ScaleSnapshot `json:"scale"`
-	Zebra     ZebraSnapshot `json:"zebra"`
-	Batch     BatchSnapshot `json:"batch"`
-	UpdatedAt string        `json:"updated_at,omitempty"`
+	SchemaVersion int `json:"schema_version"`
+	// Revision shu stansiya har yozilganda oshadi (CompareAndUpdate uchun); boshqa
+	// stansiyalarning yozuvlari uni o'zgartirmaydi.
+	Revision uint64 `json:"revision"`
+	// DocumentRevision butun hujjatning revision'i (journal/tarix tartibi).
+	DocumentRevision uint64                `json:"document_revision,omitempty"`
+	StationID        string                `json:"station_id,omitempty"`
+	Scale            ScaleSnapshot         `json:"scale"`
+	Zebra            ZebraSnapshot         `json:"zebra"`
+	Batch            BatchSnapshot         `json:"batch"`
+	Command          *ScaleCommandSnapshot `json:"command,omitempty"`
+	UpdatedAt        string                `json:"updated_at,omitempty"`
 }
 
+func (d Document) snapshot(stationID string) Snapshot {
+	st := d.Stations[stationID]
+	return Snapshot{
+		SchemaVersion:    d.SchemaVersion,
+		Revision:         st.Revision,
+		DocumentRevision: d.Revision,
+		StationID:        stationID,
+		Scale:            st.Scale,
+		Zebra:            st.Zebra,
+		Batch:            st.Batch,
+		Command:          st.Command,
+		UpdatedAt:        st.UpdatedAt,
+	}
+}
+
+func (s Snapshot) stationState() StationState {
+	return StationState{Revision: s.Revision, Scale: s.Scale, Zebra: s.Zebra, Batch: s.Batch, Command: s.Command, UpdatedAt: s.UpdatedAt}
+}
+
+// ScaleSnapshot Weight - brutto (eski o'quvchilar uchun). Tara bo'lsa Gross/Tare/Net
+// alohida yoziladi; ERP qty uchun Qty() ishlatilsin.
 type ScaleSnapshot struct {
-	Source    string   `json:"source,omitempty"`
-	Port      string   `json:"port,omitempty"`
-	Weight    *float64 `json:"weight"`
-	Unit      string   `json:"unit,omitempty"`
-	Stable    *bool    `json:"stable"`
-	Error     string   `json:"error,omitempty"`
-	UpdatedAt string   `json:"updated_at,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	Port       string   `json:"port,omitempty"`
+	Weight     *float64 `json:"weight"`
+	Unit       string   `json:"unit,omitempty"`
+	Stable     *bool    `json:"stable"`
+	Gross      *float64 `json:"gross,omitempty"`
+	Tare       *float64 `json:"tare,omitempty"`
+	Net        *float64 `json:"net,omitempty"`
+	TareSource string   `json:"tare_source,omitempty"`
+	Container  string   `json:"container,omitempty"`
+	// ZeroOffset bo'sh platformaning kuzatilgan o'qishi; ZeroAlarm bo'sh bo'lmasa drift
+	// yoki nolga qaytmaslik, ZeroBlock bo'lsa yangi siklga draft yaratilmasin.
+	ZeroOffset *float64 `json:"zero_offset,omitempty"`
+	ZeroAlarm  string   `json:"zero_alarm,omitempty"`
+	ZeroBlock  bool     `json:"zero_block,omitempty"`
+	// Check faol batch item'i uchun scale check-weigh natijasi (--checkweigh-rules, qoida bo'lmasa nil).
+	Check *ScaleCheck `json:"check,omitempty"`
+	// Outage oxirgi serial uzilishi (USB adapter chiqarilgan/qayta ulangan); Active bo'lsa hali tiklanmagan.
+	Outage    *ScaleOutage `json:"outage,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	UpdatedAt string       `json:"updated_at,omitempty"`
+}
+
+// ScaleCheck netto vaznning nominalga nisbatan sinfi (UNDER/OK/OVER), Deviation = netto - nominal.
+// Block - --checkweigh-policy block va vazn tolerance'dan tashqari: scale EPC bermaydi, bot draft yaratmaydi.
+type ScaleCheck struct {
+	Class     string  `json:"class"`
+	Nominal   float64 `json:"nominal"`
+	Deviation float64 `json:"deviation"`
+	Block     bool    `json:"block,omitempty"`
+}
+
+// ScaleOutage serial uzilish oynasi: Since..Until (RFC3339Nano), Port uzilgan, RecoveredPort
+// tiklangan port (hot-plug'da boshqa `ttyUSBn` bo'lishi mumkin).
+type ScaleOutage struct {
+	Active        bool   `json:"active"`
+	Since         string `json:"since"`
+	Until         string `json:"until,omitempty"`
+	DurationMS    int64  `json:"duration_ms,omitempty"`
+	Port          string `json:"port,omitempty"`
+	RecoveredPort string `json:"recovered_port,omitempty"`
+}
+
+// Qty netto vazn; tara yozilmagan (eski) snapshot'da Weight.
+func (s ScaleSnapshot) Qty() *float64 {
+	if s.Net != nil {
+		return s.Net
+	}
+	return s.Weight
 }
 
 type ZebraSnapshot struct {
@@ -32,11 +131,65 @@ type ZebraSnapshot struct {
 	UpdatedAt   string `json:"updated_at,omitempty"`
 }
 
+// BatchSnapshot CountUOM bo'lsa item dona bilan beriladi (counting rejimi): qty
+// UnitWeight bo'yicha dona soniga aylantiriladi. UnitWeight=0 - hali o'rganilmagan.
+// GTIN item'ning GTIN-14'i (ERP barcode'laridan); scale --epc-scheme sgtin96 EPC'ga yozadi.
 type BatchSnapshot struct {
-	Active    bool   `json:"active"`
-	ChatID    int64  `json:"chat_id,omitempty"`
-	ItemCode  string `json:"item_code,omitempty"`
-	ItemName  string `json:"item_name,omitempty"`
-	Warehouse string `json:"warehouse,omitempty"`
-	UpdatedAt string `json:"updated_at,omitempty"`
+	Active           bool    `json:"active"`
+	ChatID           int64   `json:"chat_id,omitempty"`
+	ItemCode         string  `json:"item_code,omitempty"`
+	ItemName         string  `json:"item_name,omitempty"`
+	Warehouse        string  `json:"warehouse,omitempty"`
+	GTIN             string  `json:"gtin,omitempty"`
+	CountUOM         string  `json:"count_uom,omitempty"`
+	UnitWeight       float64 `json:"unit_weight,omitempty"`
+	UnitWeightSource string  `json:"unit_weight_source,omitempty"`
+	UpdatedAt        string  `json:"updated_at,omitempty"`
+}
+
+// Indikator buyruqlari (ScaleCommandSnapshot.Op).
+const (
+	ScaleOpTare      = "tare"
+	ScaleOpZero      = "zero"
+	ScaleOpClearTare = "clear_tare"
+)
+
+// ScaleCommandSnapshot.Status qiymatlari.
+const (
+	ScaleCommandPending = "pending"
+	ScaleCommandOK      = "ok"
+	ScaleCommandError   = "error"
+	// ScaleCommandExpired bot javob kutishni to'xtatgan (ScaleCommandTimeout) yoki scale
+	// so'rovni kech ko'rgan: bunday buyruq bajarilmaydi.
+	ScaleCommandExpired = "expired"
+)
+
+// ScaleCommandTimeout bot pending buyruq natijasini shuncha kutadi. Undan eski pending
+// buyruq eskirgan: operator javob olmagan, endi tarozini nollash/tara qilish kutilmagan.
+const ScaleCommandTimeout = 6 * time.Second
+
+// ScaleCommandSnapshot bot yoki TUI so'ragan indikator buyrug'i. Bot (bus bo'lmasa) Status=pending
+// yozadi, scale bajarib ok/error va DoneAt bilan tasdiqlaydi.
+type ScaleCommandSnapshot struct {
+	ID          string `json:"id"`
+	Op          string `json:"op"`
+	RequestedBy string `json:"requested_by,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	RequestedAt string `json:"requested_at,omitempty"`
+	DoneAt      string `json:"done_at,omitempty"`
+}
+
+// Pending scale hali bajarmagan so'rov.
+func (c *ScaleCommandSnapshot) Pending() bool {
+	return c != nil && c.Status == ScaleCommandPending
+}
+
+// Stale so'rov ScaleCommandTimeout'dan eski (yoki RequestedAt yo'q/buzuq): bot uni kutmayapti.
+func (c *ScaleCommandSnapshot) Stale(now time.Time) bool {
+	if c == nil {
+		return false
+	}
+	at, err := time.Parse(time.RFC3339Nano, c.RequestedAt)
+	return err != nil || now.Sub(at) > ScaleCommandTimeout
 }
@@ -0,0 +1,112 @@
+package state
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultWatchPollInterval = 200 * time.Millisecond
+	// inotify ishlayotgan bo'lsa ham vaqti-vaqti bilan faylni tekshiramiz:
+	// papka qayta yaratilsa yoki event yo'qolsa watcher "qotib" qolmasin.
+	watchSafetyInterval = 2 * time.Second
+)
+
+// fileNotifier state fayl o'zgargani haqida signal beradi.
+type fileNotifier interface {
+	Events() <-chan struct{}
+	Close() error
+}
+
+// Watch state o'zgarganda yangi snapshot'ni yuboradi.
+// Linux'da inotify ishlatiladi, u ishlamasa polling'ga o'tadi.
+// Kanal "latest wins": sekin o'quvchi oraliq snapshot'larni o'tkazib yuboradi,
+// lekin eng oxirgisini albatta oladi. ctx tugaganda kanal yopiladi.
+func (s *Store) Watch(ctx context.Context) <-chan Snapshot {
+	return s.WatchWithFallback(ctx, defaultWatchPollInterval)
+}
+
+// WatchWithFallback Watch bilan bir xil, faqat polling fallback intervalini beradi.
+func (s *Store) WatchWithFallback(ctx context.Context, pollInterval time.Duration) <-chan Snapshot {
+	out := make(chan Snapshot, 1)
+	if s == nil || s.Path() == "" {
+		close(out)
+		return out
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultWatchPollInterval
+	}
+
+	go func() {
+		defer close(out)
+
+		var notifier fileNotifier
+		defer func() {
+			if notifier != nil {
+				_ = notifier.Close()
+			}
+		}()
+		openNotifier := func() {
+			if notifier != nil {
+				return
+			}
+			if n, err := newFileNotifier(s.Path()); err == nil {
+				notifier = n
+			}
+		}
+		openNotifier()
+
+		lastSeen := ""
+		emit := func() {
+			snap, err := s.Read()
+			if err != nil || snap.UpdatedAt == lastSeen {
+				return
+			}
+			lastSeen = snap.UpdatedAt
+			sendLatest(out, snap)
+		}
+		emit()
+
+		for {
+			interval := pollInterval
+			var events <-chan struct{}
+			if notifier != nil {
+				interval = watchSafetyInterval
+				events = notifier.Events()
+			}
+			t := time.NewTimer(interval)
+
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case _, ok := <-events:
+				t.Stop()
+				if !ok {
+					_ = notifier.Close()
+					notifier = nil
+				}
+			case <-t.C:
+				openNotifier()
+			}
+			emit()
+		}
+	}()
+	return out
+}
+
+func sendLatest(ch chan Snapshot, snap Snapshot) {
+	select {
+	case ch <- snap:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	select {
+	case ch <- snap:
+	default:
+	}
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyNotifier state fayl joylashgan papkani kuzatadi.
+// Fayl rename orqali almashtirilgani uchun faylning o'zini emas,
+// papkadagi shu nomga tegishli eventlarni ushlaymiz.
+type inotifyNotifier struct {
+	f      *os.File
+	name   string
+	events chan struct{}
+}
+
+func newFileNotifier(path string) (fileNotifier, error) {
+	dir := filepath.Dir(path)
+	if st, err := os.Stat(dir); err != nil || !st.IsDir() {
+		return nil, fmt.Errorf("watch dir topilmadi: %s", dir)
+	}
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init: %w", err)
+	}
+	// IN_MODIFY SQLite backend uchun: u faylni joyida yozadi (rename qilmaydi).
+	mask := uint32(syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF)
+	if _, err := syscall.InotifyAddWatch(fd, dir, mask); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("inotify add watch: %w", err)
+	}
+
+	// Non-blocking fd os.File ichida Go poller'ga ulanadi, shu sabab Close
+	// kutib turgan Read'ni ham uyg'otadi.
+	n := &inotifyNotifier{
+		f:      os.NewFile(uintptr(fd), "inotify"),
+		name:   filepath.Base(path),
+		events: make(chan struct{}, 1),
+	}
+	go n.loop()
+	return n, nil
+}
+
+func (n *inotifyNotifier) Events() <-chan struct{} {
+	return n.events
+}
+
+func (n *inotifyNotifier) Close() error {
+	return n.f.Close()
+}
+
+func (n *inotifyNotifier) loop() {
+	defer close(n.events)
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		k, err := n.f.Read(buf)
+		if err != nil {
+			if errors.Is(err, syscall.EINTR) {
+				continue
+			}
+			return
+		}
+
+		hit := false
+		gone := false
+		for off := 0; off+syscall.SizeofInotifyEvent <= k; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameStart := off + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(ev.Len)
+			if nameEnd > k {
+				break
+			}
+			if ev.Mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF|syscall.IN_IGNORED) != 0 {
+				gone = true
+			}
+			if cString(buf[nameStart:nameEnd]) == n.name {
+				hit = true
+			}
+			off = nameEnd
+		}
+		if hit {
+			select {
+			case n.events <- struct{}{}:
+			default:
+			}
+		}
+		if gone {
+			return
+		}
+	}
+}
+
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package state
+
+import "errors"
+
+func newFileNotifier(path string) (fileNotifier, error) {
+	return nil, errors.New("file notifier faqat linux'da mavjud")
+}
@@ -0,0 +1,70 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchDeliversUpdates(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	s := New(p)
+	if err := s.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "AAAA0001" }); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Polling fallback juda sekin: event faqat notifier orqali kelishi kerak.
+	ch := s.WatchWithFallback(ctx, time.Hour)
+
+	first := waitSnapshot(t, ch, time.Second)
+	if first.Zebra.LastEPC != "AAAA0001" {
+		t.Fatalf("initial snapshot mismatch: %q", first.Zebra.LastEPC)
+	}
+
+	if err := s.Update(func(snap *Snapshot) { snap.Zebra.LastEPC = "AAAA0002" }); err != nil {
+		t.Fatal(err)
+	}
+	got := waitSnapshot(t, ch, time.Second)
+	if got.Zebra.LastEPC != "AAAA0002" {
+		t.Fatalf("updated snapshot mismatch: %q", got.Zebra.LastEPC)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchPollsUntilStateAppears(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "later", "bridge_state.json")
+	s := New(p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := s.WatchWithFallback(ctx, 30*time.Millisecond)
+
+	time.Sleep(60 * time.Millisecond)
+	if err := s.Update(func(snap *Snapshot) { snap.Batch.Active = true }); err != nil {
+		t.Fatal(err)
+	}
+	got := waitSnapshot(t, ch, time.Second)
+	if !got.Batch.Active {
+		t.Fatalf("batch should be active: %+v", got.Batch)
+	}
+}
+
+func waitSnapshot(t *testing.T, ch <-chan Snapshot, timeout time.Duration) Snapshot {
+	t.Helper()
+	select {
+	case snap, ok := <-ch:
+		if !ok {
+			t.Fatal("watch channel closed")
+		}
+		return snap
+	case <-time.After(timeout):
+		t.Fatal("watch timeout")
+	}
+	return Snapshot{}
+}
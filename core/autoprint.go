@@ -14,6 +14,15 @@ type StableEPCConfig struct {
 	StableFor time.Duration
 	Epsilon   float64
 	MinWeight float64
+	// Strategy vazn qachon barqaror ekanini hal qiladi; nil bo'lsa StableFor/Epsilon
+	// bilan EpsilonStability. Epsilon har qanday strategiyada rearm chegarasi ham:
+	// chop etilgandan keyin yangi sikl vazn shundan ko'proq o'zgargach boshlanadi.
+	Strategy StabilityStrategy
+	// Scheme EPC qanday yaratilishi; nil bo'lsa vaqtga asoslangan default (TimeEPCScheme).
+	Scheme EPCScheme
+	// OnEvent sikl event'larini oladi (armed, settling, triggered, ...); nil = o'chirilgan.
+	// Observe chaqirgan goroutine'da sinxron chaqiriladi.
+	OnEvent func(DetectorEvent)
 }
 
 func DefaultStableEPCConfig() StableEPCConfig {
@@ -27,15 +36,19 @@ func DefaultStableEPCConfig() StableEPCConfig {
 type StableEPCDetector struct {
 	cfg StableEPCConfig
 
-	active        bool
-	candidate     float64
-	since         time.Time
+	strategy      StabilityStrategy
 	printed       bool
 	printedWeight float64
 
-	lastNS int64
-	seq    uint32
-	salt   uint32
+	// active: vazn kuzatilmoqda (armed); candidate oxirgi e'lon qilingan level;
+	// waiting: printed holatida waiting_removal allaqachon yuborilgan.
+	active    bool
+	candidate float64
+	waiting   bool
+	onEvent   func(DetectorEvent)
+
+	scheme EPCScheme
+	err    error
 }
 
 func NewStableEPCDetector(cfg StableEPCConfig) *StableEPCDetector {
@@ -48,21 +61,52 @@ func NewStableEPCDetector(cfg StableEPCConfig) *StableEPCDetector {
 	if cfg.MinWeight < 0 {
 		cfg.MinWeight = 0
 	}
-	return &StableEPCDetector{cfg: cfg, salt: newEPCSalt()}
+	scheme := cfg.Scheme
+	if scheme == nil {
+		scheme = NewTimeEPCScheme()
+	}
+	strategy := cfg.Strategy
+	if strategy == nil {
+		strategy = NewEpsilonStability(cfg.StableFor, cfg.Epsilon)
+	}
+	return &StableEPCDetector{cfg: cfg, strategy: strategy, scheme: scheme, onEvent: cfg.OnEvent}
 }
 
+// SetEventHandler OnEvent'ni detector yaratilgandan keyin o'rnatadi (nil = o'chirish).
+func (d *StableEPCDetector) SetEventHandler(fn func(DetectorEvent)) { d.onEvent = fn }
+
+// Strategy detector ishlatayotgan barqarorlik strategiyasi.
+func (d *StableEPCDetector) Strategy() StabilityStrategy { return d.strategy }
+
+// Scheme detector ishlatayotgan EPC sxemasi.
+func (d *StableEPCDetector) Scheme() EPCScheme { return d.scheme }
+
+// Err oxirgi stable triggerda sxema qaytargan xato (masalan SGTIN uchun GTIN yo'q).
+// Keyingi muvaffaqiyatli triggerda tozalanadi.
+func (d *StableEPCDetector) Err() error { return d.err }
+
 func (d *StableEPCDetector) Observe(weight *float64, at time.Time) (string, bool) {
+	return d.ObserveReading(weight, nil, at)
+}
+
+// ObserveReading Observe bilan bir xil, lekin tarozi frame'idagi ST/US belgisini ham
+// strategiyaga beradi (StabilityScaleFlag uchun kerak).
+func (d *StableEPCDetector) ObserveReading(weight *float64, stable *bool, at time.Time) (string, bool) {
 	if at.IsZero() {
 		at = time.Now()
 	}
 	if weight == nil {
-		d.reset()
+		d.resetWith(at, "vazn yo'q")
 		return "", false
 	}
 
 	w := *weight
-	if math.IsNaN(w) || math.IsInf(w, 0) || w <= d.cfg.MinWeight {
-		d.reset()
+	if math.IsNaN(w) || math.IsInf(w, 0) {
+		d.resetWith(at, "vazn noto'g'ri")
+		return "", false
+	}
+	if w <= d.cfg.MinWeight {
+		d.resetWith(at, "vazn MinWeight'dan past")
 		return "", false
 	}
 
@@ -70,66 +114,106 @@ func (d *StableEPCDetector) Observe(weight *float64, at time.Time) (string, bool
 	// - O'sha barqaror nuqtada turish qayta trigger bermaydi.
 	// - Yangi sikl uchun vazn printed nuqtadan ma'noli o'zgarishi kerak (epsilon dan katta).
 	//   Keyin yana stable bo'lsa yangi EPC beriladi (hatto oldingi qty ga qaytgan bo'lsa ham).
+	sample := StabilitySample{Weight: w, Stable: stable, At: at}
+	level, ok := d.strategy.Observe(sample)
 	if d.printed {
-		if math.Abs(w-d.printedWeight) <= d.cfg.Epsilon {
+		// Rearm filtrlangan level bo'yicha: filtr ushlagan sakrash yangi sikl emas.
+		if math.Abs(level-d.printedWeight) <= d.cfg.Epsilon {
+			if !d.waiting {
+				d.waiting = true
+				d.emit(DetectorEvent{Kind: DetectorWaitingRemoval, At: at, Weight: d.printedWeight})
+			}
 			return "", false
 		}
 		d.printed = false
-		d.active = true
-		d.candidate = w
-		d.since = at
 		d.printedWeight = 0
+		d.waiting = false
+		d.strategy.Reset()
+		level, _ = d.strategy.Observe(sample)
+		d.candidate = level
+		d.emit(DetectorEvent{Kind: DetectorArmed, At: at, Weight: level})
 		return "", false
 	}
-
-	if !d.active {
+	switch {
+	case !d.active:
 		d.active = true
-		d.candidate = w
-		d.since = at
+		d.candidate = level
+		d.emit(DetectorEvent{Kind: DetectorArmed, At: at, Weight: level})
+	case math.Abs(level-d.candidate) > d.cfg.Epsilon:
+		d.candidate = level
+		d.emit(DetectorEvent{Kind: DetectorCandidate, At: at, Weight: level})
+	}
+	if !ok {
+		d.emit(DetectorEvent{Kind: DetectorSettling, At: at, Weight: level, Progress: d.progress(at)})
 		return "", false
 	}
+	w = level
 
-	if math.Abs(w-d.candidate) > d.cfg.Epsilon {
-		d.candidate = w
-		d.since = at
+	// Sxema xato bersa ham sikl "printed" deb belgilanadi: xuddi shu nuqtada
+	// har sample'da qayta urinish bo'lmaydi, yangi sikl vazn o'zgargach boshlanadi.
+	d.printed = true
+	d.printedWeight = w
+	epc, err := d.scheme.Next(at)
+	d.err = err
+	d.emit(DetectorEvent{Kind: DetectorTriggered, At: at, Weight: w, Err: err})
+	if err != nil {
 		return "", false
 	}
+	return epc, true
+}
 
-	if at.Sub(d.since) < d.cfg.StableFor {
-		return "", false
+// Abort o'qish xatosida siklni to'xtatadi; kuzatuv bo'lgan bo'lsa reset event'i reason bilan.
+func (d *StableEPCDetector) Abort(reason string, at time.Time) {
+	if at.IsZero() {
+		at = time.Now()
 	}
+	d.resetWith(at, reason)
+}
 
-	d.printed = true
-	d.printedWeight = w
-	return d.nextEPC24(at), true
+func (d *StableEPCDetector) resetWith(at time.Time, reason string) {
+	if d.active || d.printed {
+		d.emit(DetectorEvent{Kind: DetectorReset, At: at, Reason: reason})
+	}
+	d.reset()
 }
 
 func (d *StableEPCDetector) reset() {
-	d.active = false
 	d.printed = false
-	d.candidate = 0
-	d.since = time.Time{}
 	d.printedWeight = 0
+	d.active = false
+	d.candidate = 0
+	d.waiting = false
+	d.strategy.Reset()
 }
 
-// nextEPC24 returns a 24-char uppercase hex EPC-like id:
-// 30 + 14 hex chars (unix ns low 56-bit) + 8 hex chars (time-atom mix tail).
-func (d *StableEPCDetector) nextEPC24(t time.Time) string {
-	ns := t.UnixNano()
-	if ns != d.lastNS {
-		d.lastNS = ns
-		d.seq = 0
-	} else {
-		d.seq++
-	}
-	return formatEPC24(ns, d.seq, d.salt)
+func (d *StableEPCDetector) progress(at time.Time) SettleProgress {
+	if p, ok := d.strategy.(StabilityProgressReporter); ok {
+		return p.Progress(at)
+	}
+	return SettleProgress{}
+}
+
+func (d *StableEPCDetector) emit(ev DetectorEvent) {
+	if d.onEvent != nil {
+		d.onEvent(ev)
+	}
 }
 
+// formatEPC24 returns a 24-char uppercase hex EPC-like id:
+// 30 + 14 hex chars (unix ns low 56-bit) + 6 hex chars (time-atom mix tail) +
+// 2 hex chars CRC-8 of the first 11 bytes (ValidateEPC checks it).
 func formatEPC24(ns int64, seq, salt uint32) string {
 	atom := uint32((uint64(ns) / 1_000) & 0xFFFFFFFF)
 	tail := atom ^ bits.RotateLeft32(uint32(ns), 13) ^ bits.RotateLeft32(seq, 7) ^ salt
-	tail |= 1
-	return fmt.Sprintf("30%014X%08X", uint64(ns)&0x00FFFFFFFFFFFFFF, tail)
+	tail = (tail | 1) & 0xFFFFFF
+	var b [12]byte
+	b[0] = 0x30
+	binary.BigEndian.PutUint64(b[1:9], uint64(ns)<<8)
+	b[8] = byte(tail >> 16)
+	b[9] = byte(tail >> 8)
+	b[10] = byte(tail)
+	b[11] = epcCRC8(b[:11])
+	return fmt.Sprintf("%X", b[:])
 }
 
 func newEPCSalt() uint32 {
@@ -76,12 +76,48 @@ func TestStableEPCDetector_TinyJitterDoesNotRearm(t *testing.T) {
 	}
 }
 
+func TestStableEPCDetector_Events(t *testing.T) {
+	var events []DetectorEvent
+	cfg := DefaultStableEPCConfig()
+	cfg.OnEvent = func(ev DetectorEvent) { events = append(events, ev) }
+	d := NewStableEPCDetector(cfg)
+	t0 := time.Unix(1_700_000_000, 0)
+
+	w1, w2 := 1.250, 1.300
+	d.Observe(&w1, t0)
+	d.Observe(&w1, t0.Add(600*time.Millisecond))
+	d.Observe(&w2, t0.Add(700*time.Millisecond))
+	d.Observe(&w2, t0.Add(1800*time.Millisecond))
+	d.Observe(&w2, t0.Add(1900*time.Millisecond))
+	d.Observe(&w2, t0.Add(2000*time.Millisecond))
+	d.Abort("serial xato", t0.Add(2100*time.Millisecond))
+	d.Observe(nil, t0.Add(2200*time.Millisecond))
+
+	var kinds []string
+	for _, ev := range events {
+		kinds = append(kinds, string(ev.Kind))
+	}
+	want := "armed settling settling candidate settling triggered waiting_removal reset"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Fatalf("events=%q\nwant   %q", got, want)
+	}
+	if got := events[2].Progress.String(); got != "0.6s/1.0s" {
+		t.Fatalf("settling progress=%q", got)
+	}
+	if events[5].Err != nil || events[5].Weight != w2 {
+		t.Fatalf("triggered=%+v", events[5])
+	}
+	if events[7].Reason != "serial xato" {
+		t.Fatalf("reset reason=%q", events[7].Reason)
+	}
+}
+
 func TestNextEPC24_LengthAndUniq(t *testing.T) {
-	d := NewStableEPCDetector(DefaultStableEPCConfig())
+	s := NewTimeEPCScheme()
 	t0 := time.Unix(1_700_000_000, 123_456_789)
 
-	a := d.nextEPC24(t0)
-	b := d.nextEPC24(t0)
+	a, _ := s.Next(t0)
+	b, _ := s.Next(t0)
 
 	if len(a) != 24 {
 		t.Fatalf("epc len mismatch: got=%d epc=%s", len(a), a)
@@ -100,6 +136,18 @@ func TestNextEPC24_LengthAndUniq(t *testing.T) {
 	}
 }
 
+func TestTimeEPCSchemeWithSalt_Deterministic(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 123_456_789)
+	a, b := NewTimeEPCSchemeWithSalt(7), NewTimeEPCSchemeWithSalt(7)
+	for i := 0; i < 3; i++ {
+		x, _ := a.Next(t0)
+		y, _ := b.Next(t0)
+		if x != y {
+			t.Fatalf("step %d: %s != %s", i, x, y)
+		}
+	}
+}
+
 func isUpperHex(v string) bool {
 	for _, ch := range v {
 		if strings.ContainsRune("0123456789ABCDEF", ch) {
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// CheckClass barqaror vaznning nominalga nisbatan sinfi.
+type CheckClass string
+
+const (
+	CheckUnder CheckClass = "UNDER"
+	CheckOK    CheckClass = "OK"
+	CheckOver  CheckClass = "OVER"
+)
+
+const (
+	// CheckPolicyBlock tolerance'dan tashqari mahsulotga EPC berilmaydi (default).
+	CheckPolicyBlock = "block"
+	// CheckPolicyMark EPC beriladi, label'da sinf (UNDER/OVER) ko'rsatiladi.
+	CheckPolicyMark = "mark"
+)
+
+// CheckDefaultItem rules faylida item topilmasa ishlatiladigan kalit.
+const CheckDefaultItem = "*"
+
+// CheckRule bitta item uchun nominal va tolerance (vazn birligida, odatda kg).
+// Minus/Plus berilmasa Tolerance ikkala tomonga ishlaydi.
+type CheckRule struct {
+	Nominal   float64 `json:"nominal"`
+	Tolerance float64 `json:"tolerance,omitempty"`
+	Minus     float64 `json:"minus,omitempty"`
+	Plus      float64 `json:"plus,omitempty"`
+}
+
+func (r CheckRule) bounds() (float64, float64) {
+	minus, plus := r.Minus, r.Plus
+	if minus <= 0 {
+		minus = r.Tolerance
+	}
+	if plus <= 0 {
+		plus = r.Tolerance
+	}
+	return r.Nominal - minus, r.Nominal + plus
+}
+
+func (r CheckRule) validate() error {
+	if r.Nominal <= 0 || math.IsNaN(r.Nominal) || math.IsInf(r.Nominal, 0) {
+		return fmt.Errorf("nominal musbat bo'lishi kerak: %v", r.Nominal)
+	}
+	if r.Tolerance < 0 || r.Minus < 0 || r.Plus < 0 {
+		return fmt.Errorf("tolerance manfiy bo'lmasin")
+	}
+	return nil
+}
+
+// CheckResult klassifikatsiya natijasi. Deviation = vazn - nominal.
+type CheckResult struct {
+	Class     CheckClass
+	Nominal   float64
+	Deviation float64
+}
+
+// OK vazn tolerance ichida.
+func (r CheckResult) OK() bool { return r.Class == CheckOK }
+
+// String TUI/bot uchun qisqa ko'rinish: `OVER +0.021 (nominal 1.000)`.
+func (r CheckResult) String() string {
+	if r.Class == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s %+.3f (nominal %.3f)", r.Class, r.Deviation, r.Nominal)
+}
+
+// Classify vaznni qoidaga solishtiradi; chegara qiymatlari OK hisoblanadi.
+func (r CheckRule) Classify(weight float64) CheckResult {
+	lo, hi := r.bounds()
+	res := CheckResult{Class: CheckOK, Nominal: r.Nominal, Deviation: weight - r.Nominal}
+	// 1e-9: float yaxlitlash chegaradagi vaznni tashqariga chiqarib yubormasin.
+	switch {
+	case weight < lo-1e-9:
+		res.Class = CheckUnder
+	case weight > hi+1e-9:
+		res.Class = CheckOver
+	}
+	return res
+}
+
+// CheckWeigher item kodi bo'yicha qoidalar to'plami.
+type CheckWeigher struct {
+	rules map[string]CheckRule
+}
+
+// NewCheckWeigher qoidalarni tekshirib CheckWeigher yasaydi. `*` kaliti default qoida.
+func NewCheckWeigher(rules map[string]CheckRule) (*CheckWeigher, error) {
+	out := make(map[string]CheckRule, len(rules))
+	for item, rule := range rules {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("check-weigh: item kodi bo'sh")
+		}
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("check-weigh %s: %w", item, err)
+		}
+		out[item] = rule
+	}
+	return &CheckWeigher{rules: out}, nil
+}
+
+// LoadCheckWeigher JSON fayldan o'qiydi: {"ITEM-001": {"nominal": 1.0, "tolerance": 0.01}, "*": {...}}.
+func LoadCheckWeigher(path string) (*CheckWeigher, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read check-weigh rules: %w", err)
+	}
+	var rules map[string]CheckRule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("check-weigh rules noto'g'ri (%s): %w", path, err)
+	}
+	return NewCheckWeigher(rules)
+}
+
+// Rule item qoidasi (yo'q bo'lsa `*`); topilmasa false.
+func (c *CheckWeigher) Rule(itemCode string) (CheckRule, bool) {
+	if c == nil {
+		return CheckRule{}, false
+	}
+	if r, ok := c.rules[strings.TrimSpace(itemCode)]; ok {
+		return r, true
+	}
+	r, ok := c.rules[CheckDefaultItem]
+	return r, ok
+}
+
+// Classify item uchun qoida bo'lsa natija va true; qoida yo'q bo'lsa tekshiruv yo'q (false).
+func (c *CheckWeigher) Classify(itemCode string, weight float64) (CheckResult, bool) {
+	rule, ok := c.Rule(itemCode)
+	if !ok {
+		return CheckResult{}, false
+	}
+	return rule.Classify(weight), true
+}
+
+// NormalizeCheckPolicy bo'sh qiymatni CheckPolicyBlock ga aylantiradi.
+func NormalizeCheckPolicy(policy string) string {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if policy == "" {
+		return CheckPolicyBlock
+	}
+	return policy
+}
+
+// ValidateCheckPolicy faqat block yoki mark.
+func ValidateCheckPolicy(policy string) error {
+	switch NormalizeCheckPolicy(policy) {
+	case CheckPolicyBlock, CheckPolicyMark:
+		return nil
+	default:
+		return fmt.Errorf("check-weigh policy noma'lum: %q (block yoki mark)", policy)
+	}
+}
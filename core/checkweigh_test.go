@@ -0,0 +1,65 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckRuleClassify(t *testing.T) {
+	sym := CheckRule{Nominal: 1.000, Tolerance: 0.010}
+	asym := CheckRule{Nominal: 1.000, Minus: 0.005, Plus: 0.020}
+	cases := []struct {
+		rule   CheckRule
+		weight float64
+		want   CheckClass
+	}{
+		{sym, 0.989, CheckUnder},
+		{sym, 0.990, CheckOK},
+		{sym, 1.010, CheckOK},
+		{sym, 1.011, CheckOver},
+		{asym, 0.994, CheckUnder},
+		{asym, 1.020, CheckOK},
+		{asym, 1.021, CheckOver},
+	}
+	for _, tc := range cases {
+		if got := tc.rule.Classify(tc.weight); got.Class != tc.want {
+			t.Fatalf("rule=%+v weight=%.3f class=%s want %s", tc.rule, tc.weight, got.Class, tc.want)
+		}
+	}
+	if got := sym.Classify(1.021).String(); got != "OVER +0.021 (nominal 1.000)" {
+		t.Fatalf("string=%q", got)
+	}
+}
+
+func TestLoadCheckWeigher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	body := `{"ITM-1": {"nominal": 2.5, "tolerance": 0.05}, "*": {"nominal": 1.0, "tolerance": 0.01}}`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadCheckWeigher(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, ok := c.Classify("ITM-1", 2.46); !ok || res.Class != CheckOK {
+		t.Fatalf("ITM-1: %+v ok=%v", res, ok)
+	}
+	if res, ok := c.Classify("OTHER", 1.2); !ok || res.Class != CheckOver {
+		t.Fatalf("default rule: %+v ok=%v", res, ok)
+	}
+
+	c2, err := NewCheckWeigher(map[string]CheckRule{"ITM-1": {Nominal: 1, Tolerance: 0.1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c2.Classify("OTHER", 5); ok {
+		t.Fatal("no rule -> no classification")
+	}
+	if _, err := NewCheckWeigher(map[string]CheckRule{"X": {Nominal: 0}}); err == nil {
+		t.Fatal("zero nominal should fail")
+	}
+	if err := ValidateCheckPolicy("warn"); err == nil {
+		t.Fatal("unknown policy should fail")
+	}
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// UnitWeightSample dona og'irligi tarozida namuna (N dona) tortib o'rganilgan.
+	UnitWeightSample = "sample"
+	// UnitWeightERP dona og'irligi ERP item'idan (weight_per_unit) olingan.
+	UnitWeightERP = "erp"
+)
+
+// DefaultCountTolerance yaxlitlashda ruxsat etilgan og'ish koeffitsienti: donalar og'irligi
+// farqi yig'indida √n bilan o'sadi, ruxsat = Tolerance * √n dona. 24 donada ±0.24:
+// 23.8 -> 24 ishonchli, 23.6 -> ishonchsiz.
+const DefaultCountTolerance = 0.05
+
+const (
+	// minCountDeviation kam donada ruxsat etilgan eng kichik og'ish (dona): tarozi
+	// diskretligi va namuna xatosi uchun.
+	minCountDeviation = 0.1
+	// maxCountDeviation qat'iy chegara: yaxlitlash qoldig'i hech qachon 0.5 dan oshmaydi,
+	// shuning uchun undan past chegara bo'lmasa katta sonlarda tekshiruv hech narsani rad etmaydi.
+	maxCountDeviation = 0.3
+)
+
+var (
+	// ErrNoUnitWeight counting rejimida dona og'irligi hali ma'lum emas.
+	ErrNoUnitWeight = errors.New("dona og'irligi noma'lum")
+	// ErrCountUncertain vazn butun dona soniga yetarlicha yaqin emas.
+	ErrCountUncertain = errors.New("dona soni ishonchsiz")
+)
+
+// PieceCounter vaznni dona soniga aylantiradi. UnitWeight kg'da,
+// UOM ERP stock UOM (masalan `Nos`). UnitWeight=0 bo'lsa counting rejimi bor, lekin
+// dona og'irligi hali o'rganilmagan.
+type PieceCounter struct {
+	UnitWeight float64
+	Source     string
+	SampleQty  int
+	UOM        string
+	// Tolerance ruxsat koeffitsienti (og'ish = Tolerance * √n); 0 bo'lsa DefaultCountTolerance.
+	Tolerance float64
+}
+
+// NewPieceCounter ma'lum dona og'irligi (masalan ERP'dan) bilan counter.
+func NewPieceCounter(unitWeight float64, source, uom string) (PieceCounter, error) {
+	if unitWeight <= 0 || math.IsNaN(unitWeight) || math.IsInf(unitWeight, 0) {
+		return PieceCounter{}, fmt.Errorf("dona og'irligi musbat bo'lishi kerak: %v", unitWeight)
+	}
+	return PieceCounter{UnitWeight: unitWeight, Source: strings.TrimSpace(source), UOM: strings.TrimSpace(uom)}, nil
+}
+
+// SamplePieceCounter tarozidagi namunadan (weight vazn unit birligida, pieces dona) dona
+// og'irligini o'rganadi.
+func SamplePieceCounter(weight float64, unit string, pieces int, uom string) (PieceCounter, error) {
+	if pieces <= 0 {
+		return PieceCounter{}, fmt.Errorf("namuna soni musbat bo'lishi kerak: %d", pieces)
+	}
+	if weight <= 0 {
+		return PieceCounter{}, fmt.Errorf("namuna vazni musbat bo'lishi kerak: %.3f", weight)
+	}
+	kg, err := WeightToKg(weight, unit)
+	if err != nil {
+		return PieceCounter{}, err
+	}
+	c, err := NewPieceCounter(kg/float64(pieces), UnitWeightSample, uom)
+	if err != nil {
+		return PieceCounter{}, err
+	}
+	c.SampleQty = pieces
+	return c, nil
+}
+
+// Ready dona og'irligi ma'lum.
+func (c PieceCounter) Ready() bool { return c.UnitWeight > 0 }
+
+// allowedDeviation exact dona uchun ruxsat etilgan og'ish (dona).
+func (c PieceCounter) allowedDeviation(exact float64) float64 {
+	tol := c.Tolerance
+	if tol <= 0 {
+		tol = DefaultCountTolerance
+	}
+	dev := tol * math.Sqrt(math.Abs(exact))
+	return math.Min(math.Max(dev, minCountDeviation), maxCountDeviation)
+}
+
+// WeightToKg tarozi birligidagi vaznni kg'ga aylantiradi (bo'sh birlik - kg).
+func WeightToKg(weight float64, unit string) (float64, error) {
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "", "kg":
+		return weight, nil
+	case "g":
+		return weight / 1000, nil
+	case "lb":
+		return weight * 0.45359237, nil
+	case "oz":
+		return weight * 0.028349523125, nil
+	default:
+		return 0, fmt.Errorf("vazn birligi noma'lum: %q", unit)
+	}
+}
+
+// String TUI/bot uchun: `0.125 kg/dona (sample 10)`.
+func (c PieceCounter) String() string {
+	if !c.Ready() {
+		return "-"
+	}
+	src := c.Source
+	if c.SampleQty > 0 {
+		src = fmt.Sprintf("%s %d", src, c.SampleQty)
+	}
+	if src == "" {
+		return fmt.Sprintf("%.4f kg/dona", c.UnitWeight)
+	}
+	return fmt.Sprintf("%.4f kg/dona (%s)", c.UnitWeight, src)
+}
+
+// PieceCount Count natijasi. Exact - yaxlitlanmagan dona soni, Deviation = Exact - Pieces.
+type PieceCount struct {
+	Pieces    int
+	Exact     float64
+	Deviation float64
+	UOM       string
+}
+
+// String label/bot uchun: `12 Nos`.
+func (p PieceCount) String() string {
+	uom := p.UOM
+	if uom == "" {
+		uom = "dona"
+	}
+	return fmt.Sprintf("%d %s", p.Pieces, uom)
+}
+
+// CountUnit Count kabi, lekin vazn tarozi birligida (`g`, `lb`, ...): avval kg'ga aylantiriladi.
+func (c PieceCounter) CountUnit(weight float64, unit string) (PieceCount, error) {
+	kg, err := WeightToKg(weight, unit)
+	if err != nil {
+		return PieceCount{}, err
+	}
+	return c.Count(kg)
+}
+
+// Count kg'dagi vaznni eng yaqin butun dona soniga yaxlitlaydi. Og'ish ruxsatdan
+// (Tolerance * √n, 0.1..0.3 dona) katta bo'lsa (yoki 1 donadan kam) ErrCountUncertain:
+// noto'g'ri son ERP'ga ketmasin.
+func (c PieceCounter) Count(weight float64) (PieceCount, error) {
+	if !c.Ready() {
+		return PieceCount{}, ErrNoUnitWeight
+	}
+	exact := weight / c.UnitWeight
+	res := PieceCount{Pieces: int(math.Round(exact)), Exact: exact, UOM: c.UOM}
+	res.Deviation = exact - float64(res.Pieces)
+	if res.Pieces < 1 {
+		return res, fmt.Errorf("%w: %.2f dona (1 donadan kam)", ErrCountUncertain, exact)
+	}
+	if allowed := c.allowedDeviation(exact); math.Abs(res.Deviation) > allowed+1e-9 {
+		return res, fmt.Errorf("%w: %.2f dona (og'ish %+.2f, ruxsat ±%.2f)", ErrCountUncertain, exact, res.Deviation, allowed)
+	}
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestPieceCounter_SampleAndCount(t *testing.T) {
+	c, err := SamplePieceCounter(1.250, "kg", 10, "Nos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(c.UnitWeight-0.125) > 1e-12 || c.Source != UnitWeightSample || c.SampleQty != 10 {
+		t.Fatalf("sample: %+v", c)
+	}
+	if got := c.String(); got != "0.1250 kg/dona (sample 10)" {
+		t.Fatalf("string: %q", got)
+	}
+
+	got, err := c.Count(2.990) // 23.92 dona
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Pieces != 24 || got.String() != "24 Nos" {
+		t.Fatalf("count: %+v", got)
+	}
+
+	// 23.6 dona: qaysi tomonga yaxlitlash noma'lum.
+	if _, err := c.Count(2.950); !errors.Is(err, ErrCountUncertain) {
+		t.Fatalf("uncertain: %v", err)
+	}
+	if _, err := c.Count(0.05); !errors.Is(err, ErrCountUncertain) {
+		t.Fatalf("below one piece: %v", err)
+	}
+
+	// Ruxsat √n bilan o'sadi, lekin 0.3 donadan oshmaydi: katta sonda ham yarim dona
+	// qoldiq ERP'ga ketmaydi.
+	if got, err := c.Count(0.125 * 480.2); err != nil || got.Pieces != 480 {
+		t.Fatalf("large count: %+v err=%v", got, err)
+	}
+	for _, n := range []float64{100.5, 480.4} {
+		if _, err := c.Count(0.125 * n); !errors.Is(err, ErrCountUncertain) {
+			t.Fatalf("%.1f pieces must be uncertain: %v", n, err)
+		}
+	}
+	if _, err := c.Count(0.125 * 3.2); !errors.Is(err, ErrCountUncertain) {
+		t.Fatalf("small count: %v", err)
+	}
+
+	c.Tolerance = 0.01
+	if _, err := c.Count(0.125 * 23.85); !errors.Is(err, ErrCountUncertain) {
+		t.Fatalf("custom tolerance: %v", err)
+	}
+}
+
+func TestPieceCounter_Units(t *testing.T) {
+	c, err := SamplePieceCounter(1250, "g", 10, "Nos")
+	if err != nil || math.Abs(c.UnitWeight-0.125) > 1e-12 {
+		t.Fatalf("gram sample: %+v err=%v", c, err)
+	}
+	if got, err := c.CountUnit(2990, "g"); err != nil || got.Pieces != 24 {
+		t.Fatalf("gram count: %+v err=%v", got, err)
+	}
+	if got, err := c.CountUnit(6.5918, "lb"); err != nil || got.Pieces != 24 {
+		t.Fatalf("lb count: %+v err=%v", got, err)
+	}
+	if _, err := c.CountUnit(1, "stone"); err == nil {
+		t.Fatal("unknown unit should fail")
+	}
+}
+
+func TestPieceCounter_Errors(t *testing.T) {
+	if _, err := (PieceCounter{UOM: "Nos"}).Count(1); !errors.Is(err, ErrNoUnitWeight) {
+		t.Fatalf("no unit weight: %v", err)
+	}
+	if _, err := SamplePieceCounter(1, "kg", 0, "Nos"); err == nil {
+		t.Fatal("zero pieces should fail")
+	}
+	if _, err := SamplePieceCounter(0, "kg", 5, "Nos"); err == nil {
+		t.Fatal("empty scale sample should fail")
+	}
+	if _, err := NewPieceCounter(math.NaN(), UnitWeightERP, "Nos"); err == nil {
+		t.Fatal("NaN unit weight should fail")
+	}
+	c, err := NewPieceCounter(0.5, UnitWeightERP, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Count(3.02); err != nil || got.String() != "6 dona" {
+		t.Fatalf("erp counter: %+v err=%v", got, err)
+	}
+}
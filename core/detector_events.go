@@ -0,0 +1,91 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// DetectorEventKind StableEPCDetector sikl bosqichi.
+type DetectorEventKind string
+
+const (
+	// DetectorArmed tarozida vazn paydo bo'ldi (yoki chop etilgan vazn olinib yangi sikl boshlandi).
+	DetectorArmed DetectorEventKind = "armed"
+	// DetectorCandidate filtrlangan vazn Epsilon'dan ko'proq o'zgardi: barqarorlik qaytadan hisoblanadi.
+	DetectorCandidate DetectorEventKind = "candidate"
+	// DetectorSettling vazn hali barqaror emas; Progress qancha qolganini ko'rsatadi.
+	DetectorSettling DetectorEventKind = "settling"
+	// DetectorTriggered barqaror vazn uchun trigger bo'ldi (Err bo'lsa sxema EPC bermadi).
+	// Label hali berilmagan: EPC chaqiruvchi tekshiruvlari va registry'dan keyin chiqariladi.
+	DetectorTriggered DetectorEventKind = "triggered"
+	// DetectorWaitingRemoval chop etildi, mahsulot olinishi (vazn o'zgarishi) kutilmoqda.
+	DetectorWaitingRemoval DetectorEventKind = "waiting_removal"
+	// DetectorReset vazn yo'qoldi, MinWeight'dan tushdi yoki o'qish xatosi (Reason).
+	DetectorReset DetectorEventKind = "reset"
+)
+
+// SettleProgress barqarorlikka qancha qolgani. Unit "s" (sekund) yoki "frames" (ST frame'lar).
+type SettleProgress struct {
+	Done float64
+	Need float64
+	Unit string
+}
+
+// Known strategiya progress bera oladi.
+func (p SettleProgress) Known() bool { return p.Need > 0 }
+
+// String `0.6s/1.0s` yoki `2/3 frames`.
+func (p SettleProgress) String() string {
+	if !p.Known() {
+		return "-"
+	}
+	if p.Unit == "s" {
+		return fmt.Sprintf("%.1fs/%.1fs", p.Done, p.Need)
+	}
+	return fmt.Sprintf("%.0f/%.0f %s", p.Done, p.Need, p.Unit)
+}
+
+func durationProgress(done, need time.Duration) SettleProgress {
+	if done < 0 {
+		done = 0
+	}
+	if done > need {
+		done = need
+	}
+	return SettleProgress{Done: done.Seconds(), Need: need.Seconds(), Unit: "s"}
+}
+
+// StabilityProgressReporter ixtiyoriy: strategiya joriy oynada barqarorlikka qancha
+// qolganini aytadi (DetectorSettling event'i uchun).
+type StabilityProgressReporter interface {
+	Progress(at time.Time) SettleProgress
+}
+
+// DetectorEvent detector'dan chiqadigan bitta event. Weight filtrlangan level.
+type DetectorEvent struct {
+	Kind     DetectorEventKind
+	At       time.Time
+	Weight   float64
+	Progress SettleProgress
+	Reason   string
+	Err      error
+}
+
+// String TUI/workflow log uchun qisqa ko'rinish.
+func (e DetectorEvent) String() string {
+	switch e.Kind {
+	case DetectorSettling:
+		return fmt.Sprintf("settling %s (%.3f)", e.Progress, e.Weight)
+	case DetectorTriggered:
+		if e.Err != nil {
+			return fmt.Sprintf("triggered %.3f, EPC xato: %v", e.Weight, e.Err)
+		}
+		return fmt.Sprintf("triggered %.3f", e.Weight)
+	case DetectorWaitingRemoval:
+		return fmt.Sprintf("waiting removal (%.3f)", e.Weight)
+	case DetectorReset:
+		return "reset: " + e.Reason
+	default:
+		return fmt.Sprintf("%s %.3f", e.Kind, e.Weight)
+	}
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// readbackHexRegex readback qatoridagi EPC ko'rinishidagi hex (kamida 2 word).
+var readbackHexRegex = regexp.MustCompile(`[0-9A-F]{8,}`)
+
+var (
+	// ErrEPCFormat EPC hex emas, bo'sh yoki uzunligi 8..64 oralig'ida emas.
+	ErrEPCFormat = errors.New("epc formati noto'g'ri")
+	// ErrEPCTruncated uzunlik 16-bit word'ga bo'linmaydi: odatda PC word noto'g'ri
+	// bo'lib reader oxirgi word'ni kesgan (24 o'rniga 22 hex) yoki qo'lda kam yozilgan.
+	ErrEPCTruncated = errors.New("epc kesilgan")
+	// ErrEPCChecksum 96-bit EPC na bizning checksum'ga, na SGTIN-96 tuzilishiga mos:
+	// noto'g'ri o'qilgan yoki qo'lda xato kiritilgan.
+	ErrEPCChecksum = errors.New("epc checksum mos emas")
+)
+
+// EPCKind ValidateEPC aniqlagan EPC turi.
+type EPCKind string
+
+const (
+	// EPCKindTime TimeEPCScheme yaratgan EPC: oxirgi bayt CRC-8.
+	EPCKindTime EPCKind = "time"
+	// EPCKindSGTIN96 GS1 SGTIN-96 tuzilishi to'g'ri (alohida checksum yo'q).
+	EPCKindSGTIN96 EPCKind = "sgtin96"
+	// EPCKindUnknown boshqa uzunlikdagi EPC: faqat hex/word tekshirildi.
+	EPCKindUnknown EPCKind = "unknown"
+)
+
+// EPCCheck validatsiya natijasi. Checksum=true bo'lsa CRC tekshirilgan va mos.
+type EPCCheck struct {
+	EPC      string
+	Kind     EPCKind
+	Checksum bool
+}
+
+// NormalizeEPC EPC'ni katta harf hex'ga keltiradi (`0x`, bo'sh joy va `-` olib tashlanadi)
+// va tuzilishini tekshiradi. Xatolar ErrEPCFormat yoki ErrEPCTruncated'ni o'raydi.
+func NormalizeEPC(epc string) (string, error) {
+	v := strings.ToUpper(strings.TrimSpace(epc))
+	v = strings.TrimPrefix(v, "0X")
+	v = strings.ReplaceAll(v, " ", "")
+	v = strings.ReplaceAll(v, "-", "")
+
+	if v == "" {
+		return "", fmt.Errorf("%w: epc bo'sh", ErrEPCFormat)
+	}
+	for _, r := range v {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'F') {
+			return "", fmt.Errorf("%w: epc faqat hex bo'lishi kerak", ErrEPCFormat)
+		}
+	}
+	if len(v)%4 != 0 {
+		return "", fmt.Errorf("%w: %d hex 16-bit word (4 hex belgi) ga bo'linmaydi (PC word noto'g'ri? 96-bit EPC 24 hex)", ErrEPCTruncated, len(v))
+	}
+	if len(v) < 8 || len(v) > 64 {
+		return "", fmt.Errorf("%w: epc uzunligi 8..64 oralig'ida bo'lsin", ErrEPCFormat)
+	}
+	return v, nil
+}
+
+// ValidateEPC EPC'ni normalizatsiya qiladi va turini aniqlaydi. 96-bit (24 hex) EPC
+// CRC-8 mos bo'lsa EPCKindTime, aks holda SGTIN-96 sifatida o'qilsa EPCKindSGTIN96,
+// ikkalasi ham bo'lmasa ErrEPCChecksum. Boshqa uzunliklar EPCKindUnknown.
+// SGTIN-96 ham 0x30 header bilan boshlanadi, shuning uchun bitta belgisi buzilgan
+// time EPC ko'pincha SGTIN bo'lib o'tib ketadi: sxema ma'lum bo'lsa ValidateEPCScheme.
+func ValidateEPC(epc string) (EPCCheck, error) {
+	v, err := NormalizeEPC(epc)
+	if err != nil {
+		return EPCCheck{}, err
+	}
+	if len(v) != 24 {
+		return EPCCheck{EPC: v, Kind: EPCKindUnknown}, nil
+	}
+	if epcChecksumOK(v) {
+		return EPCCheck{EPC: v, Kind: EPCKindTime, Checksum: true}, nil
+	}
+	if _, err := DecodeSGTIN96(v); err == nil {
+		return EPCCheck{EPC: v, Kind: EPCKindSGTIN96}, nil
+	}
+	return EPCCheck{EPC: v}, fmt.Errorf("%w: %s", ErrEPCChecksum, v)
+}
+
+// ValidateEPCScheme sxema ma'lum bo'lsa (masalan registry yozuvidan) qat'iy tekshiradi:
+// time uchun CRC majburiy, sgtin96 uchun SGTIN-96 tuzilishi. Boshqa sxema - ValidateEPC.
+func ValidateEPCScheme(epc, scheme string) (EPCCheck, error) {
+	v, err := NormalizeEPC(epc)
+	if err != nil {
+		return EPCCheck{}, err
+	}
+	switch strings.ToLower(strings.TrimSpace(scheme)) {
+	case EPCSchemeTime:
+		if len(v) != 24 || !epcChecksumOK(v) {
+			return EPCCheck{EPC: v}, fmt.Errorf("%w: %s", ErrEPCChecksum, v)
+		}
+		return EPCCheck{EPC: v, Kind: EPCKindTime, Checksum: true}, nil
+	case EPCSchemeSGTIN96:
+		if _, err := DecodeSGTIN96(v); err != nil {
+			return EPCCheck{EPC: v}, fmt.Errorf("%w: %v", ErrEPCChecksum, err)
+		}
+		return EPCCheck{EPC: v, Kind: EPCKindSGTIN96}, nil
+	default:
+		return ValidateEPC(v)
+	}
+}
+
+// ReadbackEPCCorrupt Zebra readback matnidagi hex'lar orasida yaroqli EPC yo'q. Kutilgan
+// EPC'ning sxemasi (CRC'li vaqt EPC yoki SGTIN) o'qilganiga ham qo'llanadi. Hex umuman
+// bo'lmasa false: bu CORRUPT emas, MISMATCH.
+func ReadbackEPCCorrupt(readback, expected string) bool {
+	scheme := ""
+	if chk, err := ValidateEPC(expected); err == nil && chk.Kind != EPCKindUnknown {
+		scheme = string(chk.Kind)
+	}
+	found := readbackHexRegex.FindAllString(strings.ToUpper(strings.ReplaceAll(readback, " ", "")), -1)
+	if len(found) == 0 {
+		return false
+	}
+	for _, v := range found {
+		if _, err := ValidateEPCScheme(v, scheme); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func epcChecksumOK(v string) bool {
+	b, err := hex.DecodeString(v)
+	if err != nil || len(b) != 12 {
+		return false
+	}
+	return epcCRC8(b[:11]) == b[11]
+}
+
+// epcCRC8 CRC-8 (poly 0x07, init 0x00): bitta belgi xatosi va qo'shni ikki belgi
+// almashishini har doim ushlaydi.
+func epcCRC8(b []byte) byte {
+	var crc byte
+	for _, x := range b {
+		crc ^= x
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateEPC(t *testing.T) {
+	s := NewTimeEPCScheme()
+	epc, err := s.Next(time.Unix(1_700_000_000, 123_456_789))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chk, err := ValidateEPC("0x" + epc[:12] + " " + epc[12:])
+	if err != nil || chk.Kind != EPCKindTime || !chk.Checksum || chk.EPC != epc {
+		t.Fatalf("generated epc: %+v err=%v", chk, err)
+	}
+
+	// Har bitta belgi xatosi va qo'shni belgilar almashishi ushlanadi.
+	for i := 2; i < len(epc); i++ {
+		b := []byte(epc)
+		if b[i] == 'F' {
+			b[i] = '0'
+		} else if b[i] == '9' {
+			b[i] = 'A'
+		} else {
+			b[i]++
+		}
+		if _, err := ValidateEPCScheme(string(b), EPCSchemeTime); !errors.Is(err, ErrEPCChecksum) {
+			t.Fatalf("typo at %d not detected: %s err=%v", i, b, err)
+		}
+		if i+1 < len(epc) && epc[i] != epc[i+1] {
+			sw := []byte(epc)
+			sw[i], sw[i+1] = sw[i+1], sw[i]
+			if _, err := ValidateEPCScheme(string(sw), EPCSchemeTime); !errors.Is(err, ErrEPCChecksum) {
+				t.Fatalf("swap at %d not detected: %s", i, sw)
+			}
+		}
+	}
+
+	if _, err := ValidateEPC(epc[:22]); !errors.Is(err, ErrEPCTruncated) {
+		t.Fatalf("22 hex should be truncated: %v", err)
+	}
+	if _, err := ValidateEPC("30ZZ"); !errors.Is(err, ErrEPCFormat) {
+		t.Fatalf("non-hex: %v", err)
+	}
+
+	sg := "3074257BF7194E4000001A85"
+	if chk, err := ValidateEPC(sg); err != nil || chk.Kind != EPCKindSGTIN96 || chk.Checksum {
+		t.Fatalf("sgtin: %+v err=%v", chk, err)
+	}
+	if _, err := ValidateEPCScheme(sg, EPCSchemeTime); !errors.Is(err, ErrEPCChecksum) {
+		t.Fatalf("sgtin under time scheme should fail: %v", err)
+	}
+	if chk, err := ValidateEPC("E28011606000020A"); err != nil || chk.Kind != EPCKindUnknown {
+		t.Fatalf("other length: %+v err=%v", chk, err)
+	}
+}
+
+func TestReadbackEPCCorrupt(t *testing.T) {
+	s := NewTimeEPCScheme()
+	epc, _ := s.Next(time.Unix(1_700_000_000, 123_456_789))
+	other, _ := s.Next(time.Unix(1_700_000_001, 0))
+	bad := []byte(epc)
+	bad[5] ^= 1
+	cases := []struct {
+		readback string
+		want     bool
+	}{
+		{"", false},
+		{"NO TAG", false},
+		{other, false},                         // boshqa, lekin yaroqli EPC: MISMATCH
+		{string(bad), true},                    // CRC buzilgan
+		{epc[:12] + " " + epc[12:22], true},    // kesilgan
+		{string(bad) + "\",\"" + other, false}, // bittasi yaroqli
+	}
+	for _, tc := range cases {
+		if got := ReadbackEPCCorrupt(tc.readback, epc); got != tc.want {
+			t.Fatalf("readback %q: corrupt=%v want %v", tc.readback, got, tc.want)
+		}
+	}
+}
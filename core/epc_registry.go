@@ -0,0 +1,282 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultEPCIssueAttempts dublikat chiqqanda Issue sxemadan necha marta yangi EPC so'raydi.
+const DefaultEPCIssueAttempts = 8
+
+// EPCRecord.Status qiymatlari. EPC encode'dan oldin band qilinadi (reserved): boshqa
+// stansiya uni ololmaydi. Encode natijasi keyin Settle bilan yoziladi; failed EPC ham
+// qayta berilmaydi (tag'ga qisman yozilgan bo'lishi mumkin).
+const (
+	EPCStatusReserved = "reserved"
+	EPCStatusEncoded  = "encoded"
+	EPCStatusFailed   = "failed"
+)
+
+// EPCRecord registry'dagi bitta berilgan EPC: nima uchun, qayerda va qachon.
+// Status bo'sh - eski yozuv (natija yozilmagan).
+type EPCRecord struct {
+	EPC       string  `json:"epc"`
+	Scheme    string  `json:"scheme,omitempty"`
+	ItemCode  string  `json:"item_code,omitempty"`
+	ItemName  string  `json:"item_name,omitempty"`
+	Qty       float64 `json:"qty"`
+	Unit      string  `json:"unit,omitempty"`
+	Station   string  `json:"station,omitempty"`
+	IssuedAt  string  `json:"issued_at"`
+	Status    string  `json:"status,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	SettledAt string  `json:"settled_at,omitempty"`
+}
+
+// ErrEPCDuplicate EPC avval berilgan. Aniq yozuv DuplicateEPCError.Existing da.
+var ErrEPCDuplicate = errors.New("epc avval berilgan")
+
+// DuplicateEPCError avval berilgan EPC haqidagi yozuvni olib yuradi.
+type DuplicateEPCError struct {
+	Existing EPCRecord
+}
+
+func (e *DuplicateEPCError) Error() string {
+	return fmt.Sprintf("%v: %s (%s, station=%s, %s)", ErrEPCDuplicate, e.Existing.EPC,
+		e.Existing.ItemCode, e.Existing.Station, e.Existing.IssuedAt)
+}
+
+func (e *DuplicateEPCError) Unwrap() error { return ErrEPCDuplicate }
+
+// EPCRegistry berilgan har bir EPC ni append-only JSONL faylga yozadi.
+// Yozish `.lock` flock ostida: bir faylni bir nechta stansiya (process) ishlatsa ham
+// har biri avval boshqalar yozgan qatorlarni o'qib, keyin dublikatni tekshiradi.
+type EPCRegistry struct {
+	path string
+
+	mu     sync.Mutex
+	index  map[string]EPCRecord
+	offset int64
+}
+
+// OpenEPCRegistry registry faylini o'qib indeksni quradi (fayl yo'q bo'lsa bo'sh).
+func OpenEPCRegistry(path string) (*EPCRegistry, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("epc registry fayl yo'li bo'sh")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir epc registry dir: %w", err)
+	}
+	r := &EPCRegistry{path: path, index: map[string]EPCRecord{}}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.withLock(syscall.LOCK_SH, r.catchUpLocked); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *EPCRegistry) Path() string { return r.path }
+
+// Lookup EPC berilganmi va kimga (item, qty, stansiya, vaqt).
+func (r *EPCRegistry) Lookup(epc string) (EPCRecord, bool, error) {
+	epc = normalizeRegistryEPC(epc)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.withLock(syscall.LOCK_SH, r.catchUpLocked); err != nil {
+		return EPCRecord{}, false, err
+	}
+	rec, ok := r.index[epc]
+	return rec, ok, nil
+}
+
+// Len registry'dagi EPC lar soni (oxirgi o'qishdagi holat).
+func (r *EPCRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.index)
+}
+
+// Register EPC ni yozadi. Avval berilgan bo'lsa *DuplicateEPCError qaytadi va hech narsa yozilmaydi.
+func (r *EPCRegistry) Register(rec EPCRecord) error {
+	rec.EPC = normalizeRegistryEPC(rec.EPC)
+	if rec.EPC == "" {
+		return fmt.Errorf("epc bo'sh")
+	}
+	if strings.TrimSpace(rec.IssuedAt) == "" {
+		rec.IssuedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	rec.SettledAt = ""
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.withLock(syscall.LOCK_EX, func() error {
+		if err := r.catchUpLocked(); err != nil {
+			return err
+		}
+		if old, ok := r.index[rec.EPC]; ok {
+			return &DuplicateEPCError{Existing: old}
+		}
+		return r.appendLocked(rec)
+	})
+}
+
+// Settle band qilingan EPC encode natijasini yozadi (EPCStatusEncoded yoki EPCStatusFailed,
+// detail - xato/verify). Yangi qator qo'shiladi; Lookup oxirgi natijani ko'rsatadi.
+func (r *EPCRegistry) Settle(epc, status, detail string, at time.Time) error {
+	epc = normalizeRegistryEPC(epc)
+	if at.IsZero() {
+		at = time.Now()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.withLock(syscall.LOCK_EX, func() error {
+		if err := r.catchUpLocked(); err != nil {
+			return err
+		}
+		rec, ok := r.index[epc]
+		if !ok {
+			return fmt.Errorf("epc registry'da yo'q: %s", epc)
+		}
+		rec.Status = strings.TrimSpace(status)
+		rec.Error = strings.TrimSpace(detail)
+		rec.SettledAt = at.UTC().Format(time.RFC3339Nano)
+		return r.appendLocked(rec)
+	})
+}
+
+// appendLocked yozuvni faylga qo'shadi va indeksni yangilaydi (LOCK_EX ostida).
+func (r *EPCRegistry) appendLocked(rec EPCRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal epc record: %w", err)
+	}
+	line = append(line, '\n')
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open epc registry: %w", err)
+	}
+	data := line
+	if st, err := f.Stat(); err == nil && st.Size() > r.offset {
+		// Chala qolgan oxirgi qator: yangi yozuv unga yopishmasin.
+		data = append([]byte{'\n'}, line...)
+		r.offset = st.Size()
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write epc registry: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("sync epc registry: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write epc registry: %w", err)
+	}
+	r.index[rec.EPC] = rec
+	r.offset += int64(len(data))
+	return nil
+}
+
+// Issue rec.EPC ni ro'yxatdan o'tkazadi; dublikat bo'lsa scheme'dan yangisini so'rab
+// (ko'pi bilan DefaultEPCIssueAttempts marta) qayta uradi. Qaytgan EPC ro'yxatda bor.
+func (r *EPCRegistry) Issue(scheme EPCScheme, rec EPCRecord) (string, error) {
+	at := time.Now()
+	if t, err := time.Parse(time.RFC3339Nano, rec.IssuedAt); err == nil {
+		at = t
+	}
+	if rec.Scheme == "" && scheme != nil {
+		rec.Scheme = scheme.Name()
+	}
+	var err error
+	for attempt := 0; attempt < DefaultEPCIssueAttempts; attempt++ {
+		if attempt > 0 || rec.EPC == "" {
+			if scheme == nil {
+				if err == nil {
+					err = fmt.Errorf("epc bo'sh")
+				}
+				return "", err
+			}
+			if rec.EPC, err = scheme.Next(at); err != nil {
+				return "", err
+			}
+		}
+		err = r.Register(rec)
+		if !errors.Is(err, ErrEPCDuplicate) {
+			if err != nil {
+				return "", err
+			}
+			return normalizeRegistryEPC(rec.EPC), nil
+		}
+	}
+	return "", fmt.Errorf("%d urinishda unikal epc topilmadi: %w", DefaultEPCIssueAttempts, err)
+}
+
+// catchUpLocked boshqa process'lar qo'shgan qatorlarni offset'dan boshlab o'qiydi.
+// Oxirgi to'liq bo'lmagan qator (yozish o'rtasida crash) keyingi safarga qoldiriladi.
+func (r *EPCRegistry) catchUpLocked() error {
+	f, err := os.Open(r.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open epc registry: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(r.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek epc registry: %w", err)
+	}
+	br := bufio.NewReader(f)
+	for {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read epc registry: %w", err)
+		}
+		r.offset += int64(len(line))
+		var rec EPCRecord
+		if jerr := json.Unmarshal(line, &rec); jerr != nil || rec.EPC == "" {
+			// Buzilgan qator EPC ni "bo'sh" deb ko'rsatmaydi, lekin butun registry'ni ham to'xtatmaydi.
+			continue
+		}
+		rec.EPC = normalizeRegistryEPC(rec.EPC)
+		old, ok := r.index[rec.EPC]
+		switch {
+		case !ok:
+			r.index[rec.EPC] = rec
+		case rec.SettledAt != "":
+			// Settle qatori: birinchi yozuv (item, qty, stansiya) saqlanadi, natija yangilanadi.
+			old.Status, old.Error, old.SettledAt = rec.Status, rec.Error, rec.SettledAt
+			r.index[rec.EPC] = old
+		}
+	}
+}
+
+func (r *EPCRegistry) withLock(how int, fn func() error) error {
+	f, err := os.OpenFile(r.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("lock epc registry: %w", err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		return fmt.Errorf("lock epc registry: %w", err)
+	}
+	defer func() { _ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN) }()
+	return fn()
+}
+
+func normalizeRegistryEPC(epc string) string {
+	return strings.ToUpper(strings.TrimSpace(epc))
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEPCRegistry_RegisterLookupDuplicate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "epc.jsonl")
+	r, err := OpenEPCRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := EPCRecord{EPC: "30aa", ItemCode: "ITEM-1", Qty: 1.25, Unit: "kg", Station: "line-1"}
+	if err := r.Register(rec); err != nil {
+		t.Fatal(err)
+	}
+	err = r.Register(EPCRecord{EPC: "30AA", ItemCode: "ITEM-2", Station: "line-2"})
+	var dup *DuplicateEPCError
+	if !errors.As(err, &dup) || !errors.Is(err, ErrEPCDuplicate) {
+		t.Fatalf("duplicate expected, got %v", err)
+	}
+	if dup.Existing.ItemCode != "ITEM-1" || dup.Existing.Station != "line-1" {
+		t.Fatalf("existing=%+v", dup.Existing)
+	}
+
+	// Boshqa process (yoki restart) xuddi shu faylni ochsa ham ko'radi.
+	r2, err := OpenEPCRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := r2.Lookup(" 30aa ")
+	if err != nil || !ok {
+		t.Fatalf("lookup ok=%v err=%v", ok, err)
+	}
+	if got.Qty != 1.25 || got.Unit != "kg" || got.IssuedAt == "" {
+		t.Fatalf("record=%+v", got)
+	}
+
+	// r2 yozgan EPC r da ham dublikat bo'ladi (catch-up).
+	if err := r2.Register(EPCRecord{EPC: "30BB"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(EPCRecord{EPC: "30BB"}); !errors.Is(err, ErrEPCDuplicate) {
+		t.Fatalf("cross-instance duplicate expected, got %v", err)
+	}
+	if _, ok, _ := r.Lookup("30CC"); ok {
+		t.Fatal("unknown epc should not be found")
+	}
+}
+
+type fixedScheme struct {
+	epcs []string
+}
+
+func (s *fixedScheme) Name() string { return "fixed" }
+
+func (s *fixedScheme) Next(time.Time) (string, error) {
+	v := s.epcs[0]
+	s.epcs = s.epcs[1:]
+	return v, nil
+}
+
+func TestEPCRegistry_IssueRegeneratesDuplicates(t *testing.T) {
+	r, err := OpenEPCRegistry(filepath.Join(t.TempDir(), "epc.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(EPCRecord{EPC: "30A1"}); err != nil {
+		t.Fatal(err)
+	}
+	s := &fixedScheme{epcs: []string{"30A1", "30A2"}}
+	epc, err := r.Issue(s, EPCRecord{EPC: "30A1", ItemCode: "X"})
+	if err != nil || epc != "30A2" {
+		t.Fatalf("epc=%s err=%v", epc, err)
+	}
+	got, _, _ := r.Lookup("30A2")
+	if got.Scheme != "fixed" || got.ItemCode != "X" {
+		t.Fatalf("record=%+v", got)
+	}
+
+	same := &fixedScheme{epcs: []string{"30A1", "30A1", "30A1", "30A1", "30A1", "30A1", "30A1", "30A1"}}
+	if _, err := r.Issue(same, EPCRecord{}); !errors.Is(err, ErrEPCDuplicate) {
+		t.Fatalf("exhausted attempts should wrap duplicate, got %v", err)
+	}
+}
+
+func TestEPCRegistry_SkipsTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "epc.jsonl")
+	if err := os.WriteFile(path, []byte("{\"epc\":\"30A1\"}\nnot-json\n{\"epc\":\"30A"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenEPCRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 1 {
+		t.Fatalf("len=%d", r.Len())
+	}
+	if err := r.Register(EPCRecord{EPC: "30B2"}); err != nil {
+		t.Fatal(err)
+	}
+	r2, err := OpenEPCRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := r2.Lookup("30B2"); !ok || r2.Len() != 2 {
+		t.Fatalf("record after torn tail lost: len=%d", r2.Len())
+	}
+}
+
+func TestEPCRegistry_SettleKeepsIssueRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "epc.jsonl")
+	r, err := OpenEPCRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(EPCRecord{EPC: "30AA", ItemCode: "ITEM-1", Qty: 2, Station: "line-1", Status: EPCStatusReserved}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Settle("30aa", EPCStatusFailed, "printer busy", time.Unix(1_700_000_000, 0)); err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+	if err := r.Settle("30CC", EPCStatusEncoded, "", time.Time{}); err == nil {
+		t.Fatal("settle of unknown epc should fail")
+	}
+
+	// Qayta ochilganda ham birinchi yozuv + oxirgi natija; failed EPC qayta berilmaydi.
+	r2, err := OpenEPCRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := r2.Lookup("30AA")
+	if err != nil || !ok {
+		t.Fatalf("lookup ok=%v err=%v", ok, err)
+	}
+	if got.ItemCode != "ITEM-1" || got.Qty != 2 || got.Status != EPCStatusFailed || got.Error != "printer busy" || got.SettledAt == "" {
+		t.Fatalf("record=%+v", got)
+	}
+	if err := r2.Register(EPCRecord{EPC: "30AA"}); !errors.Is(err, ErrEPCDuplicate) {
+		t.Fatalf("failed epc must stay reserved, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// EPCSchemeTime default: vaqtga asoslangan `30...` 24 hex ID (faqat shu tizim ichida ma'noli).
+	EPCSchemeTime = "time"
+	// EPCSchemeSGTIN96 GS1 SGTIN-96: standart GS1 reader'lar GTIN + serial'ni o'qiy oladi.
+	EPCSchemeSGTIN96 = "sgtin96"
+)
+
+// EPCScheme stable trigger uchun yangi EPC yaratadi (24 hex, katta harf).
+type EPCScheme interface {
+	Name() string
+	Next(at time.Time) (string, error)
+}
+
+// TimeEPCScheme eski (default) sxema: 30 + unix ns + time-atom/sequence/salt aralashmasi.
+type TimeEPCScheme struct {
+	mu     sync.Mutex
+	lastNS int64
+	seq    uint32
+	salt   uint32
+}
+
+func NewTimeEPCScheme() *TimeEPCScheme {
+	return &TimeEPCScheme{salt: newEPCSalt()}
+}
+
+// NewTimeEPCSchemeWithSalt salt'i tasodifiy emas: bir xil vaqtlar ketma-ketligi har doim bir xil
+// EPC'larni beradi. Faqat replay/test uchun - real stansiyalarda salt ular orasidagi to'qnashuvni oldini oladi.
+func NewTimeEPCSchemeWithSalt(salt uint32) *TimeEPCScheme {
+	return &TimeEPCScheme{salt: salt | 1}
+}
+
+func (s *TimeEPCScheme) Name() string { return EPCSchemeTime }
+
+func (s *TimeEPCScheme) Next(at time.Time) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ns := at.UnixNano()
+	if ns != s.lastNS {
+		s.lastNS = ns
+		s.seq = 0
+	} else {
+		s.seq++
+	}
+	return formatEPC24(ns, s.seq, s.salt), nil
+}
+
+// SGTINScheme har trigger uchun joriy GTIN va allocator'dan olingan serial bilan
+// SGTIN-96 EPC yaratadi. GTIN batch item'i almashganda SetGTIN bilan beriladi.
+type SGTINScheme struct {
+	companyPrefix string
+	filter        uint8
+	serials       SerialAllocator
+
+	mu   sync.Mutex
+	gtin string
+}
+
+// NewSGTINScheme companyPrefix - GS1 kompaniya prefiksi (6-12 raqam),
+// filter - SGTIN filter qiymati (0-7, masalan 1 = POS item, 3 = yuk birligi).
+func NewSGTINScheme(companyPrefix string, filter uint8, serials SerialAllocator) (*SGTINScheme, error) {
+	if _, err := sgtinPartitionForPrefix(len(companyPrefix)); err != nil {
+		return nil, err
+	}
+	if !isDigits(companyPrefix) {
+		return nil, fmt.Errorf("gs1 company prefix faqat raqam bo'lishi kerak: %q", companyPrefix)
+	}
+	if filter > 7 {
+		return nil, fmt.Errorf("sgtin filter 0..7 bo'lishi kerak: %d", filter)
+	}
+	if serials == nil {
+		return nil, fmt.Errorf("sgtin serial allocator kerak")
+	}
+	return &SGTINScheme{companyPrefix: companyPrefix, filter: filter, serials: serials}, nil
+}
+
+func (s *SGTINScheme) Name() string { return EPCSchemeSGTIN96 }
+
+// SetGTIN batch item'ining GTIN'ini o'rnatadi (bo'sh = item GTIN'siz, Next xato beradi).
+// GTIN kompaniya prefiksi bilan boshlanishi (indikator raqamidan keyin) kerak.
+// Xato bo'lsa oldingi GTIN ham tozalanadi: yangi item'ga eski item GTIN'i yozilmasin.
+func (s *SGTINScheme) SetGTIN(gtin string) error {
+	norm, err := s.checkGTIN(gtin)
+	s.mu.Lock()
+	s.gtin = norm
+	s.mu.Unlock()
+	return err
+}
+
+func (s *SGTINScheme) checkGTIN(gtin string) (string, error) {
+	if gtin == "" {
+		return "", nil
+	}
+	norm, err := NormalizeGTIN(gtin)
+	if err != nil {
+		return "", err
+	}
+	if norm[1:1+len(s.companyPrefix)] != s.companyPrefix {
+		return "", fmt.Errorf("gtin %s kompaniya prefiksi %s ga tegishli emas", gtin, s.companyPrefix)
+	}
+	return norm, nil
+}
+
+// GTIN joriy GTIN-14 (o'rnatilmagan bo'lsa bo'sh).
+func (s *SGTINScheme) GTIN() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gtin
+}
+
+func (s *SGTINScheme) Next(time.Time) (string, error) {
+	gtin := s.GTIN()
+	if gtin == "" {
+		return "", fmt.Errorf("sgtin: batch item uchun GTIN yo'q")
+	}
+	serial, err := s.serials.Next()
+	if err != nil {
+		return "", err
+	}
+	tag, err := SGTINFromGTIN(gtin, len(s.companyPrefix), s.filter, serial)
+	if err != nil {
+		return "", err
+	}
+	return tag.Encode()
+}
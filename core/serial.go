@@ -0,0 +1,110 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SerialAllocator SGTIN serial raqamlarini beradi. Har chaqiruv yangi (takrorlanmas) qiymat.
+type SerialAllocator interface {
+	Next() (uint64, error)
+}
+
+// MemorySerialAllocator process ichidagi hisoblagich (testlar va bir martalik ishlar uchun):
+// restart'dan keyin yana start'dan boshlanadi.
+type MemorySerialAllocator struct {
+	mu   sync.Mutex
+	next uint64
+}
+
+func NewMemorySerialAllocator(start uint64) *MemorySerialAllocator {
+	return &MemorySerialAllocator{next: start}
+}
+
+func (a *MemorySerialAllocator) Next() (uint64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.next > sgtin96SerialMax {
+		return 0, fmt.Errorf("sgtin serial tugadi (max %d)", uint64(sgtin96SerialMax))
+	}
+	v := a.next
+	a.next++
+	return v, nil
+}
+
+// FileSerialAllocator keyingi serial'ni faylda saqlaydi: qiymat qaytarilishidan oldin
+// `.tmp` + fsync + rename bilan yoziladi, shuning uchun restart'dan keyin serial takrorlanmaydi
+// (eng yomoni - bitta serial ishlatilmay qoladi).
+type FileSerialAllocator struct {
+	mu   sync.Mutex
+	path string
+	next uint64
+}
+
+// NewFileSerialAllocator fayldan joriy qiymatni o'qiydi; fayl yo'q bo'lsa start'dan boshlaydi.
+func NewFileSerialAllocator(path string, start uint64) (*FileSerialAllocator, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("serial fayl yo'li bo'sh")
+	}
+	a := &FileSerialAllocator{path: path, next: start}
+	b, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("read serial file: %w", err)
+	default:
+		v, perr := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		if perr != nil {
+			return nil, fmt.Errorf("serial fayl noto'g'ri (%s): %w", path, perr)
+		}
+		if v > a.next {
+			a.next = v
+		}
+	}
+	return a, nil
+}
+
+func (a *FileSerialAllocator) Next() (uint64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.next > sgtin96SerialMax {
+		return 0, fmt.Errorf("sgtin serial tugadi (max %d)", uint64(sgtin96SerialMax))
+	}
+	v := a.next
+	if err := a.writeLocked(v + 1); err != nil {
+		return 0, err
+	}
+	a.next = v + 1
+	return v, nil
+}
+
+func (a *FileSerialAllocator) writeLocked(next uint64) error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return fmt.Errorf("mkdir serial dir: %w", err)
+	}
+	tmp := a.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("write serial file: %w", err)
+	}
+	if _, err := f.WriteString(strconv.FormatUint(next, 10) + "\n"); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write serial file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("sync serial file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write serial file: %w", err)
+	}
+	if err := os.Rename(tmp, a.path); err != nil {
+		return fmt.Errorf("rename serial file: %w", err)
+	}
+	return nil
+}
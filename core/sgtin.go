@@ -0,0 +1,217 @@
+package core
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// GS1 EPC Tag Data Standard, SGTIN-96:
+//
+//	header(8)=0x30 | filter(3) | partition(3) | company prefix(M) | item reference(N) | serial(38)
+//
+// M+N=44; partition kompaniya prefiksi uzunligini belgilaydi.
+const (
+	sgtin96Header    = 0x30
+	sgtin96SerialMax = 1<<38 - 1
+)
+
+type sgtinPartition struct {
+	companyBits   int
+	companyDigits int
+	itemBits      int
+	itemDigits    int
+}
+
+var sgtinPartitions = [7]sgtinPartition{
+	{40, 12, 4, 1},
+	{37, 11, 7, 2},
+	{34, 10, 10, 3},
+	{30, 9, 14, 4},
+	{27, 8, 17, 5},
+	{24, 7, 20, 6},
+	{20, 6, 24, 7},
+}
+
+// SGTIN96 dekodlangan SGTIN-96 maydonlari.
+// ItemReference indikator raqami bilan birga (GTIN-14 ning 1-raqami + item ref).
+type SGTIN96 struct {
+	Filter        uint8
+	Partition     uint8
+	CompanyPrefix string
+	ItemReference string
+	Serial        uint64
+}
+
+// SGTINFromGTIN GTIN-8/12/13/14 va kompaniya prefiksi uzunligidan SGTIN yasaydi.
+// GTIN nazorat raqami tekshiriladi.
+func SGTINFromGTIN(gtin string, companyPrefixLen int, filter uint8, serial uint64) (SGTIN96, error) {
+	norm, err := NormalizeGTIN(gtin)
+	if err != nil {
+		return SGTIN96{}, err
+	}
+	partition, err := sgtinPartitionForPrefix(companyPrefixLen)
+	if err != nil {
+		return SGTIN96{}, err
+	}
+	s := SGTIN96{
+		Filter:        filter,
+		Partition:     partition,
+		CompanyPrefix: norm[1 : 1+companyPrefixLen],
+		ItemReference: norm[:1] + norm[1+companyPrefixLen:13],
+		Serial:        serial,
+	}
+	return s, s.validate()
+}
+
+func (s SGTIN96) validate() error {
+	if s.Filter > 7 {
+		return fmt.Errorf("sgtin filter 0..7 bo'lishi kerak: %d", s.Filter)
+	}
+	if int(s.Partition) >= len(sgtinPartitions) {
+		return fmt.Errorf("sgtin partition 0..6 bo'lishi kerak: %d", s.Partition)
+	}
+	p := sgtinPartitions[s.Partition]
+	if len(s.CompanyPrefix) != p.companyDigits || !isDigits(s.CompanyPrefix) {
+		return fmt.Errorf("sgtin company prefix %d raqam bo'lishi kerak: %q", p.companyDigits, s.CompanyPrefix)
+	}
+	if len(s.ItemReference) != p.itemDigits || !isDigits(s.ItemReference) {
+		return fmt.Errorf("sgtin item reference %d raqam bo'lishi kerak: %q", p.itemDigits, s.ItemReference)
+	}
+	if s.Serial > sgtin96SerialMax {
+		return fmt.Errorf("sgtin serial 38 bitdan katta: %d", s.Serial)
+	}
+	return nil
+}
+
+// Encode 24 xonali katta harfli hex EPC qaytaradi.
+func (s SGTIN96) Encode() (string, error) {
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+	p := sgtinPartitions[s.Partition]
+	company, _ := strconv.ParseUint(s.CompanyPrefix, 10, 64)
+	item, _ := strconv.ParseUint(s.ItemReference, 10, 64)
+
+	v := new(big.Int).SetUint64(sgtin96Header)
+	push := func(val uint64, width int) {
+		v.Lsh(v, uint(width))
+		v.Or(v, new(big.Int).SetUint64(val))
+	}
+	push(uint64(s.Filter), 3)
+	push(uint64(s.Partition), 3)
+	push(company, p.companyBits)
+	push(item, p.itemBits)
+	push(s.Serial, 38)
+
+	b := make([]byte, 12)
+	v.FillBytes(b)
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+// DecodeSGTIN96 24 hex EPC ni SGTIN-96 sifatida o'qiydi.
+func DecodeSGTIN96(epc string) (SGTIN96, error) {
+	epc = strings.TrimSpace(epc)
+	if len(epc) != 24 {
+		return SGTIN96{}, fmt.Errorf("sgtin-96 uchun 24 hex kerak: %q", epc)
+	}
+	b, err := hex.DecodeString(epc)
+	if err != nil {
+		return SGTIN96{}, fmt.Errorf("epc hex emas: %q", epc)
+	}
+	if b[0] != sgtin96Header {
+		return SGTIN96{}, fmt.Errorf("sgtin-96 header 30 emas: %02X", b[0])
+	}
+	v := new(big.Int).SetBytes(b)
+	pop := func(offset, width int) uint64 {
+		// offset - 96 bitli qiymatning eng katta bitidan hisoblangan pozitsiya.
+		x := new(big.Int).Rsh(v, uint(96-offset-width))
+		x.And(x, new(big.Int).SetUint64(1<<width-1))
+		return x.Uint64()
+	}
+	s := SGTIN96{
+		Filter:    uint8(pop(8, 3)),
+		Partition: uint8(pop(11, 3)),
+	}
+	if int(s.Partition) >= len(sgtinPartitions) {
+		return SGTIN96{}, fmt.Errorf("sgtin partition noto'g'ri: %d", s.Partition)
+	}
+	p := sgtinPartitions[s.Partition]
+	company := pop(14, p.companyBits)
+	item := pop(14+p.companyBits, p.itemBits)
+	s.Serial = pop(58, 38)
+	s.CompanyPrefix = fmt.Sprintf("%0*d", p.companyDigits, company)
+	s.ItemReference = fmt.Sprintf("%0*d", p.itemDigits, item)
+	if len(s.CompanyPrefix) != p.companyDigits || len(s.ItemReference) != p.itemDigits {
+		return SGTIN96{}, fmt.Errorf("sgtin maydonlari partition %d ga sig'maydi", s.Partition)
+	}
+	return s, nil
+}
+
+// GTIN GTIN-14 (nazorat raqami bilan).
+func (s SGTIN96) GTIN() string {
+	if s.ItemReference == "" {
+		return ""
+	}
+	body := s.ItemReference[:1] + s.CompanyPrefix + s.ItemReference[1:]
+	return body + strconv.Itoa(gtinCheckDigit(body))
+}
+
+// URI EPC pure identity URI: urn:epc:id:sgtin:CompanyPrefix.ItemReference.Serial
+func (s SGTIN96) URI() string {
+	return fmt.Sprintf("urn:epc:id:sgtin:%s.%s.%d", s.CompanyPrefix, s.ItemReference, s.Serial)
+}
+
+func sgtinPartitionForPrefix(companyPrefixLen int) (uint8, error) {
+	for i, p := range sgtinPartitions {
+		if p.companyDigits == companyPrefixLen {
+			return uint8(i), nil
+		}
+	}
+	return 0, fmt.Errorf("gs1 company prefix 6..12 raqam bo'lishi kerak: %d", companyPrefixLen)
+}
+
+// NormalizeGTIN GTIN-8/12/13/14 ni nazorat raqami tekshirilgan GTIN-14 ga keltiradi.
+func NormalizeGTIN(gtin string) (string, error) {
+	gtin = strings.TrimSpace(gtin)
+	switch len(gtin) {
+	case 8, 12, 13, 14:
+	default:
+		return "", fmt.Errorf("gtin 8/12/13/14 raqam bo'lishi kerak: %q", gtin)
+	}
+	if !isDigits(gtin) {
+		return "", fmt.Errorf("gtin faqat raqam bo'lishi kerak: %q", gtin)
+	}
+	gtin = strings.Repeat("0", 14-len(gtin)) + gtin
+	if want := gtinCheckDigit(gtin[:13]); int(gtin[13]-'0') != want {
+		return "", fmt.Errorf("gtin nazorat raqami noto'g'ri: %s (kutilgan %d)", gtin, want)
+	}
+	return gtin, nil
+}
+
+// gtinCheckDigit GS1 mod-10: o'ngdan boshlab 3,1,3,1... vaznlar.
+func gtinCheckDigit(body string) int {
+	sum := 0
+	for i := len(body) - 1; i >= 0; i-- {
+		d := int(body[i] - '0')
+		if (len(body)-1-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
+
+func isDigits(v string) bool {
+	if v == "" {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		if v[i] < '0' || v[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
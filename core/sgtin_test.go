@@ -0,0 +1,132 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSGTIN96_KnownVector(t *testing.T) {
+	// GS1 TDS misoli: urn:epc:id:sgtin:0614141.812345.6789, filter 3.
+	tag := SGTIN96{Filter: 3, Partition: 5, CompanyPrefix: "0614141", ItemReference: "812345", Serial: 6789}
+	epc, err := tag.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epc != "3074257BF7194E4000001A85" {
+		t.Fatalf("epc=%s", epc)
+	}
+	got, err := DecodeSGTIN96(epc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != tag {
+		t.Fatalf("decode=%+v want %+v", got, tag)
+	}
+	if got.URI() != "urn:epc:id:sgtin:0614141.812345.6789" {
+		t.Fatalf("uri=%s", got.URI())
+	}
+	if got.GTIN() != "80614141123458" {
+		t.Fatalf("gtin=%s", got.GTIN())
+	}
+}
+
+func TestSGTINFromGTIN_RoundTripAllPartitions(t *testing.T) {
+	gtin := "00614141123452"
+	for prefixLen := 6; prefixLen <= 12; prefixLen++ {
+		tag, err := SGTINFromGTIN(gtin, prefixLen, 1, sgtin96SerialMax)
+		if err != nil {
+			t.Fatalf("prefix %d: %v", prefixLen, err)
+		}
+		epc, err := tag.Encode()
+		if err != nil {
+			t.Fatalf("prefix %d: %v", prefixLen, err)
+		}
+		got, err := DecodeSGTIN96(epc)
+		if err != nil {
+			t.Fatalf("prefix %d: %v", prefixLen, err)
+		}
+		if got != tag || got.GTIN() != gtin {
+			t.Fatalf("prefix %d: got=%+v gtin=%s", prefixLen, got, got.GTIN())
+		}
+	}
+}
+
+func TestSGTINFromGTIN_Validation(t *testing.T) {
+	if _, err := SGTINFromGTIN("0614141123453", 7, 1, 1); err == nil {
+		t.Fatal("bad check digit should fail")
+	}
+	if _, err := SGTINFromGTIN("614141123452", 7, 1, 1); err != nil {
+		t.Fatalf("GTIN-12 should be padded: %v", err)
+	}
+	if _, err := SGTINFromGTIN("00614141123452", 5, 1, 1); err == nil {
+		t.Fatal("company prefix len 5 should fail")
+	}
+	if _, err := SGTINFromGTIN("00614141123452", 7, 1, sgtin96SerialMax+1); err == nil {
+		t.Fatal("serial > 38 bit should fail")
+	}
+	if _, err := DecodeSGTIN96("30000000000000000000ZZZZ"); err == nil {
+		t.Fatal("non-hex should fail")
+	}
+	if _, err := DecodeSGTIN96("E28011700000020000000001"); err == nil {
+		t.Fatal("non-SGTIN header should fail")
+	}
+}
+
+func TestSGTINScheme_WithDetector(t *testing.T) {
+	scheme, err := NewSGTINScheme("0614141", 1, NewMemorySerialAllocator(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultStableEPCConfig()
+	cfg.Scheme = scheme
+	d := NewStableEPCDetector(cfg)
+	t0 := time.Unix(1_700_000_000, 0)
+
+	// GTIN yo'q: trigger bo'lmaydi, xato saqlanadi.
+	w := 1.5
+	d.Observe(&w, t0)
+	if _, ok := d.Observe(&w, t0.Add(1100*time.Millisecond)); ok || d.Err() == nil {
+		t.Fatalf("no GTIN -> no trigger, err expected (err=%v)", d.Err())
+	}
+
+	if err := scheme.SetGTIN("00712345000019"); err == nil {
+		t.Fatal("GTIN of another company prefix should fail")
+	}
+	if err := scheme.SetGTIN("00614141999996"); err != nil {
+		t.Fatal(err)
+	}
+	w2 := 2.5
+	d.Observe(&w2, t0.Add(1200*time.Millisecond))
+	epc, ok := d.Observe(&w2, t0.Add(2300*time.Millisecond))
+	if !ok || d.Err() != nil {
+		t.Fatalf("trigger expected: ok=%v err=%v", ok, d.Err())
+	}
+	tag, err := DecodeSGTIN96(epc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.GTIN() != "00614141999996" || tag.Serial != 100 || tag.Filter != 1 {
+		t.Fatalf("tag=%+v gtin=%s", tag, tag.GTIN())
+	}
+}
+
+func TestFileSerialAllocator_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serial")
+	a, err := NewFileSerialAllocator(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for want := uint64(1); want <= 3; want++ {
+		if got, err := a.Next(); err != nil || got != want {
+			t.Fatalf("next=%d err=%v want %d", got, err, want)
+		}
+	}
+	b, err := NewFileSerialAllocator(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := b.Next(); got != 4 {
+		t.Fatalf("after restart next=%d want 4", got)
+	}
+}
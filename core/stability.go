@@ -0,0 +1,295 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// StabilityEpsilon default: vazn StableFor davomida Epsilon ichida qolsa barqaror.
+	StabilityEpsilon = "epsilon"
+	// StabilityStdDev oxirgi Window ichidagi o'qishlarning standart og'ishi MaxStdDev dan kichik.
+	StabilityStdDev = "stddev"
+	// StabilityMedian median filtrdan o'tgan vazn StableFor davomida Epsilon ichida qoladi.
+	StabilityMedian = "median"
+	// StabilityScaleFlag tarozi ketma-ket STFrames marta `ST` (stable) deb yuborgan.
+	StabilityScaleFlag = "st"
+)
+
+// StabilitySample detector'ga kelgan bitta o'qish. Stable tarozi frame'idagi ST/US
+// belgisi (nil = tarozi yubormagan).
+type StabilitySample struct {
+	Weight float64
+	Stable *bool
+	At     time.Time
+}
+
+// StabilityStrategy vazn qachon "barqaror" ekanini hal qiladi. StableEPCDetector
+// sikl (printed/rearm) mantiqini o'zi boshqaradi: strategiya faqat barqarorlikni aniqlaydi.
+type StabilityStrategy interface {
+	Name() string
+	// Observe sample'ni qo'shadi va filtrlangan joriy vaznni (level) qaytaradi;
+	// stable=true bo'lsa level chop etiladi. Rearm ham level bo'yicha tekshiriladi,
+	// shuning uchun filtr ushlagan shovqin yangi siklni boshlamaydi.
+	Observe(s StabilitySample) (level float64, stable bool)
+	// Reset yig'ilgan oynani tozalaydi (vazn yo'qolganda va rearm'da).
+	Reset()
+}
+
+// StabilityOptions scale flag'laridan strategiya yasash uchun parametrlar.
+// Nol qiymatlar default bilan almashtiriladi.
+type StabilityOptions struct {
+	StableFor  time.Duration
+	Epsilon    float64
+	Window     time.Duration
+	MaxStdDev  float64
+	MinSamples int
+	MedianSize int
+	STFrames   int
+}
+
+func DefaultStabilityOptions() StabilityOptions {
+	return StabilityOptions{
+		StableFor:  1 * time.Second,
+		Epsilon:    0.005,
+		Window:     1 * time.Second,
+		MaxStdDev:  0.005,
+		MinSamples: 5,
+		MedianSize: 5,
+		STFrames:   3,
+	}
+}
+
+func (o StabilityOptions) withDefaults() StabilityOptions {
+	def := DefaultStabilityOptions()
+	if o.StableFor <= 0 {
+		o.StableFor = def.StableFor
+	}
+	if o.Epsilon <= 0 {
+		o.Epsilon = def.Epsilon
+	}
+	if o.Window <= 0 {
+		o.Window = def.Window
+	}
+	if o.MaxStdDev <= 0 {
+		o.MaxStdDev = def.MaxStdDev
+	}
+	if o.MinSamples <= 0 {
+		o.MinSamples = def.MinSamples
+	}
+	if o.MedianSize <= 0 {
+		o.MedianSize = def.MedianSize
+	}
+	if o.STFrames <= 0 {
+		o.STFrames = def.STFrames
+	}
+	return o
+}
+
+// NewStabilityStrategy nom bo'yicha strategiya yasaydi (bo'sh nom = epsilon).
+func NewStabilityStrategy(name string, opts StabilityOptions) (StabilityStrategy, error) {
+	opts = opts.withDefaults()
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", StabilityEpsilon:
+		return NewEpsilonStability(opts.StableFor, opts.Epsilon), nil
+	case StabilityStdDev:
+		return NewStdDevStability(opts.Window, opts.MaxStdDev, opts.MinSamples), nil
+	case StabilityMedian:
+		return NewMedianStability(opts.MedianSize, opts.StableFor, opts.Epsilon), nil
+	case StabilityScaleFlag:
+		return NewScaleFlagStability(opts.STFrames, opts.Epsilon), nil
+	default:
+		return nil, fmt.Errorf("stability strategiya noma'lum: %q (epsilon, stddev, median yoki st)", name)
+	}
+}
+
+// EpsilonStability eski (default) qoida: vazn StableFor davomida Epsilon ichida.
+type EpsilonStability struct {
+	stableFor time.Duration
+	epsilon   float64
+
+	active    bool
+	candidate float64
+	since     time.Time
+}
+
+func NewEpsilonStability(stableFor time.Duration, epsilon float64) *EpsilonStability {
+	return &EpsilonStability{stableFor: stableFor, epsilon: epsilon}
+}
+
+func (e *EpsilonStability) Name() string { return StabilityEpsilon }
+
+func (e *EpsilonStability) Observe(s StabilitySample) (float64, bool) {
+	return e.observe(s.Weight, s.At)
+}
+
+func (e *EpsilonStability) observe(w float64, at time.Time) (float64, bool) {
+	if !e.active || math.Abs(w-e.candidate) > e.epsilon {
+		e.active = true
+		e.candidate = w
+		e.since = at
+		return w, false
+	}
+	return w, at.Sub(e.since) >= e.stableFor
+}
+
+func (e *EpsilonStability) Progress(at time.Time) SettleProgress {
+	if !e.active {
+		return durationProgress(0, e.stableFor)
+	}
+	return durationProgress(at.Sub(e.since), e.stableFor)
+}
+
+func (e *EpsilonStability) Reset() {
+	e.active = false
+	e.candidate = 0
+	e.since = time.Time{}
+}
+
+// StdDevStability oxirgi Window ichidagi o'qishlar standart og'ishi MaxStdDev dan
+// oshmasa barqaror; chop etiladigan vazn - oyna o'rtachasi. Tebranadigan konveyer
+// uchun: alohida sakrashlar epsilon'dan chiqsa ham o'rtacha tinch bo'lsa trigger bo'ladi.
+type StdDevStability struct {
+	window     time.Duration
+	maxStdDev  float64
+	minSamples int
+
+	samples []StabilitySample
+}
+
+func NewStdDevStability(window time.Duration, maxStdDev float64, minSamples int) *StdDevStability {
+	if minSamples < 2 {
+		minSamples = 2
+	}
+	return &StdDevStability{window: window, maxStdDev: maxStdDev, minSamples: minSamples}
+}
+
+func (s *StdDevStability) Name() string { return StabilityStdDev }
+
+func (s *StdDevStability) Observe(in StabilitySample) (float64, bool) {
+	s.samples = append(s.samples, in)
+	// Oyna to'liq bo'lishi uchun eng eski sample cutoff'dan oldin/ustida qolishi kerak.
+	cutoff := in.At.Add(-s.window)
+	drop := 0
+	for drop+1 < len(s.samples) && !s.samples[drop+1].At.After(cutoff) {
+		drop++
+	}
+	s.samples = s.samples[drop:]
+
+	var sum float64
+	for _, v := range s.samples {
+		sum += v.Weight
+	}
+	mean := sum / float64(len(s.samples))
+	if len(s.samples) < s.minSamples || s.samples[0].At.After(cutoff) {
+		return mean, false
+	}
+	var sq float64
+	for _, v := range s.samples {
+		sq += (v.Weight - mean) * (v.Weight - mean)
+	}
+	return mean, math.Sqrt(sq/float64(len(s.samples))) <= s.maxStdDev
+}
+
+// Progress oyna qancha to'lgani (standart og'ish sharti alohida).
+func (s *StdDevStability) Progress(at time.Time) SettleProgress {
+	if len(s.samples) == 0 {
+		return durationProgress(0, s.window)
+	}
+	return durationProgress(at.Sub(s.samples[0].At), s.window)
+}
+
+func (s *StdDevStability) Reset() { s.samples = s.samples[:0] }
+
+// MedianStability oxirgi Size ta o'qish medianasini epsilon qoidasiga beradi:
+// yakka-yakka sakrashlar (impuls shovqini) kandidatni qayta boshlamaydi.
+type MedianStability struct {
+	size  int
+	inner EpsilonStability
+
+	window []float64
+}
+
+func NewMedianStability(size int, stableFor time.Duration, epsilon float64) *MedianStability {
+	if size < 1 {
+		size = 1
+	}
+	return &MedianStability{size: size, inner: EpsilonStability{stableFor: stableFor, epsilon: epsilon}}
+}
+
+func (m *MedianStability) Name() string { return StabilityMedian }
+
+func (m *MedianStability) Observe(s StabilitySample) (float64, bool) {
+	m.window = append(m.window, s.Weight)
+	if len(m.window) > m.size {
+		m.window = m.window[len(m.window)-m.size:]
+	}
+	sorted := append([]float64(nil), m.window...)
+	sort.Float64s(sorted)
+	med := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		med = (sorted[len(sorted)/2-1] + med) / 2
+	}
+	if len(m.window) < m.size {
+		// Oyna to'lmaguncha level bor, lekin barqarorlik hisoblanmaydi.
+		return med, false
+	}
+	return m.inner.observe(med, s.At)
+}
+
+func (m *MedianStability) Progress(at time.Time) SettleProgress {
+	if len(m.window) < m.size {
+		return durationProgress(0, m.inner.stableFor)
+	}
+	return m.inner.Progress(at)
+}
+
+func (m *MedianStability) Reset() {
+	m.window = m.window[:0]
+	m.inner.Reset()
+}
+
+// ScaleFlagStability tarozining o'z ST belgisiga ishonadi: ketma-ket frames ta `ST`
+// frame kelsa (va vazn ular orasida Epsilon ichida bo'lsa) barqaror.
+// US yoki belgisiz frame hisobni nolga tushiradi.
+type ScaleFlagStability struct {
+	frames  int
+	epsilon float64
+
+	count int
+	last  float64
+}
+
+func NewScaleFlagStability(frames int, epsilon float64) *ScaleFlagStability {
+	if frames < 1 {
+		frames = 1
+	}
+	return &ScaleFlagStability{frames: frames, epsilon: epsilon}
+}
+
+func (f *ScaleFlagStability) Name() string { return StabilityScaleFlag }
+
+func (f *ScaleFlagStability) Observe(s StabilitySample) (float64, bool) {
+	if s.Stable == nil || !*s.Stable {
+		f.count = 0
+		return s.Weight, false
+	}
+	if f.count > 0 && math.Abs(s.Weight-f.last) > f.epsilon {
+		f.count = 0
+	}
+	f.count++
+	f.last = s.Weight
+	return s.Weight, f.count >= f.frames
+}
+
+func (f *ScaleFlagStability) Progress(time.Time) SettleProgress {
+	return SettleProgress{Done: float64(f.count), Need: float64(f.frames), Unit: "frames"}
+}
+
+func (f *ScaleFlagStability) Reset() {
+	f.count = 0
+	f.last = 0
+}
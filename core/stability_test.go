@@ -0,0 +1,130 @@
+package core
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type traceFrame struct {
+	at     time.Time
+	weight *float64
+	stable *bool
+}
+
+// loadTrace testdata/*.trace: `<ms> <vazn|-> [ST|US]`, `#` izoh.
+func loadTrace(t *testing.T, name string) []traceFrame {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	t0 := time.Unix(1_700_000_000, 0)
+	var out []traceFrame
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		ms, err := strconv.Atoi(parts[0])
+		if err != nil {
+			t.Fatalf("%s: %q: %v", name, line, err)
+		}
+		fr := traceFrame{at: t0.Add(time.Duration(ms) * time.Millisecond)}
+		if parts[1] != "-" {
+			w, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				t.Fatalf("%s: %q: %v", name, line, err)
+			}
+			fr.weight = &w
+		}
+		if len(parts) > 2 {
+			st := strings.EqualFold(parts[2], "ST")
+			fr.stable = &st
+		}
+		out = append(out, fr)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func replayTrace(d *StableEPCDetector, frames []traceFrame) []float64 {
+	var printed []float64
+	for _, fr := range frames {
+		if _, ok := d.ObserveReading(fr.weight, fr.stable, fr.at); ok {
+			printed = append(printed, d.printedWeight)
+		}
+	}
+	return printed
+}
+
+// Trace'lar qo'lda yozilgan sintetik ssenariylar (haqiqiy tarozi yozuvi emas): har biri bitta
+// sharoitni (tinch stol, konveyer tebranishi, zarbalar) modellashtiradi.
+func TestStabilityStrategies_SyntheticTraces(t *testing.T) {
+	conveyor := StabilityOptions{Epsilon: 0.02, MaxStdDev: 0.015}
+	cases := []struct {
+		trace    string
+		strategy string
+		opts     StabilityOptions
+		want     []float64
+	}{
+		{"static.trace", StabilityEpsilon, StabilityOptions{}, []float64{1.250, 2.100}},
+		{"static.trace", StabilityStdDev, StabilityOptions{}, []float64{1.250, 2.100}},
+		{"static.trace", StabilityMedian, StabilityOptions{}, []float64{1.250, 2.100}},
+		{"static.trace", StabilityScaleFlag, StabilityOptions{}, []float64{1.250, 2.100}},
+
+		// Tebranish epsilon'dan katta: stddev (o'rtacha) va median (davrga yaqin oyna) topadi.
+		{"conveyor_vibration.trace", StabilityEpsilon, StabilityOptions{}, nil},
+		{"conveyor_vibration.trace", StabilityScaleFlag, StabilityOptions{}, nil},
+		{"conveyor_vibration.trace", StabilityStdDev, conveyor, []float64{3.000}},
+		{"conveyor_vibration.trace", StabilityMedian, conveyor, []float64{3.000}},
+
+		// Yakka sakrashlar: median ularni kesadi, epsilon esa har safar qaytadan boshlaydi.
+		{"impulse_spikes.trace", StabilityEpsilon, StabilityOptions{}, nil},
+		{"impulse_spikes.trace", StabilityStdDev, StabilityOptions{}, nil},
+		{"impulse_spikes.trace", StabilityScaleFlag, StabilityOptions{}, nil},
+		{"impulse_spikes.trace", StabilityMedian, StabilityOptions{}, []float64{1.800}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.trace+"/"+tc.strategy, func(t *testing.T) {
+			strategy, err := NewStabilityStrategy(tc.strategy, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := DefaultStableEPCConfig()
+			if tc.opts.Epsilon > 0 {
+				cfg.Epsilon = tc.opts.Epsilon
+			}
+			cfg.Strategy = strategy
+			got := replayTrace(NewStableEPCDetector(cfg), loadTrace(t, tc.trace))
+			if len(got) != len(tc.want) {
+				t.Fatalf("triggers=%v want %v", got, tc.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tc.want[i]) > 0.01 {
+					t.Fatalf("printed weight[%d]=%.4f want %.3f", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewStabilityStrategy_Unknown(t *testing.T) {
+	if _, err := NewStabilityStrategy("kalman", StabilityOptions{}); err == nil {
+		t.Fatal("unknown strategy should fail")
+	}
+	s, err := NewStabilityStrategy("", StabilityOptions{})
+	if err != nil || s.Name() != StabilityEpsilon {
+		t.Fatalf("default strategy=%v err=%v", s, err)
+	}
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TareSource tara qayerdan olingani.
+type TareSource string
+
+const (
+	// TareSourcePreset konteyner turi bo'yicha oldindan berilgan tara (--tare-presets).
+	TareSourcePreset TareSource = "preset"
+	// TareSourceButton operator tugmasi: joriy brutto vazn tara sifatida olinadi.
+	TareSourceButton TareSource = "button"
+	// TareSourceIndicator tarozi indikatori protokolda yuborgan tara (TR/TARE/PT).
+	TareSourceIndicator TareSource = "indicator"
+)
+
+// Tare joriy tara. Source bo'sh bo'lsa tara yo'q (net = brutto).
+type Tare struct {
+	Value     float64
+	Source    TareSource
+	Container string
+}
+
+// Active tara o'rnatilgan.
+func (t Tare) Active() bool { return t.Source != "" }
+
+// String TUI uchun qisqa ko'rinish: `1.200 (preset crate)`.
+func (t Tare) String() string {
+	if !t.Active() {
+		return "-"
+	}
+	s := fmt.Sprintf("%.3f (%s", t.Value, t.Source)
+	if t.Container != "" {
+		s += " " + t.Container
+	}
+	return s + ")"
+}
+
+// WeightBreakdown bitta o'qishning brutto/tara/netto ko'rinishi.
+type WeightBreakdown struct {
+	Gross float64
+	Tare  Tare
+	Net   float64
+}
+
+// Apply brutto vazndan tarani ayiradi.
+func (t Tare) Apply(gross float64) WeightBreakdown {
+	out := WeightBreakdown{Gross: gross, Net: gross}
+	if t.Active() {
+		out.Tare = t
+		out.Net = gross - t.Value
+	}
+	return out
+}
+
+// TareRegister stansiyadagi tara holati: konteyner preset'lari va operator/indikator
+// tarasi. Indikator tarasi (o'qishda bo'lsa) qo'lda berilganidan ustun turadi:
+// tarozi uni allaqachon ayirgan bo'lishi mumkin.
+type TareRegister struct {
+	mu      sync.Mutex
+	presets map[string]float64
+	manual  Tare
+}
+
+// NewTareRegister preset'larni tekshirib register yasaydi (preset'lar ixtiyoriy).
+func NewTareRegister(presets map[string]float64) (*TareRegister, error) {
+	out := make(map[string]float64, len(presets))
+	for name, v := range presets {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("tare preset: konteyner nomi bo'sh")
+		}
+		if err := validateTare(v); err != nil {
+			return nil, fmt.Errorf("tare preset %s: %w", name, err)
+		}
+		out[name] = v
+	}
+	return &TareRegister{presets: out}, nil
+}
+
+// LoadTareRegister JSON fayldan preset'larni o'qiydi: {"crate": 1.2, "pallet": 22.5}.
+func LoadTareRegister(path string) (*TareRegister, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tare presets: %w", err)
+	}
+	var presets map[string]float64
+	if err := json.Unmarshal(b, &presets); err != nil {
+		return nil, fmt.Errorf("tare presets noto'g'ri (%s): %w", path, err)
+	}
+	return NewTareRegister(presets)
+}
+
+func validateTare(v float64) error {
+	if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("tara manfiy bo'lmasin: %v", v)
+	}
+	return nil
+}
+
+// Containers preset nomlari, alifbo tartibida.
+func (r *TareRegister) Containers() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, 0, len(r.presets))
+	for name := range r.presets {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SelectContainer konteyner preset'ini joriy tara qiladi; bo'sh nom tarani o'chiradi.
+func (r *TareRegister) SelectContainer(name string) (Tare, error) {
+	if r == nil {
+		return Tare{}, fmt.Errorf("tare o'chirilgan")
+	}
+	name = strings.TrimSpace(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == "" {
+		r.manual = Tare{}
+		return r.manual, nil
+	}
+	v, ok := r.presets[name]
+	if !ok {
+		return Tare{}, fmt.Errorf("tare preset topilmadi: %q", name)
+	}
+	r.manual = Tare{Value: v, Source: TareSourcePreset, Container: name}
+	return r.manual, nil
+}
+
+// NextContainer preset'larni aylantiradi: yo'q -> birinchi -> ... -> oxirgi -> yo'q.
+func (r *TareRegister) NextContainer() (Tare, error) {
+	names := r.Containers()
+	if len(names) == 0 {
+		return Tare{}, fmt.Errorf("tare preset'lar yo'q")
+	}
+	cur := r.Current()
+	next := names[0]
+	if cur.Source == TareSourcePreset {
+		i := sort.SearchStrings(names, cur.Container)
+		next = ""
+		if i+1 < len(names) {
+			next = names[i+1]
+		}
+	}
+	return r.SelectContainer(next)
+}
+
+// Capture tugma bilan tara: joriy brutto vazn tara bo'ladi. Bo'sh tarozida
+// (gross <= 0) tara o'chiriladi, indikatordagi TARE tugmasi kabi.
+func (r *TareRegister) Capture(gross float64) (Tare, error) {
+	if r == nil {
+		return Tare{}, fmt.Errorf("tare o'chirilgan")
+	}
+	if math.IsNaN(gross) || math.IsInf(gross, 0) {
+		return Tare{}, fmt.Errorf("tara noto'g'ri: %v", gross)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if gross <= 0 {
+		r.manual = Tare{}
+		return r.manual, nil
+	}
+	r.manual = Tare{Value: gross, Source: TareSourceButton}
+	return r.manual, nil
+}
+
+// Clear qo'lda berilgan tarani o'chiradi.
+func (r *TareRegister) Clear() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.manual = Tare{}
+	r.mu.Unlock()
+}
+
+// Current qo'lda berilgan (preset yoki tugma) tara.
+func (r *TareRegister) Current() Tare {
+	if r == nil {
+		return Tare{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.manual
+}
+
+// Resolve o'qish uchun amaldagi tara: indikator tarasi bo'lsa (> 0) u, aks holda Current.
+func (r *TareRegister) Resolve(indicator *float64) Tare {
+	if indicator != nil && *indicator > 0 {
+		return Tare{Value: *indicator, Source: TareSourceIndicator}
+	}
+	return r.Current()
+}
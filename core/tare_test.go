@@ -0,0 +1,73 @@
+package core
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTareRegister_PresetButtonIndicator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tare.json")
+	if err := os.WriteFile(path, []byte(`{"crate": 1.2, "pallet": 22.5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LoadTareRegister(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.Resolve(nil).Apply(10); got.Net != 10 || got.Tare.Active() {
+		t.Fatalf("no tare: %+v", got)
+	}
+
+	if _, err := r.SelectContainer("crate"); err != nil {
+		t.Fatal(err)
+	}
+	got := r.Resolve(nil).Apply(11.5)
+	if math.Abs(got.Net-10.3) > 1e-9 || got.Tare.Source != TareSourcePreset || got.Tare.Container != "crate" {
+		t.Fatalf("preset: %+v", got)
+	}
+	if _, err := r.SelectContainer("box"); err == nil {
+		t.Fatal("unknown container should fail")
+	}
+
+	// Tugma preset'ni almashtiradi, bo'sh tarozida esa tarani o'chiradi.
+	if _, err := r.Capture(0.85); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Resolve(nil); got.Source != TareSourceButton || got.Value != 0.85 {
+		t.Fatalf("button: %+v", got)
+	}
+
+	// Indikator tarasi qo'lda berilganidan ustun.
+	ind := 2.0
+	if got := r.Resolve(&ind).Apply(12); got.Net != 10 || got.Tare.Source != TareSourceIndicator {
+		t.Fatalf("indicator: %+v", got)
+	}
+
+	if _, err := r.Capture(0); err != nil || r.Current().Active() {
+		t.Fatalf("capture on empty scale should clear: %+v err=%v", r.Current(), err)
+	}
+}
+
+func TestTareRegister_NextContainer(t *testing.T) {
+	r, err := NewTareRegister(map[string]float64{"pallet": 22.5, "crate": 1.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seq []string
+	for i := 0; i < 3; i++ {
+		tr, err := r.NextContainer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seq = append(seq, tr.Container)
+	}
+	if seq[0] != "crate" || seq[1] != "pallet" || seq[2] != "" {
+		t.Fatalf("cycle=%q", seq)
+	}
+	if _, err := NewTareRegister(map[string]float64{"x": -1}); err == nil {
+		t.Fatal("negative preset should fail")
+	}
+}
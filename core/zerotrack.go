@@ -0,0 +1,274 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ZeroPolicyOff zero-tracking o'chirilgan.
+	ZeroPolicyOff = "off"
+	// ZeroPolicyWarn ogohlantirish (TUI, bridge, bot), sikllar davom etadi (default).
+	ZeroPolicyWarn = "warn"
+	// ZeroPolicyBlock alarm turganda yangi siklga EPC/draft berilmaydi, tarozi qayta nollanishi kerak.
+	ZeroPolicyBlock = "block"
+)
+
+// ZeroAlarm zero-tracking ogohlantirishi (bo'sh = hammasi joyida).
+type ZeroAlarm string
+
+const (
+	ZeroAlarmNone ZeroAlarm = ""
+	// ZeroAlarmDrift bo'sh platforma o'qishi noldan DriftLimit'dan ko'proq siljigan.
+	ZeroAlarmDrift ZeroAlarm = "drift"
+	// ZeroAlarmNoReturn tarozi ikki mahsulot orasida nolga qaytmadi.
+	ZeroAlarmNoReturn ZeroAlarm = "no_return"
+)
+
+// ZeroTrackerConfig zero-band parametrlari (vazn birligida, odatda kg).
+type ZeroTrackerConfig struct {
+	// Band |vazn| shundan kichik bo'lsa platforma bo'sh deb olinadi.
+	Band float64
+	// DriftLimit bo'sh platforma o'qishi (offset) shundan katta bo'lsa drift.
+	DriftLimit float64
+	// SettleFor bo'sh o'qish offset'ga qo'shilishidan oldin shuncha turishi kerak.
+	SettleFor time.Duration
+	// Alpha offset uchun EMA koeffitsienti (0..1]: sekin drift shu bilan kuzatiladi.
+	Alpha float64
+}
+
+func DefaultZeroTrackerConfig() ZeroTrackerConfig {
+	return ZeroTrackerConfig{
+		Band:       0.05,
+		DriftLimit: 0.005,
+		SettleFor:  700 * time.Millisecond,
+		Alpha:      0.3,
+	}
+}
+
+// NormalizeZeroPolicy bo'sh qiymatni ZeroPolicyWarn ga aylantiradi.
+func NormalizeZeroPolicy(policy string) string {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if policy == "" {
+		return ZeroPolicyWarn
+	}
+	return policy
+}
+
+// ValidateZeroPolicy faqat off, warn yoki block.
+func ValidateZeroPolicy(policy string) error {
+	switch NormalizeZeroPolicy(policy) {
+	case ZeroPolicyOff, ZeroPolicyWarn, ZeroPolicyBlock:
+		return nil
+	default:
+		return fmt.Errorf("zero policy noma'lum: %q (off, warn yoki block)", policy)
+	}
+}
+
+// ZeroStatus tracker holati. Offset - bo'sh platformaning kuzatilgan o'qishi (Known bo'lsa).
+type ZeroStatus struct {
+	Offset     float64
+	Known      bool
+	Alarm      ZeroAlarm
+	Since      time.Time
+	DriftLimit float64
+	// PrevLoad/Load NoReturn alarmi uchun: nolga qaytmasdan almashgan ikki yuk.
+	PrevLoad float64
+	Load     float64
+}
+
+// OK alarm yo'q.
+func (s ZeroStatus) OK() bool { return s.Alarm == ZeroAlarmNone }
+
+// String TUI/bot uchun: `ok +0.001`, `DRIFT +0.012 (limit 0.005)`, `NO RETURN 1.250 -> 2.400`.
+func (s ZeroStatus) String() string {
+	switch s.Alarm {
+	case ZeroAlarmDrift:
+		return fmt.Sprintf("DRIFT %+.3f (limit %.3f)", s.Offset, s.DriftLimit)
+	case ZeroAlarmNoReturn:
+		return fmt.Sprintf("NO RETURN %.3f -> %.3f", s.PrevLoad, s.Load)
+	}
+	if !s.Known {
+		return "-"
+	}
+	return fmt.Sprintf("ok %+.3f", s.Offset)
+}
+
+// ZeroTracker bo'sh platforma o'qishini vaqt bo'yicha kuzatadi: sekin drift va
+// mahsulotlar orasida nolga qaytmaslikni aniqlaydi. Alarm tarozi nolga (DriftLimit
+// ichida) qaytganda o'zi tozalanadi.
+type ZeroTracker struct {
+	mu  sync.Mutex
+	cfg ZeroTrackerConfig
+
+	status     ZeroStatus
+	drift      bool
+	noReturn   bool
+	emptySince time.Time
+	returned   bool
+	hasLoad    bool
+	lastLoad   float64
+	loadLevel  float64
+	loadSince  time.Time
+}
+
+func NewZeroTracker(cfg ZeroTrackerConfig) *ZeroTracker {
+	def := DefaultZeroTrackerConfig()
+	if cfg.Band <= 0 {
+		cfg.Band = def.Band
+	}
+	if cfg.DriftLimit <= 0 {
+		cfg.DriftLimit = def.DriftLimit
+	}
+	if cfg.SettleFor <= 0 {
+		cfg.SettleFor = def.SettleFor
+	}
+	if cfg.Alpha <= 0 || cfg.Alpha > 1 {
+		cfg.Alpha = def.Alpha
+	}
+	t := &ZeroTracker{cfg: cfg, returned: true}
+	t.status.DriftLimit = cfg.DriftLimit
+	return t
+}
+
+// Config tracker sozlamalari.
+func (t *ZeroTracker) Config() ZeroTrackerConfig {
+	if t == nil {
+		return ZeroTrackerConfig{}
+	}
+	return t.cfg
+}
+
+// Observe netto vazn va tarozi ST/US belgisini qabul qiladi. stable=nil bo'lsa
+// o'qish SettleFor davomida Band ichida tursa barqaror deb olinadi.
+func (t *ZeroTracker) Observe(weight *float64, stable *bool, at time.Time) ZeroStatus {
+	if t == nil {
+		return ZeroStatus{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if weight == nil || math.IsNaN(*weight) || math.IsInf(*weight, 0) {
+		t.emptySince = time.Time{}
+		t.loadSince = time.Time{}
+		return t.status
+	}
+	w := *weight
+
+	if math.Abs(w) <= t.cfg.Band {
+		t.loadSince = time.Time{}
+		// Bir lahzalik bo'sh platforma ham "nolga qaytdi" hisoblanadi.
+		t.returned = true
+		t.noReturn = false
+		if stable != nil && !*stable {
+			t.emptySince = time.Time{}
+			return t.update(at)
+		}
+		if t.emptySince.IsZero() {
+			t.emptySince = at
+		}
+		if at.Sub(t.emptySince) < t.cfg.SettleFor {
+			return t.update(at)
+		}
+		t.sampleZero(w)
+		return t.update(at)
+	}
+	t.emptySince = time.Time{}
+
+	if w < 0 {
+		// Band'dan pastdagi manfiy vazn: nol noto'g'ri ekani aniq.
+		if t.settled(w, stable, at) {
+			t.status.Offset, t.status.Known = w, true
+			t.drift = true
+		}
+		return t.update(at)
+	}
+
+	if !t.settled(w, stable, at) {
+		return t.update(at)
+	}
+	if t.hasLoad && !t.returned && math.Abs(w-t.lastLoad) > t.cfg.Band {
+		t.noReturn = true
+		t.status.PrevLoad = t.lastLoad
+		t.status.Load = w
+	}
+	if !t.hasLoad || t.returned || math.Abs(w-t.lastLoad) > t.cfg.Band {
+		t.lastLoad = w
+	}
+	t.hasLoad = true
+	t.returned = false
+	return t.update(at)
+}
+
+// settled yuk o'qishi barqarormi: ST belgisi yoki SettleFor davomida Band ichida turish.
+func (t *ZeroTracker) settled(w float64, stable *bool, at time.Time) bool {
+	if stable != nil {
+		return *stable
+	}
+	if t.loadSince.IsZero() || math.Abs(w-t.loadLevel) > t.cfg.Band {
+		t.loadLevel = w
+		t.loadSince = at
+		return false
+	}
+	return at.Sub(t.loadSince) >= t.cfg.SettleFor
+}
+
+// sampleZero bo'sh platforma o'qishini offset'ga qo'shadi. DriftLimit'dan katta sakrash
+// (tarozi qayta nollandi yoki birdan siljidi) EMA'siz darhol olinadi.
+func (t *ZeroTracker) sampleZero(w float64) {
+	switch {
+	case !t.status.Known, math.Abs(w-t.status.Offset) > t.cfg.DriftLimit:
+		t.status.Offset = w
+	default:
+		t.status.Offset += t.cfg.Alpha * (w - t.status.Offset)
+	}
+	t.status.Known = true
+	t.drift = math.Abs(t.status.Offset) > t.cfg.DriftLimit+1e-9
+}
+
+func (t *ZeroTracker) update(at time.Time) ZeroStatus {
+	alarm := ZeroAlarmNone
+	switch {
+	case t.drift:
+		alarm = ZeroAlarmDrift
+	case t.noReturn:
+		alarm = ZeroAlarmNoReturn
+	}
+	if alarm != t.status.Alarm {
+		t.status.Alarm = alarm
+		t.status.Since = at
+		if alarm == ZeroAlarmNone {
+			t.status.Since = time.Time{}
+		}
+	}
+	return t.status
+}
+
+// Status oxirgi holat.
+func (t *ZeroTracker) Status() ZeroStatus {
+	if t == nil {
+		return ZeroStatus{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// Reset tarozi qayta nollanganda (zero buyrug'i) holatni tozalaydi.
+func (t *ZeroTracker) Reset() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status = ZeroStatus{DriftLimit: t.cfg.DriftLimit}
+	t.drift, t.noReturn = false, false
+	t.emptySince, t.loadSince = time.Time{}, time.Time{}
+	t.returned, t.hasLoad = true, false
+	t.lastLoad, t.loadLevel = 0, 0
+}
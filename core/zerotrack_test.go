@@ -0,0 +1,97 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZeroTracker_Drift(t *testing.T) {
+	z := NewZeroTracker(ZeroTrackerConfig{Band: 0.05, DriftLimit: 0.005, SettleFor: 500 * time.Millisecond, Alpha: 0.5})
+	at := time.Unix(1_700_000_000, 0)
+	st := true
+	obs := func(w float64) ZeroStatus {
+		at = at.Add(250 * time.Millisecond)
+		return z.Observe(&w, &st, at)
+	}
+
+	for i := 0; i < 4; i++ {
+		obs(0.001)
+	}
+	if s := z.Status(); !s.OK() || !s.Known || s.String() != "ok +0.001" {
+		t.Fatalf("zero ok: %+v %s", s, s)
+	}
+
+	// Sekin drift: har bo'sh o'qish biroz yuqori.
+	var s ZeroStatus
+	for _, w := range []float64{0.003, 0.005, 0.007, 0.009, 0.011, 0.012, 0.012, 0.012} {
+		s = obs(w)
+	}
+	if s.Alarm != ZeroAlarmDrift || s.Since.IsZero() {
+		t.Fatalf("drift expected: %+v", s)
+	}
+
+	// Tarozi qayta nollandi: sakrash darhol olinadi va alarm tushadi.
+	for i := 0; i < 3; i++ {
+		s = obs(0)
+	}
+	if !s.OK() || s.Offset != 0 {
+		t.Fatalf("re-zero should clear drift: %+v", s)
+	}
+
+	// Band'dan past manfiy vazn - nol noto'g'ri.
+	if s = obs(-0.2); s.Alarm != ZeroAlarmDrift {
+		t.Fatalf("negative weight: %+v", s)
+	}
+	z.Reset()
+	if s := z.Status(); !s.OK() || s.Known {
+		t.Fatalf("reset: %+v", s)
+	}
+}
+
+func TestZeroTracker_NoReturn(t *testing.T) {
+	z := NewZeroTracker(ZeroTrackerConfig{SettleFor: 300 * time.Millisecond})
+	at := time.Unix(1_700_000_000, 0)
+	obs := func(w float64, stable *bool) ZeroStatus {
+		at = at.Add(200 * time.Millisecond)
+		return z.Observe(&w, stable, at)
+	}
+	yes := true
+
+	obs(1.25, &yes)
+	obs(1.26, &yes)
+	// Mahsulot olinmasdan ikkinchisi qo'yildi.
+	if s := obs(2.40, &yes); s.Alarm != ZeroAlarmNoReturn || s.String() != "NO RETURN 1.250 -> 2.400" {
+		t.Fatalf("no return expected: %+v %s", s, s)
+	}
+	// Bir lahzalik bo'sh platforma ham qaytish hisoblanadi.
+	if s := obs(0.0, &yes); !s.OK() {
+		t.Fatalf("return to zero should clear: %+v", s)
+	}
+	if s := obs(1.10, &yes); !s.OK() {
+		t.Fatalf("next item after zero: %+v", s)
+	}
+
+	// ST belgisi yo'q (nil): yuk SettleFor davomida tursa barqaror.
+	obs(0, nil)
+	obs(0.8, nil)
+	obs(0.8, nil)
+	obs(0.8, nil)
+	if s := obs(1.9, nil); !s.OK() {
+		t.Fatalf("unsettled load must not alarm yet: %+v", s)
+	}
+	obs(1.9, nil)
+	if s := obs(1.9, nil); s.Alarm != ZeroAlarmNoReturn {
+		t.Fatalf("settled load without return: %+v", s)
+	}
+}
+
+func TestValidateZeroPolicy(t *testing.T) {
+	for _, p := range []string{"", "off", "WARN", "block"} {
+		if err := ValidateZeroPolicy(p); err != nil {
+			t.Fatalf("%q: %v", p, err)
+		}
+	}
+	if err := ValidateZeroPolicy("strict"); err == nil {
+		t.Fatal("unknown policy should fail")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	corepkg "core"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// autoEncode stable trigger'dan label'gacha bo'lgan qismlar: detector, EPC registry,
+// check-weigh va zero-tracking. Hammasi ixtiyoriy (nil = o'chirilgan), faqat detector kerak.
+// sgtin --epc-scheme sgtin96 bo'lsa detector sxemasi: GTIN faol batch item'idan beriladi.
+type autoEncode struct {
+	detector    *corepkg.StableEPCDetector
+	sgtin       *corepkg.SGTINScheme
+	issuer      *epcIssuer
+	checker     *corepkg.CheckWeigher
+	checkPolicy string
+	zero        *zeroGuard
+}
+
+// newEPCScheme --epc-scheme: time (default) yoki sgtin96 (serial --sgtin-serial-file'da saqlanadi).
+func newEPCScheme(cfg appConfig) (corepkg.EPCScheme, error) {
+	if cfg.epcScheme != corepkg.EPCSchemeSGTIN96 {
+		return corepkg.NewTimeEPCScheme(), nil
+	}
+	serials, err := corepkg.NewFileSerialAllocator(cfg.sgtinSerialFile, 1)
+	if err != nil {
+		return nil, err
+	}
+	scheme, err := corepkg.NewSGTINScheme(strings.TrimSpace(cfg.sgtinPrefix), uint8(cfg.sgtinFilter), serials)
+	if err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// newAutoDetector --stability va unga tegishli flag'lardan auto encode detector'ini yasaydi.
+// scheme nil bo'lsa detector default (vaqt) sxemasini oladi.
+func newAutoDetector(cfg appConfig, scheme corepkg.EPCScheme) (*corepkg.StableEPCDetector, error) {
+	strategy, err := corepkg.NewStabilityStrategy(cfg.stability, cfg.stabilityOpts)
+	if err != nil {
+		return nil, err
+	}
+	dc := corepkg.DefaultStableEPCConfig()
+	dc.StableFor = cfg.stabilityOpts.StableFor
+	dc.Epsilon = cfg.stabilityOpts.Epsilon
+	dc.Strategy = strategy
+	dc.Scheme = scheme
+	return corepkg.NewStableEPCDetector(dc), nil
+}
+
+// syncGTIN faol batch item'ining GTIN'ini SGTIN sxemasiga beradi (o'zgarmagan bo'lsa hech narsa
+// qilmaydi). GTIN yo'q yoki noto'g'ri bo'lsa sxema EPC bermaydi: trigger xato bilan keladi.
+func (a autoEncode) syncGTIN(gtin string) error {
+	gtin = strings.TrimSpace(gtin)
+	if a.sgtin == nil || a.sgtin.GTIN() == gtin {
+		return nil
+	}
+	return a.sgtin.SetGTIN(gtin)
+}
+
+// scheme --epc-scheme sxemasi (detector'niki). Detector yo'q bo'lsa vaqt sxemasi.
+func (a autoEncode) scheme() corepkg.EPCScheme {
+	if a.detector == nil {
+		return corepkg.NewTimeEPCScheme()
+	}
+	return a.detector.Scheme()
+}
+
+// nextEPC qo'lda (`e`) encode uchun EPC: auto trigger bilan bir xil sxemadan, sgtin96 bo'lsa
+// faol batch GTIN'i bilan. GTIN yo'q yoki noto'g'ri bo'lsa xato: EPC berilmaydi.
+// EPC'ni yaratgan sxema ham qaytadi: registry'ga aynan shu sxema yoziladi.
+func (a autoEncode) nextEPC(gtin string, at time.Time) (string, corepkg.EPCScheme, error) {
+	if err := a.syncGTIN(gtin); err != nil {
+		return "", nil, err
+	}
+	scheme := a.scheme()
+	epc, err := scheme.Next(at)
+	if err != nil {
+		return "", nil, err
+	}
+	return epc, scheme, nil
+}
+
+// check item qoidasi bo'lsa vaznni klassifikatsiya qiladi. allow=false bo'lsa EPC
+// berilmaydi (block policy); labelNote mark policy'da label'ga qo'shiladigan sinf.
+func (a autoEncode) check(itemCode string, weight *float64) (res corepkg.CheckResult, allow bool, labelNote string) {
+	if a.checker == nil || weight == nil {
+		return corepkg.CheckResult{}, true, ""
+	}
+	res, ok := a.checker.Classify(itemCode, *weight)
+	if !ok || res.OK() {
+		return res, true, ""
+	}
+	if corepkg.NormalizeCheckPolicy(a.checkPolicy) == corepkg.CheckPolicyMark {
+		return res, true, string(res.Class)
+	}
+	return res, false, ""
+}
+
+// applyCheck faol batch item'i uchun check-weigh natijasini o'qishga qo'yadi: bridge snapshot
+// va bot sinfni scale'dan oladi (bot o'zi klassifikatsiya qilmaydi).
+func (a autoEncode) applyCheck(rd Reading, itemCode string) Reading {
+	res, allow, _ := a.check(itemCode, rd.netWeight())
+	if res.Class == "" {
+		return rd
+	}
+	rd.Check = &res
+	rd.CheckBlock = !allow
+	return rd
+}
+
+// labelQty label, EPC registry va bus uchun qty: vazn yoki counting rejimida dona soni.
+type labelQty struct {
+	qty  *float64
+	unit string
+	text string
+}
+
+// labelQtyFor counting rejimi (batch'da CountUOM) bo'lsa net vaznni dona soniga aylantiradi.
+// Dona og'irligi noma'lum yoki son ishonchsiz bo'lsa xato: bunday mahsulotga EPC berilmaydi.
+func labelQtyFor(count corepkg.PieceCounter, rd Reading) (labelQty, error) {
+	w := rd.netWeight()
+	if strings.TrimSpace(count.UOM) == "" || w == nil {
+		return labelQty{qty: w, unit: rd.Unit, text: formatLabelQty(w, rd.Unit)}, nil
+	}
+	pc, err := count.CountUnit(*w, rd.Unit)
+	if err != nil {
+		return labelQty{}, err
+	}
+	n := float64(pc.Pieces)
+	return labelQty{qty: &n, unit: pc.UOM, text: pc.String()}, nil
+}
+
+// countText TUI `COUNT` qatori: `24 Nos (0.1250 kg/dona (sample 10))`, rejim yo'q bo'lsa `-`.
+func countText(count corepkg.PieceCounter, rd Reading) string {
+	if strings.TrimSpace(count.UOM) == "" {
+		return "-"
+	}
+	if !count.Ready() {
+		return count.UOM + ": dona og'irligi noma'lum (/sample N)"
+	}
+	w := rd.netWeight()
+	if w == nil {
+		return "- " + count.UOM + " (" + count.String() + ")"
+	}
+	pc, err := count.CountUnit(*w, rd.Unit)
+	if err != nil {
+		return fmt.Sprintf("%.2f %s ? (%s)", pc.Exact, count.UOM, count.String())
+	}
+	return pc.String() + " (" + count.String() + ")"
+}
+
+// autoStatus detector event'larini TUI (`AUTO` qatori) va workflow log uchun ushlaydi.
+// Settling har sample'da keladi, shuning uchun log'ga faqat bosqich o'zgarishlari yoziladi.
+type autoStatus struct {
+	last corepkg.DetectorEvent
+}
+
+func (s *autoStatus) handle(ev corepkg.DetectorEvent) {
+	s.last = ev
+	if ev.Kind != corepkg.DetectorSettling {
+		workerLog("worker.auto").Printf("detector %s", ev.String())
+	}
+}
+
+func (s *autoStatus) text() string {
+	if s == nil || s.last.Kind == "" {
+		return "-"
+	}
+	return s.last.String()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	corepkg "core"
+	"testing"
+	"time"
+)
+
+func TestAutoEncodeCheckPolicy(t *testing.T) {
+	checker, err := corepkg.NewCheckWeigher(map[string]corepkg.CheckRule{"ITM-1": {Nominal: 1.0, Tolerance: 0.01}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	over := 1.05
+	ok := 1.005
+
+	block := autoEncode{checker: checker, checkPolicy: corepkg.CheckPolicyBlock}
+	if res, allow, note := block.check("ITM-1", &over); allow || note != "" || res.Class != corepkg.CheckOver {
+		t.Fatalf("block: res=%+v allow=%v note=%q", res, allow, note)
+	}
+	if _, allow, _ := block.check("ITM-1", &ok); !allow {
+		t.Fatal("in-tolerance weight must be allowed")
+	}
+	if res, allow, _ := block.check("OTHER", &over); !allow || res.Class != "" {
+		t.Fatalf("item without rule must pass unchecked: res=%+v", res)
+	}
+
+	mark := autoEncode{checker: checker, checkPolicy: corepkg.CheckPolicyMark}
+	if _, allow, note := mark.check("ITM-1", &over); !allow || note != "OVER" {
+		t.Fatalf("mark: allow=%v note=%q", allow, note)
+	}
+
+	if _, allow, _ := (autoEncode{}).check("ITM-1", &over); !allow {
+		t.Fatal("disabled check-weigh must allow")
+	}
+}
+
+func TestLabelQtyFor_Counting(t *testing.T) {
+	gross := 3.2
+	tare := 0.2
+	net := 3.0
+	rd := Reading{Weight: &gross, Tare: &tare, Net: &net, Unit: "kg"}
+
+	lq, err := labelQtyFor(corepkg.PieceCounter{}, rd)
+	if err != nil || lq.text != "3.000 kg" || *lq.qty != 3.0 || lq.unit != "kg" {
+		t.Fatalf("weight mode: %+v err=%v", lq, err)
+	}
+
+	count := corepkg.PieceCounter{UnitWeight: 0.125, Source: corepkg.UnitWeightSample, UOM: "Nos"}
+	lq, err = labelQtyFor(count, rd)
+	if err != nil || lq.text != "24 Nos" || *lq.qty != 24 || lq.unit != "Nos" {
+		t.Fatalf("count mode: %+v err=%v", lq, err)
+	}
+
+	grams := 3000.0 // g tarozi: dona og'irligi kg'da, vazn avval kg'ga
+	if lq, err := labelQtyFor(count, Reading{Weight: &grams, Unit: "g"}); err != nil || lq.text != "24 Nos" {
+		t.Fatalf("gram reading: %+v err=%v", lq, err)
+	}
+
+	odd := 2.95 // 23.6 dona
+	if _, err := labelQtyFor(count, Reading{Weight: &odd, Unit: "kg"}); err == nil {
+		t.Fatal("uncertain count must block the label")
+	}
+	if _, err := labelQtyFor(corepkg.PieceCounter{UOM: "Nos"}, rd); err == nil {
+		t.Fatal("unknown unit weight must block the label")
+	}
+	if got := countText(count, rd); got != "24 Nos (0.1250 kg/dona (sample))" {
+		t.Fatalf("count text: %q", got)
+	}
+}
+
+func TestAutoEncodeSyncGTIN(t *testing.T) {
+	scheme, err := corepkg.NewSGTINScheme("0614141", 1, corepkg.NewMemorySerialAllocator(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := corepkg.DefaultStableEPCConfig()
+	dc.Scheme = scheme
+	auto := autoEncode{detector: corepkg.NewStableEPCDetector(dc), sgtin: scheme}
+
+	if err := auto.syncGTIN("80614141123458"); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	epc, err := auto.detector.Scheme().Next(time.Now())
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	tag, err := corepkg.DecodeSGTIN96(epc)
+	if err != nil || tag.GTIN() != "80614141123458" || tag.Serial != 7 {
+		t.Fatalf("sgtin mismatch: %+v err=%v", tag, err)
+	}
+
+	// Boshqa kompaniya GTIN'i: eski item GTIN'i qolmaydi, EPC berilmaydi.
+	if err := auto.syncGTIN("00712345000019"); err == nil {
+		t.Fatal("foreign gtin should fail")
+	}
+	if _, err := scheme.Next(time.Now()); err == nil {
+		t.Fatal("scheme must not reuse the previous gtin")
+	}
+	if err := (autoEncode{}).syncGTIN("80614141123458"); err != nil {
+		t.Fatalf("time scheme: %v", err)
+	}
+}
+
+func TestAutoEncodeNextEPCUsesConfiguredScheme(t *testing.T) {
+	scheme, err := corepkg.NewSGTINScheme("0614141", 1, corepkg.NewMemorySerialAllocator(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := corepkg.DefaultStableEPCConfig()
+	dc.Scheme = scheme
+	auto := autoEncode{detector: corepkg.NewStableEPCDetector(dc), sgtin: scheme}
+
+	epc, _, err := auto.nextEPC("80614141123458", time.Now())
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	if chk, err := corepkg.ValidateEPCScheme(epc, corepkg.EPCSchemeSGTIN96); err != nil || chk.Kind != corepkg.EPCKindSGTIN96 {
+		t.Fatalf("manual epc %s is not sgtin96: %+v err=%v", epc, chk, err)
+	}
+	tag, err := corepkg.DecodeSGTIN96(epc)
+	if err != nil || tag.GTIN() != "80614141123458" || tag.Serial != 9 {
+		t.Fatalf("sgtin mismatch: %+v err=%v", tag, err)
+	}
+	// GTIN yo'q: vaqt EPC'siga qaytib ketmaydi, EPC berilmaydi.
+	if epc, _, err := auto.nextEPC("", time.Now()); err == nil {
+		t.Fatalf("missing gtin must not issue an epc: %s", epc)
+	}
+
+	epc, _, err = (autoEncode{}).nextEPC("", time.Now())
+	if err != nil {
+		t.Fatalf("time scheme: %v", err)
+	}
+	if _, err := corepkg.ValidateEPCScheme(epc, corepkg.EPCSchemeTime); err != nil {
+		t.Fatalf("time epc %s: %v", epc, err)
+	}
+}
+
+func TestAutoEncodeApplyCheckPublishesClass(t *testing.T) {
+	checker, err := corepkg.NewCheckWeigher(map[string]corepkg.CheckRule{"ITM-1": {Nominal: 1.0, Tolerance: 0.01}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gross, tare, net := 1.25, 0.2, 1.05
+	rd := Reading{Weight: &gross, Tare: &tare, Net: &net}
+
+	block := autoEncode{checker: checker, checkPolicy: corepkg.CheckPolicyBlock}
+	snap := scaleSnapshotOf(block.applyCheck(rd, "ITM-1")).Check
+	if snap == nil || snap.Class != "OVER" || !snap.Block || snap.Nominal != 1.0 {
+		t.Fatalf("block snapshot: %+v", snap)
+	}
+	mark := autoEncode{checker: checker, checkPolicy: corepkg.CheckPolicyMark}
+	if snap := scaleSnapshotOf(mark.applyCheck(rd, "ITM-1")).Check; snap == nil || snap.Block {
+		t.Fatalf("mark snapshot: %+v", snap)
+	}
+	if got := block.applyCheck(rd, "OTHER"); got.Check != nil || scaleSnapshotOf(got).Check != nil {
+		t.Fatalf("item without rule: %+v", got.Check)
+	}
+}
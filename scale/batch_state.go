@@ -2,6 +2,8 @@ package main
 
 import (
 	bridgestate "bridge/state"
+	"context"
+	corepkg "core"
 	"strings"
 	"time"
 )
@@ -15,6 +17,9 @@ type batchStateReader struct {
 	itemCode      string
 	itemName      string
 	warehouse     string
+	gtin          string
+	count         corepkg.PieceCounter
+	updates       <-chan bridgestate.Snapshot
 }
 
 func newBatchStateReader(path string, defaultActive bool) *batchStateReader {
@@ -22,12 +27,28 @@ func newBatchStateReader(path string, defaultActive bool) *batchStateReader {
 	if path == "" {
 		return nil
 	}
+	return newBatchStateReaderFromStore(bridgestate.New(path), defaultActive)
+}
+
+// newBatchStateReaderFromStore store bog'langan stansiyaning batch holatini o'qiydi.
+func newBatchStateReaderFromStore(store *bridgestate.Store, defaultActive bool) *batchStateReader {
+	if store == nil || strings.TrimSpace(store.Path()) == "" {
+		return nil
+	}
 	return &batchStateReader{
-		store:         bridgestate.New(path),
+		store:         store,
 		defaultActive: defaultActive,
 	}
 }
 
+// Watch batch holatini polling o'rniga bridge watch orqali yangilaydi.
+func (r *batchStateReader) Watch(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.updates = r.store.Watch(ctx)
+}
+
 func (r *batchStateReader) Active(now time.Time) bool {
 	r.refresh(now)
 	return r.value
@@ -44,6 +65,32 @@ func (r *batchStateReader) ItemLabel(now time.Time) string {
 	return strings.TrimSpace(r.itemCode)
 }
 
+func (r *batchStateReader) ItemCode(now time.Time) string {
+	r.refresh(now)
+	if !r.value {
+		return ""
+	}
+	return strings.TrimSpace(r.itemCode)
+}
+
+// GTIN faol batch item'ining GTIN-14'i (bot ERP barcode'laridan; bo'sh = yo'q).
+func (r *batchStateReader) GTIN(now time.Time) string {
+	r.refresh(now)
+	if !r.value {
+		return ""
+	}
+	return r.gtin
+}
+
+// Counter faol batch counting rejimida bo'lsa (bot COUNT_UOMS) dona counter'i.
+func (r *batchStateReader) Counter(now time.Time) corepkg.PieceCounter {
+	r.refresh(now)
+	if !r.value {
+		return corepkg.PieceCounter{}
+	}
+	return r.count
+}
+
 func (r *batchStateReader) refresh(now time.Time) {
 	if r == nil {
 		return
@@ -51,6 +98,10 @@ func (r *batchStateReader) refresh(now time.Time) {
 	if now.IsZero() {
 		now = time.Now()
 	}
+	if r.cached && r.updates != nil {
+		r.drainUpdates()
+		return
+	}
 	if r.cached && now.Before(r.nextReadAt) {
 		return
 	}
@@ -65,11 +116,35 @@ func (r *batchStateReader) refresh(now time.Time) {
 		return
 	}
 
+	r.apply(snap)
+	r.cached = true
+	r.nextReadAt = now.Add(250 * time.Millisecond)
+}
+
+func (r *batchStateReader) drainUpdates() {
+	for {
+		select {
+		case snap, ok := <-r.updates:
+			if !ok {
+				// Watch to'xtadi: polling'ga qaytamiz.
+				r.updates = nil
+				return
+			}
+			r.apply(snap)
+		default:
+			return
+		}
+	}
+}
+
+func (r *batchStateReader) apply(snap bridgestate.Snapshot) {
 	if strings.TrimSpace(snap.Batch.UpdatedAt) == "" && snap.Batch.ChatID == 0 && !snap.Batch.Active {
 		r.value = r.defaultActive
 		r.itemCode = ""
 		r.itemName = ""
 		r.warehouse = ""
+		r.gtin = ""
+		r.count = corepkg.PieceCounter{}
 	} else {
 		r.value = snap.Batch.Active
 		r.itemCode = strings.TrimSpace(snap.Batch.ItemCode)
@@ -78,13 +153,18 @@ func (r *batchStateReader) refresh(now time.Time) {
 			r.itemName = r.itemCode
 		}
 		r.warehouse = strings.TrimSpace(snap.Batch.Warehouse)
+		r.gtin = strings.TrimSpace(snap.Batch.GTIN)
+		r.count = corepkg.PieceCounter{
+			UnitWeight: snap.Batch.UnitWeight,
+			Source:     snap.Batch.UnitWeightSource,
+			UOM:        strings.TrimSpace(snap.Batch.CountUOM),
+		}
 		if !r.value {
 			r.itemCode = ""
 			r.itemName = ""
 			r.warehouse = ""
+			r.gtin = ""
+			r.count = corepkg.PieceCounter{}
 		}
 	}
-
-	r.cached = true
-	r.nextReadAt = now.Add(250 * time.Millisecond)
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	bridgestate "bridge/state"
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -25,3 +27,53 @@ func TestBatchStateReader_ReadsActive(t *testing.T) {
 		t.Fatalf("expected true")
 	}
 }
+
+func TestBatchStateReader_ReadsCountMode(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	if err := os.WriteFile(p, []byte(`{"batch":{"active":true,"item_code":"BOLT","count_uom":"Nos","unit_weight":0.025,"unit_weight_source":"erp","updated_at":"2026-02-18T00:00:00Z"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := newBatchStateReader(p, false)
+	c := r.Counter(time.Now())
+	if c.UOM != "Nos" || c.UnitWeight != 0.025 || c.Source != "erp" {
+		t.Fatalf("counter mismatch: %+v", c)
+	}
+}
+
+func TestBatchStateReader_WatchPicksUpChanges(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "bridge_state.json")
+	store := bridgestate.New(p)
+	if err := store.Update(func(s *bridgestate.Snapshot) {
+		s.Batch.Active = false
+		s.Batch.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newBatchStateReader(p, true)
+	if r.Active(time.Now()) {
+		t.Fatal("expected inactive batch")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Watch(ctx)
+
+	if err := store.Update(func(s *bridgestate.Snapshot) {
+		s.Batch.Active = true
+		s.Batch.ItemCode = "ITM-001"
+		s.Batch.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !r.Active(time.Now()) {
+		if time.Now().After(deadline) {
+			t.Fatal("watch did not deliver batch change")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := r.ItemLabel(time.Now()); got != "ITM-001" {
+		t.Fatalf("item label mismatch: %q", got)
+	}
+}
@@ -11,29 +11,82 @@ func writeBridgeStateSnapshot(store *bridgestate.Store, rd Reading, zebra ZebraS
 		return nil
 	}
 
+	scaleSnap := scaleSnapshotOf(rd)
+	zebraSnap := zebraSnapshotOf(zebra, rd.UpdatedAt)
+
+	return store.Update(func(s *bridgestate.Snapshot) {
+		s.Scale = scaleSnap
+		s.Zebra = zebraSnap
+	})
+}
+
+func scaleSnapshotOf(rd Reading) bridgestate.ScaleSnapshot {
 	scaleTS := rd.UpdatedAt
 	if scaleTS.IsZero() {
 		scaleTS = time.Now()
 	}
-	zebraTS := zebra.UpdatedAt
-	if zebraTS.IsZero() {
-		zebraTS = scaleTS
-	}
 
 	scaleSnap := bridgestate.ScaleSnapshot{
-		Source:    strings.TrimSpace(rd.Source),
-		Port:      strings.TrimSpace(rd.Port),
-		Weight:    rd.Weight,
-		Unit:      strings.TrimSpace(rd.Unit),
-		Stable:    rd.Stable,
-		Error:     strings.TrimSpace(rd.Error),
-		UpdatedAt: scaleTS.UTC().Format(time.RFC3339Nano),
+		Source:     strings.TrimSpace(rd.Source),
+		Port:       strings.TrimSpace(rd.Port),
+		Weight:     rd.Weight,
+		Unit:       strings.TrimSpace(rd.Unit),
+		Stable:     rd.Stable,
+		Gross:      rd.Weight,
+		Tare:       rd.Tare,
+		Net:        rd.Net,
+		TareSource: rd.TareSource,
+		Container:  rd.Container,
+		ZeroOffset: rd.ZeroOffset,
+		ZeroAlarm:  rd.ZeroAlarm,
+		ZeroBlock:  rd.ZeroBlock,
+		Error:      strings.TrimSpace(rd.Error),
+		UpdatedAt:  scaleTS.UTC().Format(time.RFC3339Nano),
 	}
 	if scaleSnap.Unit == "" {
 		scaleSnap.Unit = "kg"
 	}
+	if rd.Check != nil {
+		scaleSnap.Check = &bridgestate.ScaleCheck{
+			Class:     string(rd.Check.Class),
+			Nominal:   rd.Check.Nominal,
+			Deviation: rd.Check.Deviation,
+			Block:     rd.CheckBlock,
+		}
+	}
+	scaleSnap.Outage = outageSnapshotOf(rd.Outage)
+	return scaleSnap
+}
 
-	zebraSnap := bridgestate.ZebraSnapshot{
+// outageSnapshotOf davom etayotgan uzilishda Until va DurationMS bo'sh.
+func outageSnapshotOf(o *scaleOutage) *bridgestate.ScaleOutage {
+	if o == nil {
+		return nil
+	}
+	snap := &bridgestate.ScaleOutage{
+		Active:        o.active(),
+		Since:         o.Since.UTC().Format(time.RFC3339Nano),
+		Port:          o.Port,
+		RecoveredPort: o.RecoveredPort,
+	}
+	if !o.active() {
+		snap.Until = o.Until.UTC().Format(time.RFC3339Nano)
+		snap.DurationMS = o.Until.Sub(o.Since).Milliseconds()
+	}
+	return snap
+}
+
+// zebraSnapshotOf zebra.UpdatedAt bo'sh bo'lsa fallback vaqtini ishlatadi.
+func zebraSnapshotOf(zebra ZebraStatus, fallback time.Time) bridgestate.ZebraSnapshot {
+	zebraTS := zebra.UpdatedAt
+	if zebraTS.IsZero() {
+		zebraTS = fallback
+	}
+	if zebraTS.IsZero() {
+		zebraTS = time.Now()
+	}
+
+	return bridgestate.ZebraSnapshot{
 		Connected:   zebra.Connected,
 		DevicePath:  strings.TrimSpace(zebra.DevicePath),
 		Name:        strings.TrimSpace(zebra.Name),
@@ -47,9 +100,4 @@ func writeBridgeStateSnapshot(store *bridgestate.Store, rd Reading, zebra ZebraS
 		Error:       strings.TrimSpace(zebra.Error),
 		UpdatedAt:   zebraTS.UTC().Format(time.RFC3339Nano),
 	}
-
-	return store.Update(func(s *bridgestate.Snapshot) {
-		s.Scale = scaleSnap
-		s.Zebra = zebraSnap
-	})
 }
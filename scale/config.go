@@ -1,6 +1,9 @@
 package main
 
 import (
+	"bridge/ipc"
+	bridgestate "bridge/state"
+	corepkg "core"
 	"errors"
 	"flag"
 	"fmt"
@@ -25,18 +28,65 @@ type appConfig struct {
 	botDir          string
 	disableBot      bool
 	bridgeStateFile string
+	bridgeJournal   string
+	bridgeBackend   string
+	ipcSocket       string
+	stationID       string
+	httpAddr        string
+	metricsAddr     string
+	epcRegistry     string
+	epcScheme       string
+	sgtinPrefix     string
+	sgtinFilter     int
+	sgtinSerialFile string
+	stability       string
+	stabilityOpts   corepkg.StabilityOptions
+	checkRules      string
+	checkPolicy     string
+	tarePresets     string
+	container       string
+	traceRecord     string
+	replay          string
+	zeroPolicy      string
+	zeroCfg         corepkg.ZeroTrackerConfig
+	scaleDriver     string
+	pollInterval    time.Duration
+	source          scaleSource
+	tcpKeepAlive    time.Duration
+	tcpIdleTimeout  time.Duration
+	modbus          modbusOptions
 }
 
 func parseFlags() (appConfig, error) {
 	cfg := appConfig{}
 	preferredBaud := 9600
+	sourceRaw := sourceSerial
 	baudListRaw := "9600,19200,38400,57600,115200"
 
+	flag.StringVar(&sourceRaw, "source", sourceSerial, "scale source: serial (auto-detect), tcp://host:port (connect to an Ethernet indicator), tcp-listen://:port (indicator connects to us), modbus-rtu (--device) or modbus-tcp://host:502")
+	flag.DurationVar(&cfg.tcpKeepAlive, "tcp-keepalive", 15*time.Second, "TCP keepalive period for tcp sources (0 = disabled)")
+	flag.DurationVar(&cfg.tcpIdleTimeout, "tcp-idle-timeout", 10*time.Second, "reconnect a tcp source after this long without data (0 = disabled)")
+	mb := defaultModbusMap()
+	mbUnitID, mbWeightReg := int(mb.UnitID), int(mb.WeightReg)
+	mbFunction, mbParity := "holding", "N"
+	flag.IntVar(&mbUnitID, "modbus-unit-id", mbUnitID, "modbus: slave/unit id (1..247)")
+	flag.StringVar(&mbFunction, "modbus-function", mbFunction, "modbus: register type, holding (0x03) or input (0x04)")
+	flag.IntVar(&mbWeightReg, "modbus-weight-reg", mbWeightReg, "modbus: 0-based weight register address (40001 = 0)")
+	flag.StringVar(&mb.Format, "modbus-weight-format", mb.Format, "modbus: weight format int16, uint16, int32, uint32 or float32")
+	flag.BoolVar(&mb.WordSwap, "modbus-word-swap", false, "modbus: 32-bit weight has the low word first (CDAB)")
+	flag.Float64Var(&mb.Scale, "modbus-scale", mb.Scale, "modbus: weight = raw * scale / 10^decimals")
+	flag.IntVar(&mb.Decimals, "modbus-decimals", mb.Decimals, "modbus: decimal places of the raw weight")
+	flag.IntVar(&mb.StatusReg, "modbus-status-reg", mb.StatusReg, "modbus: status register address (-1 = stability unknown)")
+	flag.IntVar(&mb.StableBit, "modbus-stable-bit", mb.StableBit, "modbus: bit in the status register that is set when the weight is stable")
+	flag.StringVar(&mbParity, "modbus-parity", mbParity, "modbus-rtu: parity N, E or O")
+	flag.DurationVar(&cfg.modbus.Timeout, "modbus-timeout", 500*time.Millisecond, "modbus: response timeout per request")
 	flag.StringVar(&cfg.device, "device", "", "serial device path, example /dev/ttyUSB0")
 	flag.IntVar(&preferredBaud, "baud", 9600, "preferred baudrate")
 	flag.StringVar(&baudListRaw, "baud-list", "9600,19200,38400,57600,115200", "comma-separated baudrates for auto-detect")
 	flag.StringVar(&cfg.unit, "unit", "kg", "default unit")
 	flag.DurationVar(&cfg.probeTimeout, "probe-timeout", 800*time.Millisecond, "probe duration per port/baud")
+	flag.StringVar(&cfg.scaleDriver, "scale-driver", driverStream, "scale protocol: stream (passive regex), auto (probe --device with SI/Q, listen only on scanned ports), sics (Mettler Toledo MT-SICS), cas or ad (A&D)")
+	flag.DurationVar(&cfg.pollInterval, "poll-interval", 200*time.Millisecond, "weight request interval for polled drivers (sics, ad) and modbus sources")
 	flag.StringVar(&cfg.bridgeURL, "bridge-url", "http://127.0.0.1:18000/api/v1/scale", "fallback HTTP endpoint")
 	flag.DurationVar(&cfg.bridgeInterval, "bridge-interval", 120*time.Millisecond, "bridge poll interval")
 	flag.BoolVar(&cfg.disableBridge, "no-bridge", false, "disable HTTP bridge fallback")
@@ -46,8 +96,140 @@ func parseFlags() (appConfig, error) {
 	flag.StringVar(&cfg.botDir, "bot-dir", "../bot", "telegram bot module directory")
 	flag.BoolVar(&cfg.disableBot, "no-bot", false, "disable auto-start telegram bot")
 	flag.StringVar(&cfg.bridgeStateFile, "bridge-state-file", defaultSharedBridgeStateFile, "shared bridge JSON file for scale+zebra+bot")
+	flag.StringVar(&cfg.bridgeBackend, "bridge-backend", bridgestate.BackendFile, "bridge state storage: file (JSON) or sqlite (use a persistent --bridge-state-file path, example /var/lib/gscale-zebra/bridge_state.db)")
+	flag.StringVar(&cfg.bridgeJournal, "bridge-journal-dir", "", "append-only bridge state journal dir (empty = disabled)")
+	flag.StringVar(&cfg.ipcSocket, "ipc-socket", ipc.DefaultSocketPath, "unix socket for scale<->bot IPC bus (empty = disabled)")
+	flag.StringVar(&cfg.stationID, "station-id", bridgestate.DefaultStationID, "station id inside shared bridge state (one per scale+printer)")
+	flag.StringVar(&cfg.httpAddr, "http-addr", "", "read-only HTTP API + SSE for bridge state, example 127.0.0.1:18080 (empty = disabled)")
+	flag.StringVar(&cfg.metricsAddr, "metrics-addr", "", "Prometheus /metrics listen address, example 127.0.0.1:19100 (empty = disabled)")
+	flag.StringVar(&cfg.epcRegistry, "epc-registry", "", "persistent EPC registry (JSONL) for uniqueness across restarts/stations, example /var/lib/gscale-zebra/epc_registry.jsonl (empty = disabled)")
+	flag.StringVar(&cfg.epcScheme, "epc-scheme", corepkg.EPCSchemeTime, "auto encode EPC scheme: time (internal 30... id) or sgtin96 (GS1 SGTIN-96 from the batch item's GTIN)")
+	flag.StringVar(&cfg.sgtinPrefix, "sgtin-company-prefix", "", "sgtin96: GS1 company prefix (6..12 digits), example 0614141")
+	flag.IntVar(&cfg.sgtinFilter, "sgtin-filter", 1, "sgtin96: filter value 0..7 (1 = POS item, 3 = unit load)")
+	flag.StringVar(&cfg.sgtinSerialFile, "sgtin-serial-file", "", "sgtin96: file that keeps the next serial across restarts, example /var/lib/gscale-zebra/sgtin_serial")
+	stab := corepkg.DefaultStabilityOptions()
+	flag.StringVar(&cfg.stability, "stability", corepkg.StabilityEpsilon, "auto encode stability strategy: epsilon, stddev (vibrating conveyor), median (impulse noise) or st (scale ST frames)")
+	flag.DurationVar(&stab.StableFor, "stable-for", stab.StableFor, "epsilon/median: weight must hold this long")
+	flag.Float64Var(&stab.Epsilon, "stable-epsilon", stab.Epsilon, "epsilon/median threshold; also the re-arm delta for every strategy")
+	flag.DurationVar(&stab.Window, "stability-window", stab.Window, "stddev: moving window length")
+	flag.Float64Var(&stab.MaxStdDev, "stability-max-stddev", stab.MaxStdDev, "stddev: max standard deviation inside the window")
+	flag.IntVar(&stab.MinSamples, "stability-min-samples", stab.MinSamples, "stddev: min readings inside the window")
+	flag.IntVar(&stab.MedianSize, "stability-median", stab.MedianSize, "median: filter size (readings)")
+	flag.IntVar(&stab.STFrames, "stability-st-frames", stab.STFrames, "st: consecutive ST frames required")
+	flag.StringVar(&cfg.checkRules, "checkweigh-rules", "", "check-weigh rules JSON: item code -> nominal/tolerance, \"*\" = default (empty = disabled)")
+	flag.StringVar(&cfg.checkPolicy, "checkweigh-policy", corepkg.CheckPolicyBlock, "out-of-tolerance items: block (no EPC) or mark (UNDER/OVER on label)")
+	flag.StringVar(&cfg.tarePresets, "tare-presets", "", "tare presets JSON: container type -> tare weight, example {\"crate\": 1.2} (empty = button/indicator tare only)")
+	flag.StringVar(&cfg.container, "container", "", "initial container preset from --tare-presets (empty = no tare)")
+	flag.StringVar(&cfg.traceRecord, "trace-record", "", "record raw serial bytes with timestamps to this trace file (empty = disabled)")
+	flag.StringVar(&cfg.replay, "replay", "", "replay a recorded trace through the parser and stable detector on a virtual clock, print triggers/EPCs and exit")
+	zc := corepkg.DefaultZeroTrackerConfig()
+	flag.StringVar(&cfg.zeroPolicy, "zero-policy", corepkg.ZeroPolicyWarn, "zero-tracking: off, warn (TUI/bridge/bot warning) or block (no EPC until the scale is re-zeroed)")
+	flag.Float64Var(&zc.Band, "zero-band", zc.Band, "zero-tracking: |gross| below this is an empty platform")
+	flag.Float64Var(&zc.DriftLimit, "zero-drift", zc.DriftLimit, "zero-tracking: empty-platform offset above this raises a drift alarm")
 	flag.Parse()
 
+	if _, err := corepkg.NewStabilityStrategy(cfg.stability, stab); err != nil {
+		return appConfig{}, err
+	}
+	cfg.stabilityOpts = stab
+	cfg.checkPolicy = corepkg.NormalizeCheckPolicy(cfg.checkPolicy)
+	if err := corepkg.ValidateCheckPolicy(cfg.checkPolicy); err != nil {
+		return appConfig{}, err
+	}
+
+	src, err := parseScaleSource(sourceRaw)
+	if err != nil {
+		return appConfig{}, err
+	}
+	cfg.source = src
+	if cfg.tcpKeepAlive < 0 || cfg.tcpIdleTimeout < 0 {
+		return appConfig{}, errors.New("--tcp-keepalive va --tcp-idle-timeout manfiy bo'lmasligi kerak")
+	}
+	if cfg.source.isModbus() {
+		if mbUnitID < 1 || mbUnitID > 247 || mbWeightReg < 0 || mbWeightReg > 0xFFFF {
+			return appConfig{}, fmt.Errorf("--modbus-unit-id (1..247) yoki --modbus-weight-reg noto'g'ri: %d, %d", mbUnitID, mbWeightReg)
+		}
+		mb.UnitID, mb.WeightReg = byte(mbUnitID), uint16(mbWeightReg)
+		mb.Format = strings.ToLower(strings.TrimSpace(mb.Format))
+		if mb.Function, err = parseModbusFunction(mbFunction); err != nil {
+			return appConfig{}, err
+		}
+		if err := mb.validate(); err != nil {
+			return appConfig{}, err
+		}
+		cfg.modbus.Map = mb
+		if cfg.modbus.Parity, err = parseModbusParity(mbParity); err != nil {
+			return appConfig{}, err
+		}
+		if cfg.modbus.Timeout <= 0 {
+			return appConfig{}, errors.New("--modbus-timeout 0 dan katta bo'lishi kerak")
+		}
+		if cfg.source.Kind == sourceModbusRTU && cfg.source.Addr == "" {
+			cfg.source.Addr = strings.TrimSpace(cfg.device)
+			if cfg.source.Addr == "" {
+				return appConfig{}, errors.New("--source modbus-rtu uchun --device kerak (yoki modbus-rtu:///dev/ttyUSB0)")
+			}
+		}
+		if strings.TrimSpace(cfg.traceRecord) != "" {
+			return appConfig{}, errors.New("--trace-record modbus manbada qo'llanmaydi")
+		}
+	}
+
+	cfg.epcScheme = strings.ToLower(strings.TrimSpace(cfg.epcScheme))
+	switch cfg.epcScheme {
+	case corepkg.EPCSchemeTime:
+	case corepkg.EPCSchemeSGTIN96:
+		if strings.TrimSpace(cfg.sgtinPrefix) == "" || strings.TrimSpace(cfg.sgtinSerialFile) == "" {
+			return appConfig{}, errors.New("--epc-scheme sgtin96 uchun --sgtin-company-prefix va --sgtin-serial-file kerak")
+		}
+		if cfg.sgtinFilter < 0 || cfg.sgtinFilter > 7 {
+			return appConfig{}, fmt.Errorf("--sgtin-filter 0..7 bo'lishi kerak: %d", cfg.sgtinFilter)
+		}
+	default:
+		return appConfig{}, fmt.Errorf("--epc-scheme noto'g'ri: %q (time yoki sgtin96)", cfg.epcScheme)
+	}
+
+	cfg.scaleDriver = normalizeDriverName(cfg.scaleDriver)
+	if err := validateDriverName(cfg.scaleDriver); err != nil {
+		return appConfig{}, err
+	}
+	if cfg.pollInterval <= 0 {
+		return appConfig{}, errors.New("--poll-interval 0 dan katta bo'lishi kerak")
+	}
+
+	cfg.zeroPolicy = corepkg.NormalizeZeroPolicy(cfg.zeroPolicy)
+	if err := corepkg.ValidateZeroPolicy(cfg.zeroPolicy); err != nil {
+		return appConfig{}, err
+	}
+	if zc.Band <= 0 || zc.DriftLimit <= 0 || zc.DriftLimit >= zc.Band {
+		return appConfig{}, fmt.Errorf("zero-tracking: 0 < --zero-drift < --zero-band bo'lishi kerak (%.4f, %.4f)", zc.DriftLimit, zc.Band)
+	}
+	cfg.zeroCfg = zc
+
+	cfg.container = strings.TrimSpace(cfg.container)
+	if cfg.container != "" && strings.TrimSpace(cfg.tarePresets) == "" {
+		return appConfig{}, errors.New("--container uchun --tare-presets kerak")
+	}
+
+	cfg.bridgeBackend = bridgestate.NormalizeBackend(cfg.bridgeBackend)
+	if err := bridgestate.ValidateBackend(cfg.bridgeBackend); err != nil {
+		return appConfig{}, err
+	}
+	cfg.stationID = bridgestate.NormalizeStationID(cfg.stationID)
+	if err := bridgestate.ValidateStationID(cfg.stationID); err != nil {
+		return appConfig{}, err
+	}
+	ipcSocketSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "ipc-socket" {
+			ipcSocketSet = true
+		}
+	})
+	if !ipcSocketSet {
+		// Har stansiya o'z socket'ini ochadi, bir hostda bir nechta scale bo'lishi mumkin.
+		cfg.ipcSocket = ipc.SocketPathForStation(cfg.ipcSocket, cfg.stationID)
+	}
+
 	bauds, err := parseBaudList(baudListRaw, preferredBaud)
 	if err != nil {
 		return appConfig{}, err
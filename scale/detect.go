@@ -11,20 +11,36 @@ import (
 	"github.com/tarm/serial"
 )
 
-func detectScalePort(device string, bauds []int, probeTimeout time.Duration, unit string) (string, int, error) {
-	if strings.TrimSpace(device) != "" {
-		return strings.TrimSpace(device), bauds[0], nil
+// detectScalePort port, baud va driver'ni aniqlaydi. So'rov (SICS `SI`, A&D `Q`) faqat aniq
+// berilgan --device'ga yuboriladi: ttyUSB/ttyACM ro'yxatidagi boshqa qurilmalar (printer,
+// modem) tarozi bo'lmasligi mumkin, ular faqat tinglanadi. Aniq protokol topilmasa stream.
+func detectScalePort(device string, bauds []int, probeTimeout time.Duration, unit, driver string) (string, int, string, error) {
+	driver = normalizeDriverName(driver)
+	if dev := strings.TrimSpace(device); dev != "" {
+		if driver != driverAuto {
+			return dev, bauds[0], driver, nil
+		}
+		for _, b := range bauds {
+			if found, _, err := probePort(dev, b, probeTimeout, unit, driver, true); err == nil && found != "" {
+				return dev, b, found, nil
+			}
+		}
+		return dev, bauds[0], driverStream, nil
 	}
 
 	candidates := listCandidates()
 	if len(candidates) == 0 {
-		return "", 0, errors.New("serial device topilmadi (/dev/ttyUSB* yoki /dev/ttyACM*)")
+		return "", 0, "", errors.New("serial device topilmadi (/dev/ttyUSB* yoki /dev/ttyACM*)")
 	}
 
+	fallback := driver
+	if fallback == driverAuto {
+		fallback = driverStream
+	}
 	var lastBusy error
 	for _, dev := range candidates {
 		for _, b := range bauds {
-			found, hasData, err := probePort(dev, b, probeTimeout, unit)
+			found, hasData, err := probePort(dev, b, probeTimeout, unit, driver, false)
 			if err != nil {
 				if isBusyErr(err) {
 					lastBusy = fmt.Errorf("%s band: %w", dev, err)
@@ -32,20 +48,35 @@ func detectScalePort(device string, bauds []int, probeTimeout time.Duration, uni
 				}
 				continue
 			}
-			if found {
-				return dev, b, nil
+			if found != "" {
+				return dev, b, found, nil
 			}
 			if hasData {
-				return dev, b, nil
+				return dev, b, fallback, nil
 			}
 		}
 	}
 
 	if lastBusy != nil {
-		return "", 0, fmt.Errorf("serial port band: %w", lastBusy)
+		return "", 0, "", fmt.Errorf("serial port band: %w", lastBusy)
 	}
 
-	return candidates[0], bauds[0], nil
+	return candidates[0], bauds[0], fallback, nil
+}
+
+// findScalePort barcha port'larni (faqat tinglab) skanerlaydi va probe tarozi frame'ini
+// aniq topgan port'ni qaytaradi. detectScalePort'dan farqi: biror bayt kelgan yoki birinchi
+// port'ga fallback yo'q - hot-plug tiklanishida printer/modem port'i tarozi deb olinmasin.
+func findScalePort(bauds []int, probeTimeout time.Duration, unit, driver string) (string, int, string, error) {
+	driver = normalizeDriverName(driver)
+	for _, dev := range listCandidates() {
+		for _, b := range bauds {
+			if found, _, err := probePort(dev, b, probeTimeout, unit, driver, false); err == nil && found != "" {
+				return dev, b, found, nil
+			}
+		}
+	}
+	return "", 0, "", errors.New("tarozi oqimi hech bir serial port'da topilmadi")
 }
 
 func listCandidates() []string {
@@ -86,33 +117,80 @@ func listCandidates() []string {
 	return out
 }
 
-func probePort(device string, baud int, timeout time.Duration, unit string) (bool, bool, error) {
-	port, err := serial.OpenPort(&serial.Config{Name: device, Baud: baud, ReadTimeout: timeout})
+// probePort port'ni timeout davomida tinglaydi (query=true va driver so'rovli bo'lsa so'rab ham)
+// va mos driver nomini qaytaradi ("" = topilmadi). hasData - port'dan biror bayt keldi.
+func probePort(device string, baud int, timeout time.Duration, unit, driver string, query bool) (string, bool, error) {
+	readTimeout := timeout / 4
+	if readTimeout < 100*time.Millisecond {
+		readTimeout = 100 * time.Millisecond
+	}
+	port, err := serial.OpenPort(&serial.Config{Name: device, Baud: baud, ReadTimeout: readTimeout})
 	if err != nil {
-		return false, false, err
+		return "", false, err
 	}
 	defer port.Close()
 
+	var queries [][]byte
+	switch {
+	case !query:
+		// Faqat tinglash: port tarozi ekani hali ma'lum emas.
+	case driver == driverAuto:
+		queries = [][]byte{sicsDriver{}.PollCommand(), adDriver{}.PollCommand()}
+	case driver == driverSICS:
+		queries = [][]byte{sicsDriver{}.PollCommand()}
+	case driver == driverAD:
+		queries = [][]byte{adDriver{}.PollCommand()}
+	}
+
 	deadline := time.Now().Add(timeout)
 	buf := make([]byte, 256)
-	raw := ""
+	raw, pending := "", ""
 	hasData := false
-	for time.Now().Before(deadline) {
+	for q := 0; time.Now().Before(deadline); q++ {
+		if len(queries) > 0 {
+			if _, err := port.Write(queries[q%len(queries)]); err != nil {
+				return "", hasData, err
+			}
+		}
 		n, err := port.Read(buf)
 		if err != nil {
-			return false, hasData, err
+			return "", hasData, err
 		}
 		if n == 0 {
 			continue
 		}
 		hasData = true
-		raw = appendRaw(raw, string(buf[:n]), 240)
-		if _, _, _, ok := parseWeight(raw, unit); ok {
-			return true, true, nil
+		chunk := string(buf[:n])
+		raw = appendRaw(raw, chunk, 240)
+		pending = appendRaw(pending, chunk, 1024)
+		for {
+			frame, rest, ok := popSerialFrame(pending)
+			if !ok {
+				break
+			}
+			pending = rest
+			frame = strings.TrimSpace(frame)
+			if frame == "" {
+				continue
+			}
+			name := classifyFrame(frame)
+			switch {
+			case name != "" && (driver == driverAuto || driver == name):
+				return name, true, nil
+			case name == "" && driver == driverAuto:
+				if _, _, _, ok := parseWeight(frame, unit); ok {
+					return driverStream, true, nil
+				}
+			}
+		}
+		if driver == driverStream {
+			if _, _, _, ok := parseWeight(raw, unit); ok {
+				return driverStream, true, nil
+			}
 		}
 	}
 
-	return false, hasData, nil
+	return "", hasData, nil
 }
 
 func isBusyErr(err error) bool {
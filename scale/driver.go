@@ -0,0 +1,319 @@
+package main
+
+import (
+	bridgestate "bridge/state"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// driverStream passiv oqim: indikator o'zi yuboradi, frame weightRegex bilan parse qilinadi (default).
+	driverStream = "stream"
+	// driverAuto port'ni so'rab protokolni aniqlaydi (sics, ad, cas, aks holda stream).
+	driverAuto = "auto"
+	driverSICS = "sics"
+	driverCAS  = "cas"
+	driverAD   = "ad"
+)
+
+// scaleOp indikatorga yuboriladigan buyruq.
+type scaleOp string
+
+const (
+	scaleOpTare      scaleOp = bridgestate.ScaleOpTare
+	scaleOpZero      scaleOp = bridgestate.ScaleOpZero
+	scaleOpClearTare scaleOp = bridgestate.ScaleOpClearTare
+)
+
+type frameKind int
+
+const (
+	frameWeight frameKind = iota
+	// frameAck buyruq (tare/zero) bajarildi.
+	frameAck
+	// frameError indikator xato/overload qaytardi.
+	frameError
+)
+
+var (
+	errFrameUnknown  = errors.New("frame protokolga mos emas")
+	errOpUnsupported = errors.New("driver bu buyruqni qo'llamaydi")
+)
+
+// driverFrame protokol bo'yicha aniq parse qilingan frame.
+type driverFrame struct {
+	Kind   frameKind
+	Weight float64
+	Unit   string
+	Stable *bool
+	// Net vazn netto (indikator tarani ayirgan).
+	Net bool
+	// Tare indikator tarasi ma'lum bo'lsa (SICS `T S` javobi).
+	Tare *float64
+	Op   scaleOp
+	Err  string
+	// Ack frame oldida buyruq tasdig'i kelgan (A&D ACK keyingi frame'ga yopishadi):
+	// kutayotgan buyruq tugaydi, frame'ning o'zi odatdagidek ishlanadi.
+	Ack bool
+}
+
+// scaleDriver indikator protokoli: so'rov, buyruqlar va frame parse.
+type scaleDriver interface {
+	Name() string
+	// PollCommand har --poll-interval'da yuboriladi; nil = indikator o'zi oqim yuboradi.
+	PollCommand() []byte
+	// Command tare/zero/clear_tare buyrug'i baytlari (qo'llanmasa errOpUnsupported).
+	Command(op scaleOp) ([]byte, error)
+	// ParseFrame CR/LF'siz bitta frame. Protokolga mos kelmasa errFrameUnknown.
+	ParseFrame(frame string) (driverFrame, error)
+}
+
+// newScaleDriver --scale-driver qiymatidan driver. stream uchun nil: streamSerial ishlatiladi.
+func newScaleDriver(name string) (scaleDriver, error) {
+	switch normalizeDriverName(name) {
+	case driverStream:
+		return nil, nil
+	case driverSICS:
+		return sicsDriver{}, nil
+	case driverCAS:
+		return casDriver{}, nil
+	case driverAD:
+		return adDriver{}, nil
+	default:
+		return nil, fmt.Errorf("scale driver noma'lum: %q (stream, auto, sics, cas yoki ad)", name)
+	}
+}
+
+func normalizeDriverName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "":
+		return driverStream
+	case "mt-sics", "mettler":
+		return driverSICS
+	case "a&d", "and":
+		return driverAD
+	}
+	return name
+}
+
+// validateDriverName flag tekshiruvi (auto ham ruxsat).
+func validateDriverName(name string) error {
+	if normalizeDriverName(name) == driverAuto {
+		return nil
+	}
+	_, err := newScaleDriver(name)
+	return err
+}
+
+// classifyFrame frame qaysi aniq protokolga mos: auto-detect uchun. SICS (`S S ...`) boshqalarni
+// kesmaydi; `ST,GS,+00001.250kg` A&D va CAS'ga ham mos, A&D buyruqlarni ham qabul qilgani uchun u birinchi.
+func classifyFrame(frame string) string {
+	for _, d := range []scaleDriver{sicsDriver{}, adDriver{}, casDriver{}} {
+		if f, err := d.ParseFrame(frame); err == nil && f.Kind == frameWeight {
+			return d.Name()
+		}
+	}
+	return ""
+}
+
+// streamDriver src'dan frame'larni driver bilan parse qiladi; send bo'lsa har poll
+// oralig'ida PollCommand yuboriladi (replay'da send=nil). cmds'dan kelgan buyruq port'ga
+// yoziladi va indikatorning ack/xato javobi (yoki scaleCommandTimeout) bilan yakunlanadi.
+func streamDriver(ctx context.Context, src io.Reader, send func([]byte) error, cmds <-chan scaleCommandReq, drv scaleDriver, device string, baud int, unit string, poll time.Duration, now func() time.Time, emit func(Reading)) error {
+	lg := workerLog("worker.serial")
+	buf := make([]byte, 256)
+	pending := ""
+	lastUnit := strings.ToLower(strings.TrimSpace(unit))
+	if lastUnit == "" {
+		lastUnit = "kg"
+	}
+	var indicatorTare *float64
+	var lastPoll time.Time
+	pollCmd := drv.PollCommand()
+	var inflight *scaleCommandReq
+	var inflightAt time.Time
+	finish := func(err error) {
+		if inflight != nil {
+			inflight.done <- err
+			inflight = nil
+		}
+	}
+	// Port yopilsa (reconnect) kutayotgan buyruq xato bilan tugaydi.
+	defer finish(errors.New("serial port yopildi"))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		if inflight != nil && now().Sub(inflightAt) > scaleCommandTimeout {
+			lg.Printf("%s command timeout: op=%s", drv.Name(), inflight.op)
+			finish(fmt.Errorf("%s: indikator javob bermadi", inflight.op))
+		}
+		if inflight == nil && send != nil {
+			select {
+			case req := <-cmds:
+				b, err := drv.Command(req.op)
+				if err == nil {
+					err = send(b)
+				}
+				if err != nil {
+					req.done <- fmt.Errorf("%s: %w", req.op, err)
+					break
+				}
+				lg.Printf("%s command sent: op=%s raw=%q", drv.Name(), req.op, b)
+				inflight, inflightAt = &req, now()
+			default:
+			}
+		}
+
+		if send != nil && len(pollCmd) > 0 && now().Sub(lastPoll) >= poll {
+			if err := send(pollCmd); err != nil {
+				return fmt.Errorf("%s poll: %w", drv.Name(), err)
+			}
+			lastPoll = now()
+		}
+
+		n, err := src.Read(buf)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			continue
+		}
+		pending = appendRaw(pending, string(buf[:n]), 1024)
+
+		for {
+			frame, rest, ok := popSerialFrame(pending)
+			if !ok {
+				break
+			}
+			pending = rest
+			trimmed := strings.TrimSpace(frame)
+			if trimmed == "" {
+				continue
+			}
+
+			f, err := drv.ParseFrame(trimmed)
+			if f.Ack && inflight != nil {
+				lg.Printf("%s ack: op=%s raw=%q", drv.Name(), inflight.op, trimmed)
+				finish(nil)
+			}
+			if err != nil {
+				lg.Printf("frame parse miss: driver=%s raw=%q", drv.Name(), trimmed)
+				metricParseMisses.Inc()
+				emit(Reading{Source: "serial", Port: device, Baud: baud, Unit: lastUnit, Raw: trimmed, UpdatedAt: now()})
+				continue
+			}
+			if f.Tare != nil {
+				indicatorTare = nil
+				if *f.Tare > 0 {
+					v := *f.Tare
+					indicatorTare = &v
+				}
+			}
+			switch f.Kind {
+			case frameAck:
+				lg.Printf("%s ack: op=%s raw=%q", drv.Name(), f.Op, trimmed)
+				// A&D ACK'da op yo'q: kutayotgan buyruqqa tegishli.
+				if inflight != nil && (f.Op == "" || f.Op == inflight.op) {
+					finish(nil)
+				}
+				continue
+			case frameError:
+				lg.Printf("%s error: op=%s err=%s raw=%q", drv.Name(), f.Op, f.Err, trimmed)
+				if inflight != nil && (f.Op == "" || f.Op == inflight.op) {
+					finish(fmt.Errorf("%s: %s", inflight.op, f.Err))
+					if f.Op != "" {
+						continue
+					}
+				}
+				emit(Reading{Source: "serial", Port: device, Baud: baud, Unit: lastUnit, Raw: trimmed,
+					Error: drv.Name() + ": " + f.Err, UpdatedAt: now()})
+				continue
+			}
+
+			w := f.Weight
+			var frameTare *float64
+			if indicatorTare != nil {
+				v := *indicatorTare
+				frameTare = &v
+				if f.Net {
+					// Reading.Weight har doim brutto.
+					w += v
+				}
+			}
+			if f.Unit != "" {
+				lastUnit = f.Unit
+			}
+			lg.Printf("frame parsed: driver=%s weight=%.3f unit=%s stable=%s raw=%q", drv.Name(), w, lastUnit, stableText(f.Stable), trimmed)
+			emit(Reading{
+				Source:    "serial",
+				Port:      device,
+				Baud:      baud,
+				Weight:    &w,
+				Unit:      lastUnit,
+				Stable:    f.Stable,
+				Tare:      frameTare,
+				Raw:       trimmed,
+				UpdatedAt: now(),
+			})
+		}
+	}
+}
+
+// parseDriverNumber `+  1.234`, `-0012.5`, `+00123.45` kabi qat'iy son (bo'shliqlar belgi va
+// raqamlar orasida bo'lishi mumkin).
+func parseDriverNumber(s string) (float64, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if s == "" {
+		return 0, false
+	}
+	digits := strings.TrimLeft(s, "+-")
+	if digits == "" || len(s)-len(digits) > 1 {
+		return 0, false
+	}
+	for _, r := range digits {
+		if (r < '0' || r > '9') && r != '.' {
+			return 0, false
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// normalizeDriverUnit protokol birligini Reading birligiga: `kg`, `g`, `lb`, `oz`.
+func normalizeDriverUnit(u string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(u)) {
+	case "kg":
+		return "kg", true
+	case "g":
+		return "g", true
+	case "lb", "lbs":
+		return "lb", true
+	case "oz":
+		return "oz", true
+	}
+	return "", false
+}
+
+func boolPtr(v bool) *bool { return &v }
+
+// driverName log/TUI uchun: nil driver = stream.
+func driverName(drv scaleDriver) string {
+	if drv == nil {
+		return driverStream
+	}
+	return drv.Name()
+}
@@ -0,0 +1,78 @@
+package main
+
+import "strings"
+
+const asciiACK = "\x06"
+
+// adDriver A&D formatlari: tarozi `ST,+00123.45  g` va indikator `ST,GS,+00001.250kg`
+// (ST/US/OL/QT header, GS/NT, belgili 9 belgili qiymat, birlik). `Q` bilan so'raladi,
+// tara `T`, nol `R` (RE-ZERO).
+type adDriver struct{}
+
+func (adDriver) Name() string { return driverAD }
+
+func (adDriver) PollCommand() []byte { return []byte("Q\r\n") }
+
+func (adDriver) Command(op scaleOp) ([]byte, error) {
+	switch op {
+	case scaleOpTare:
+		return []byte("T\r\n"), nil
+	case scaleOpZero:
+		return []byte("R\r\n"), nil
+	}
+	return nil, errOpUnsupported
+}
+
+func (adDriver) ParseFrame(frame string) (driverFrame, error) {
+	// AK rejimida buyruq tasdig'i CR/LF'siz ACK bayt: keyingi frame boshida keladi
+	// (`\x06ST,GS,...`). ACK yo'qolmasin: frame o'zi parse qilinadi, Ack esa belgilanadi.
+	body := strings.TrimLeft(frame, asciiACK)
+	if body == "" {
+		return driverFrame{Kind: frameAck}, nil
+	}
+	f, err := parseADFrame(body)
+	f.Ack = len(body) < len(frame)
+	return f, err
+}
+
+// parseADFrame ACK'siz A&D frame.
+func parseADFrame(frame string) (driverFrame, error) {
+	if code, ok := strings.CutPrefix(frame, "EC,"); ok {
+		return driverFrame{Kind: frameError, Err: "xato kodi " + strings.TrimSpace(code)}, nil
+	}
+
+	header, rest, ok := strings.Cut(frame, ",")
+	if !ok {
+		return driverFrame{}, errFrameUnknown
+	}
+	switch header {
+	case "ST", "US", "QT":
+	case "OL":
+		return driverFrame{Kind: frameError, Err: "overload (OL)"}, nil
+	default:
+		return driverFrame{}, errFrameUnknown
+	}
+	net := false
+	if mode, body, ok := strings.Cut(rest, ","); ok {
+		if mode != "GS" && mode != "NT" {
+			return driverFrame{}, errFrameUnknown
+		}
+		net, rest = mode == "NT", body
+	}
+	// Qiymat belgili va nol bilan to'ldirilgan (`+00123.45`, `+00001.250`), bo'shliqsiz.
+	end := len(rest) - len(strings.TrimLeft(rest[min(1, len(rest)):], "0123456789."))
+	if end < 8 || (rest[0] != '+' && rest[0] != '-') {
+		return driverFrame{}, errFrameUnknown
+	}
+	value, unitField := rest[:end], rest[end:]
+	w, ok := parseDriverNumber(value)
+	if !ok {
+		return driverFrame{}, errFrameUnknown
+	}
+	unit, ok := normalizeDriverUnit(unitField)
+	if !ok {
+		// `PC` (dona), `%` va boshqalar vazn emas.
+		return driverFrame{}, errFrameUnknown
+	}
+	return driverFrame{Kind: frameWeight, Weight: w, Unit: unit, Stable: boolPtr(header != "US"), Net: net}, nil
+}
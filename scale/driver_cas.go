@@ -0,0 +1,56 @@
+package main
+
+import "strings"
+
+// casDriver CAS indikatorlari (CI/DB seriya) oqim formati: `ST,GS,+  1.234kg`,
+// ixtiyoriy qurilma raqami bilan `ST,NT,01,+001.234 kg`. Nol bilan to'ldirilgan 9 belgili
+// qiymat (`+00001.250kg`) A&D ham bo'lishi mumkin: auto-detect avval A&D'ni tanlaydi. Indikator o'zi uzluksiz yuboradi;
+// tara/nol buyrug'i oqim rejimida yo'q (indikator tugmasi).
+type casDriver struct{}
+
+func (casDriver) Name() string { return driverCAS }
+
+func (casDriver) PollCommand() []byte { return nil }
+
+func (casDriver) Command(scaleOp) ([]byte, error) { return nil, errOpUnsupported }
+
+func (casDriver) ParseFrame(frame string) (driverFrame, error) {
+	parts := strings.Split(frame, ",")
+	if len(parts) < 3 || len(parts) > 4 {
+		return driverFrame{}, errFrameUnknown
+	}
+	state, mode := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if mode != "GS" && mode != "NT" {
+		return driverFrame{}, errFrameUnknown
+	}
+	if len(parts) == 4 {
+		id := strings.TrimSpace(parts[2])
+		if id == "" || strings.Trim(id, "0123456789") != "" {
+			return driverFrame{}, errFrameUnknown
+		}
+	}
+	data := parts[len(parts)-1]
+
+	switch state {
+	case "ST", "US":
+	case "OL":
+		return driverFrame{Kind: frameError, Err: "overload (OL)"}, nil
+	default:
+		return driverFrame{}, errFrameUnknown
+	}
+
+	data = strings.TrimSpace(data)
+	cut := strings.LastIndexAny(data, "0123456789.")
+	if cut < 0 {
+		return driverFrame{}, errFrameUnknown
+	}
+	w, ok := parseDriverNumber(data[:cut+1])
+	if !ok {
+		return driverFrame{}, errFrameUnknown
+	}
+	unit, ok := normalizeDriverUnit(data[cut+1:])
+	if !ok {
+		return driverFrame{}, errFrameUnknown
+	}
+	return driverFrame{Kind: frameWeight, Weight: w, Unit: unit, Stable: boolPtr(state == "ST"), Net: mode == "NT"}, nil
+}
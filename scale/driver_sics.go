@@ -0,0 +1,113 @@
+package main
+
+import "strings"
+
+// sicsDriver Mettler Toledo MT-SICS (level 0/1): `SI` bilan so'raladi, `S`/`SI` javobi
+// `S S|D <vazn> <birlik>`, tara `T`, nol `Z`, tarani tozalash `TAC`.
+type sicsDriver struct{}
+
+func (sicsDriver) Name() string { return driverSICS }
+
+// PollCommand `SI` - darhol javob (barqaror `S S` yoki dinamik `S D`); `S` barqarorlikni kutadi.
+func (sicsDriver) PollCommand() []byte { return []byte("SI\r\n") }
+
+func (sicsDriver) Command(op scaleOp) ([]byte, error) {
+	switch op {
+	case scaleOpTare:
+		return []byte("T\r\n"), nil
+	case scaleOpZero:
+		return []byte("Z\r\n"), nil
+	case scaleOpClearTare:
+		return []byte("TAC\r\n"), nil
+	}
+	return nil, errOpUnsupported
+}
+
+func (sicsDriver) ParseFrame(frame string) (driverFrame, error) {
+	f := strings.Fields(frame)
+	if len(f) == 0 {
+		return driverFrame{}, errFrameUnknown
+	}
+	switch f[0] {
+	case "ES":
+		return driverFrame{Kind: frameError, Err: "sintaksis xato (ES)"}, nil
+	case "ET":
+		return driverFrame{Kind: frameError, Err: "uzatish xatosi (ET)"}, nil
+	case "EL":
+		return driverFrame{Kind: frameError, Err: "buyruq bajarilmaydi (EL)"}, nil
+	}
+	if len(f) < 2 {
+		return driverFrame{}, errFrameUnknown
+	}
+
+	switch f[0] {
+	case "S":
+		switch f[1] {
+		case "S", "D":
+			w, unit, ok := sicsValue(f[2:])
+			if !ok {
+				return driverFrame{}, errFrameUnknown
+			}
+			// SICS vazni doim netto (tara bo'lmasa brutto bilan bir xil).
+			return driverFrame{Kind: frameWeight, Weight: w, Unit: unit, Stable: boolPtr(f[1] == "S"), Net: true}, nil
+		}
+		if err, ok := sicsStatusErr(f[1]); ok && len(f) == 2 {
+			return driverFrame{Kind: frameError, Err: err}, nil
+		}
+	case "T", "TA":
+		op := driverFrame{Op: scaleOpTare}
+		if (f[0] == "T" && f[1] == "S") || (f[0] == "TA" && f[1] == "A") {
+			w, unit, ok := sicsValue(f[2:])
+			if !ok {
+				return driverFrame{}, errFrameUnknown
+			}
+			op.Kind, op.Tare, op.Unit = frameAck, &w, unit
+			return op, nil
+		}
+		if err, ok := sicsStatusErr(f[1]); ok && len(f) == 2 {
+			op.Kind, op.Err = frameError, "tara: "+err
+			return op, nil
+		}
+	case "TAC":
+		if f[1] == "A" && len(f) == 2 {
+			zero := 0.0
+			return driverFrame{Kind: frameAck, Op: scaleOpClearTare, Tare: &zero}, nil
+		}
+		if err, ok := sicsStatusErr(f[1]); ok && len(f) == 2 {
+			return driverFrame{Kind: frameError, Op: scaleOpClearTare, Err: "tara tozalash: " + err}, nil
+		}
+	case "Z":
+		if f[1] == "A" && len(f) == 2 {
+			return driverFrame{Kind: frameAck, Op: scaleOpZero}, nil
+		}
+		if err, ok := sicsStatusErr(f[1]); ok && len(f) == 2 {
+			return driverFrame{Kind: frameError, Op: scaleOpZero, Err: "nol: " + err}, nil
+		}
+	}
+	return driverFrame{}, errFrameUnknown
+}
+
+// sicsValue `<vazn> <birlik>` maydonlari.
+func sicsValue(f []string) (float64, string, bool) {
+	if len(f) != 2 {
+		return 0, "", false
+	}
+	w, ok := parseDriverNumber(f[0])
+	if !ok {
+		return 0, "", false
+	}
+	unit, ok := normalizeDriverUnit(f[1])
+	return w, unit, ok
+}
+
+func sicsStatusErr(status string) (string, bool) {
+	switch status {
+	case "I":
+		return "hozir bajarilmaydi (I)", true
+	case "+":
+		return "overload (+)", true
+	case "-":
+		return "underload (-)", true
+	}
+	return "", false
+}
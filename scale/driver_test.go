@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDriverParseFrame(t *testing.T) {
+	cases := []struct {
+		drv    scaleDriver
+		frame  string
+		kind   frameKind
+		weight float64
+		unit   string
+		stable bool
+		net    bool
+	}{
+		{sicsDriver{}, "S S      1.250 kg", frameWeight, 1.25, "kg", true, true},
+		{sicsDriver{}, "S D     -12.5 g", frameWeight, -12.5, "g", false, true},
+		{sicsDriver{}, "Z A", frameAck, 0, "", false, false},
+		{sicsDriver{}, "S +", frameError, 0, "", false, false},
+		{sicsDriver{}, "ES", frameError, 0, "", false, false},
+		{adDriver{}, "ST,+00123.45  g", frameWeight, 123.45, "g", true, false},
+		{adDriver{}, "US,NT,-0001.250kg", frameWeight, -1.25, "kg", false, true},
+		{adDriver{}, "\x06", frameAck, 0, "", false, false},
+		{adDriver{}, "\x06ST,GS,+00001.250kg", frameWeight, 1.25, "kg", true, false},
+		{adDriver{}, "OL,+9999999 kg", frameError, 0, "", false, false},
+		{adDriver{}, "EC,E1", frameError, 0, "", false, false},
+		{casDriver{}, "ST,GS,+  1.234kg", frameWeight, 1.234, "kg", true, false},
+		{casDriver{}, "US,NT,01,-  0.500 kg", frameWeight, -0.5, "kg", false, true},
+	}
+	for _, tc := range cases {
+		f, err := tc.drv.ParseFrame(tc.frame)
+		if err != nil || f.Kind != tc.kind {
+			t.Fatalf("%s %q: %+v err=%v", tc.drv.Name(), tc.frame, f, err)
+		}
+		if tc.kind != frameWeight {
+			continue
+		}
+		if f.Weight != tc.weight || f.Unit != tc.unit || f.Stable == nil || *f.Stable != tc.stable || f.Net != tc.net {
+			t.Fatalf("%s %q: %+v", tc.drv.Name(), tc.frame, f)
+		}
+	}
+
+	// ACK keyingi frame'ga yopishgan: vazn ham, tasdiq ham yo'qolmaydi.
+	if f, err := (adDriver{}).ParseFrame("\x06ST,GS,+00001.250kg"); err != nil || !f.Ack {
+		t.Fatalf("ack + weight: %+v err=%v", f, err)
+	}
+	if f, _ := (adDriver{}).ParseFrame("ST,GS,+00001.250kg"); f.Ack {
+		t.Fatalf("plain frame must not ack: %+v", f)
+	}
+
+	// Aniq parse: boshqa protokol yoki buzilgan frame qabul qilinmaydi.
+	for _, bad := range []struct {
+		drv   scaleDriver
+		frame string
+	}{
+		{sicsDriver{}, "ST,GS,+0001.250kg"},
+		{sicsDriver{}, "S S 1.2.5 kg"},
+		{sicsDriver{}, "S S 1.250"},
+		{adDriver{}, "ST,GS,+  1.234kg"},
+		{adDriver{}, "ST,+00012.00 PC"},
+		{casDriver{}, "S S      1.250 kg"},
+		{casDriver{}, "ST,XX,+  1.234kg"},
+		{casDriver{}, "ST,GS,+  1.2x4kg"},
+	} {
+		if f, err := bad.drv.ParseFrame(bad.frame); err == nil {
+			t.Fatalf("%s %q should fail: %+v", bad.drv.Name(), bad.frame, f)
+		}
+	}
+}
+
+func TestClassifyFrame(t *testing.T) {
+	for frame, want := range map[string]string{
+		"S S      0.000 kg":  driverSICS,
+		"ST,+00000.00  g":    driverAD,
+		"ST,GS,+00001.250kg": driverAD,
+		"ST,GS,+  1.250kg":   driverCAS,
+		"ST,GS,01,+1.250kg":  driverCAS,
+		"  1.250 kg":         "",
+		"ES":                 "",
+	} {
+		if got := classifyFrame(frame); got != want {
+			t.Fatalf("%q: got %q want %q", frame, got, want)
+		}
+	}
+	if err := validateDriverName("MT-SICS"); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateDriverName("ohaus"); err == nil {
+		t.Fatal("unknown driver should fail")
+	}
+}
+
+// scriptedPort so'rov kelganda keyingi javobni qaytaradi.
+type scriptedPort struct {
+	sent    bytes.Buffer
+	replies []string
+	out     strings.Reader
+}
+
+func (p *scriptedPort) send(b []byte) error {
+	p.sent.Write(b)
+	if len(p.replies) == 0 {
+		return nil
+	}
+	p.out = *strings.NewReader(p.replies[0])
+	p.replies = p.replies[1:]
+	return nil
+}
+
+func (p *scriptedPort) Read(b []byte) (int, error) {
+	if p.out.Len() == 0 && len(p.replies) == 0 {
+		return 0, io.EOF
+	}
+	n, _ := p.out.Read(b)
+	return n, nil
+}
+
+func TestStreamDriver_PollsAndAppliesTare(t *testing.T) {
+	port := &scriptedPort{replies: []string{"T S 0.400 kg\r\n", "S D 0.900 kg\r\n", "S S 0.800 kg\r\n", "S I\r\n"}}
+	at := time.Unix(1_700_000_000, 0)
+	now := func() time.Time {
+		at = at.Add(100 * time.Millisecond)
+		return at
+	}
+	var got []Reading
+	err := streamDriver(context.Background(), port, port.send, nil, sicsDriver{}, "/dev/ttyUSB1", 9600, "kg", 100*time.Millisecond, now, func(r Reading) {
+		got = append(got, r)
+	})
+	if err != io.EOF {
+		t.Fatalf("err=%v", err)
+	}
+	// To'rt javob + EOF'dan oldingi oxirgi so'rov.
+	if s := port.sent.String(); s != strings.Repeat("SI\r\n", 5) {
+		t.Fatalf("sent=%q", s)
+	}
+	if len(got) != 3 {
+		t.Fatalf("readings=%+v", got)
+	}
+	if got[1].Weight == nil || math.Abs(*got[1].Weight-1.2) > 1e-9 || got[1].Tare == nil || *got[1].Tare != 0.4 || !*got[1].Stable {
+		t.Fatalf("stable gross/tare: %+v", got[1])
+	}
+	if n := applyTare(nil, got[1]).netWeight(); n == nil || math.Abs(*n-0.8) > 1e-9 {
+		t.Fatalf("net=%v", n)
+	}
+	if !strings.Contains(got[2].Error, "sics:") {
+		t.Fatalf("error frame: %+v", got[2])
+	}
+}
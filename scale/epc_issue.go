@@ -0,0 +1,68 @@
+package main
+
+import (
+	corepkg "core"
+	"strings"
+	"time"
+)
+
+// epcIssuer encode'dan oldin EPC ni core registry'da band qiladi (--epc-registry, status
+// reserved), encode tugagach natijani settle bilan yozadi. Registry o'chirilgan bo'lsa (nil)
+// EPC o'zgarishsiz qaytadi.
+type epcIssuer struct {
+	registry *corepkg.EPCRegistry
+	station  string
+}
+
+func newEPCIssuer(registry *corepkg.EPCRegistry, station string) *epcIssuer {
+	if registry == nil {
+		return nil
+	}
+	return &epcIssuer{registry: registry, station: station}
+}
+
+// issue epc ni ro'yxatdan o'tkazadi. scheme - epc ni yaratgan sxema: registry'ga shu nom
+// yoziladi va dublikat o'rniga shundan yangi EPC olinadi. nil bo'lsa (EPC tashqaridan
+// berilgan) sxema "external", dublikat esa xato bo'lib qaytadi.
+func (i *epcIssuer) issue(epc string, scheme corepkg.EPCScheme, qty *float64, unit, itemCode, itemName string, at time.Time) (string, error) {
+	if i == nil || i.registry == nil {
+		return epc, nil
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	rec := corepkg.EPCRecord{
+		EPC:      epc,
+		ItemCode: strings.TrimSpace(itemCode),
+		ItemName: strings.TrimSpace(itemName),
+		Unit:     strings.TrimSpace(unit),
+		Station:  i.station,
+		IssuedAt: at.UTC().Format(time.RFC3339Nano),
+		Status:   corepkg.EPCStatusReserved,
+	}
+	if qty != nil {
+		rec.Qty = *qty
+	}
+	if scheme == nil {
+		rec.Scheme = "external"
+	}
+	return i.registry.Issue(scheme, rec)
+}
+
+// settle encode natijasini registry'ga yozadi: verify muvaffaqiyatli bo'lsa encoded, aks holda
+// failed (xato yoki verify qiymati bilan). Yozilmasa faqat log: label allaqachon chiqqan.
+func (i *epcIssuer) settle(epc string, st ZebraStatus) {
+	if i == nil || i.registry == nil || strings.TrimSpace(epc) == "" {
+		return
+	}
+	status, detail := corepkg.EPCStatusEncoded, ""
+	switch {
+	case strings.TrimSpace(st.Error) != "":
+		status, detail = corepkg.EPCStatusFailed, strings.TrimSpace(st.Error)
+	case !isVerifySuccess(st.Verify):
+		status, detail = corepkg.EPCStatusFailed, "verify="+safeText("-", st.Verify)
+	}
+	if err := i.registry.Settle(epc, status, detail, st.UpdatedAt); err != nil {
+		workerLog("worker.epc").Printf("settle error: epc=%s status=%s err=%v", epc, status, err)
+	}
+}
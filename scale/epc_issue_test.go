@@ -0,0 +1,112 @@
+package main
+
+import (
+	corepkg "core"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEPCIssuerRegistersAndRegenerates(t *testing.T) {
+	if epc, err := (*epcIssuer)(nil).issue("30AA", nil, nil, "", "", "", time.Now()); err != nil || epc != "30AA" {
+		t.Fatalf("disabled issuer: epc=%s err=%v", epc, err)
+	}
+
+	reg, err := corepkg.OpenEPCRegistry(filepath.Join(t.TempDir(), "epc.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := newEPCIssuer(reg, "line-1")
+	scheme := corepkg.NewTimeEPCScheme()
+	qty := 1.5
+	at := time.Unix(1_700_000_000, 0)
+	first, err := issuer.issue("30AA", scheme, &qty, "kg", "ITM-1", "Item 1", at)
+	if err != nil || first != "30AA" {
+		t.Fatalf("first: epc=%s err=%v", first, err)
+	}
+	rec, ok, _ := reg.Lookup("30AA")
+	if !ok || rec.ItemCode != "ITM-1" || rec.Qty != 1.5 || rec.Station != "line-1" {
+		t.Fatalf("record=%+v ok=%v", rec, ok)
+	}
+
+	second, err := issuer.issue("30AA", scheme, &qty, "kg", "ITM-1", "Item 1", at)
+	if err != nil || second == "30AA" || len(second) != 24 {
+		t.Fatalf("regenerate: epc=%s err=%v", second, err)
+	}
+	if _, err := issuer.issue("30AA", nil, &qty, "kg", "", "", at); !errors.Is(err, corepkg.ErrEPCDuplicate) {
+		t.Fatalf("explicit duplicate should fail, got %v", err)
+	}
+}
+
+func TestEPCIssuerRecordsGeneratingScheme(t *testing.T) {
+	reg, err := corepkg.OpenEPCRegistry(filepath.Join(t.TempDir(), "epc.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := newEPCIssuer(reg, "line-1")
+	sgtin, err := corepkg.NewSGTINScheme("0614141", 1, corepkg.NewMemorySerialAllocator(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := corepkg.DefaultStableEPCConfig()
+	dc.Scheme = sgtin
+	auto := autoEncode{detector: corepkg.NewStableEPCDetector(dc), sgtin: sgtin, issuer: issuer}
+	at := time.Unix(1_700_000_000, 0)
+
+	// Qo'lda (`e`) yo'l: EPC qaysi sxemadan olingan bo'lsa registry'da shu sxema.
+	for _, tc := range []struct {
+		name string
+		auto autoEncode
+		gtin string
+		want string
+	}{
+		{"sgtin96", auto, "80614141123458", corepkg.EPCSchemeSGTIN96},
+		{"time", autoEncode{issuer: issuer}, "", corepkg.EPCSchemeTime},
+	} {
+		epc, scheme, err := tc.auto.nextEPC(tc.gtin, at)
+		if err != nil {
+			t.Fatalf("%s next: %v", tc.name, err)
+		}
+		epc, err = tc.auto.issuer.issue(epc, scheme, nil, "kg", "ITM-1", "", at)
+		if err != nil {
+			t.Fatalf("%s issue: %v", tc.name, err)
+		}
+		rec, ok, _ := reg.Lookup(epc)
+		if !ok || rec.Scheme != tc.want {
+			t.Fatalf("%s record scheme=%q want %q (%+v)", tc.name, rec.Scheme, tc.want, rec)
+		}
+	}
+
+	ext, err := issuer.issue("30CC", nil, nil, "kg", "ITM-1", "", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, _, _ := reg.Lookup(ext); rec.Scheme != "external" {
+		t.Fatalf("external record scheme=%q", rec.Scheme)
+	}
+}
+
+func TestEPCIssuerSettlesEncodeResult(t *testing.T) {
+	reg, err := corepkg.OpenEPCRegistry(filepath.Join(t.TempDir(), "epc.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := newEPCIssuer(reg, "line-1")
+	at := time.Unix(1_700_000_000, 0)
+	ok, _ := issuer.issue("30AA", nil, nil, "kg", "ITM-1", "", at)
+	bad, _ := issuer.issue("30BB", nil, nil, "kg", "ITM-1", "", at)
+	if rec, _, _ := reg.Lookup(ok); rec.Status != corepkg.EPCStatusReserved {
+		t.Fatalf("issued epc must be reserved until encode: %+v", rec)
+	}
+
+	issuer.settle(ok, ZebraStatus{Verify: "WRITTEN", UpdatedAt: at})
+	issuer.settle(bad, ZebraStatus{Verify: "UNKNOWN", Error: "printer busy", UpdatedAt: at})
+	if rec, _, _ := reg.Lookup(ok); rec.Status != corepkg.EPCStatusEncoded || rec.Error != "" {
+		t.Fatalf("encoded record: %+v", rec)
+	}
+	if rec, _, _ := reg.Lookup(bad); rec.Status != corepkg.EPCStatusFailed || rec.Error != "printer busy" {
+		t.Fatalf("failed record: %+v", rec)
+	}
+	(*epcIssuer)(nil).settle("30AA", ZebraStatus{})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bridge/ipc"
+	bridgestate "bridge/state"
+	"context"
+	"strings"
+	"time"
+)
+
+// startIPCBus scale hosts qiladigan bus'ni ochadi va bot request'lari uchun handler'larni ulaydi.
+func startIPCBus(ctx context.Context, path string, store *bridgestate.Store, ctl *scaleControl) (*ipc.Server, error) {
+	srv, err := ipc.Listen(path)
+	if err != nil {
+		return nil, err
+	}
+
+	batchHandler := func(active bool) ipc.Handler {
+		return func(_ context.Context, req ipc.Message) ipc.Message {
+			resp := ipc.Reply(req, ipc.TypeAck)
+			if err := writeBatchFromBus(store, active, req.Batch); err != nil {
+				resp.Error = err.Error()
+			}
+			return resp
+		}
+	}
+	srv.Handle(ipc.TypeBatchStart, batchHandler(true))
+	srv.Handle(ipc.TypeBatchStop, batchHandler(false))
+
+	srv.Handle(ipc.TypeScaleCommand, func(ctx context.Context, req ipc.Message) ipc.Message {
+		resp := ipc.Reply(req, ipc.TypeScaleCommandAck)
+		if req.Command == nil || ctl == nil {
+			resp.Error = "scale command bo'sh yoki scale buyruqlari yo'q"
+			return resp
+		}
+		done := ctl.run(ctx, *req.Command)
+		resp.Command = &done
+		resp.Error = done.Error
+		return resp
+	})
+
+	go func() {
+		if err := srv.Serve(ctx); err != nil {
+			workerLog("main").Printf("ipc serve error: %v", err)
+		}
+	}()
+	return srv, nil
+}
+
+func writeBatchFromBus(store *bridgestate.Store, active bool, in *bridgestate.BatchSnapshot) error {
+	if store == nil {
+		return nil
+	}
+	b := bridgestate.BatchSnapshot{}
+	if in != nil {
+		b = *in
+	}
+	b.Active = active
+	if !active {
+		b = bridgestate.BatchSnapshot{Active: false, ChatID: b.ChatID}
+	}
+	b.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	return store.Update(func(s *bridgestate.Snapshot) {
+		s.Batch = b
+	})
+}
+
+// publishScaleReading har scale o'qishini bus'ga event sifatida yuboradi.
+func publishScaleReading(bus *ipc.Server, rd Reading) {
+	if bus == nil {
+		return
+	}
+	snap := scaleSnapshotOf(rd)
+	bus.Publish(ipc.Message{Type: ipc.TypeScaleReading, Scale: &snap})
+}
+
+// publishZebraResult encode/read natijasini, uni qo'zg'atgan scale o'qishi bilan birga yuboradi.
+func publishZebraResult(bus *ipc.Server, st ZebraStatus, trigger Reading) {
+	if bus == nil || strings.TrimSpace(st.Action) == "" {
+		return
+	}
+	zs := zebraSnapshotOf(st, time.Now())
+	msg := ipc.Message{Type: ipc.TypeZebraResult, Zebra: &zs}
+	if !trigger.UpdatedAt.IsZero() {
+		ss := scaleSnapshotOf(trigger)
+		msg.Scale = &ss
+	}
+	bus.Publish(msg)
+}
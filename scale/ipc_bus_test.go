@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bridge/ipc"
+	bridgestate "bridge/state"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIPCBus_BatchStartWritesBridgeState(t *testing.T) {
+	d := t.TempDir()
+	store := bridgestate.New(filepath.Join(d, "bridge_state.json"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv, err := startIPCBus(ctx, filepath.Join(d, "bridge.sock"), store, nil)
+	if err != nil {
+		t.Fatalf("startIPCBus error: %v", err)
+	}
+	defer srv.Close()
+
+	c := ipc.NewClient(srv.Path())
+	defer c.Close()
+	rctx, rcancel := context.WithTimeout(ctx, 2*time.Second)
+	defer rcancel()
+
+	if _, err := c.Request(rctx, ipc.Message{
+		Type:  ipc.TypeBatchStart,
+		Batch: &bridgestate.BatchSnapshot{ChatID: 7, ItemCode: "ITEM-1", ItemName: "Olma"},
+	}); err != nil {
+		t.Fatalf("batch_start error: %v", err)
+	}
+	snap, err := store.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snap.Batch.Active || snap.Batch.ItemCode != "ITEM-1" || snap.Batch.ChatID != 7 {
+		t.Fatalf("batch mismatch: %+v", snap.Batch)
+	}
+
+	if _, err := c.Request(rctx, ipc.Message{Type: ipc.TypeBatchStop, Batch: &bridgestate.BatchSnapshot{ChatID: 7}}); err != nil {
+		t.Fatalf("batch_stop error: %v", err)
+	}
+	snap, _ = store.Read()
+	if snap.Batch.Active || snap.Batch.ItemCode != "" {
+		t.Fatalf("batch should be cleared: %+v", snap.Batch)
+	}
+}
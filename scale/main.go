@@ -1,7 +1,12 @@
 package main
 
 import (
+	"bridge/httpapi"
+	"bridge/ipc"
+	"bridge/metrics"
+	bridgestate "bridge/state"
 	"context"
+	corepkg "core"
 	"errors"
 	"fmt"
 	"os"
@@ -29,26 +34,39 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	if strings.TrimSpace(cfg.replay) != "" {
+		if err := runReplay(ctx, cfg, os.Stdout); err != nil {
+			exitErr(err)
+		}
+		return
+	}
+
+	// Metrikalar reader goroutine'lardan oldin yaratiladi.
+	if strings.TrimSpace(cfg.metricsAddr) != "" {
+		addr, err := metrics.Start(ctx, cfg.metricsAddr, initMetrics(cfg.stationID))
+		if err != nil {
+			workerLog("main").Printf("metrics warning: %v", err)
+			fmt.Fprintf(os.Stderr, "warning: metrics ochilmadi: %v\n", err)
+		} else {
+			workerLog("main").Printf("metrics started: addr=%s", addr)
+		}
+	}
+
 	updates := make(chan Reading, 32)
 	var zebraUpdates <-chan ZebraStatus
 	var sourceLine string
 	var serialErr error
-	started := false
-
-	port, usedBaud, err := detectScalePort(cfg.device, cfg.bauds, cfg.probeTimeout, cfg.unit)
-	if err == nil {
-		if startErr := startSerialReader(ctx, port, usedBaud, cfg.unit, updates); startErr == nil {
-			workerLog("main").Printf("serial reader started: device=%s baud=%d", port, usedBaud)
-			sourceLine = fmt.Sprintf("serial (%s @ %d)", port, usedBaud)
-			started = true
-		} else {
-			workerLog("main").Printf("serial reader start error: %v", startErr)
-			serialErr = startErr
-		}
-	} else {
-		workerLog("main").Printf("serial detect error: %v", err)
-		serialErr = err
+	var cmdr *scaleCommander
+
+	switch {
+	case cfg.source.Kind == sourceSerial:
+		cmdr, sourceLine, serialErr = startSerialSource(ctx, cfg, updates)
+	case cfg.source.isModbus():
+		sourceLine, serialErr = startModbusSource(ctx, cfg, updates)
+	default:
+		cmdr, sourceLine, serialErr = startTCPSource(ctx, cfg, updates)
 	}
+	started := serialErr == nil
 
 	if !started && !cfg.disableBridge && strings.TrimSpace(cfg.bridgeURL) != "" {
 		startBridgeReader(ctx, strings.TrimSpace(cfg.bridgeURL), cfg.bridgeInterval, updates)
@@ -71,6 +89,80 @@ func main() {
 		zebraUpdates = zch
 	}
 
+	bridgeStore := bridgestate.NewWithOptions(cfg.bridgeStateFile, bridgestate.Options{
+		JournalDir: cfg.bridgeJournal,
+		StationID:  cfg.stationID,
+		Backend:    cfg.bridgeBackend,
+	})
+	defer bridgeStore.Close()
+	workerLog("main").Printf("bridge station: id=%s backend=%s file=%s", bridgeStore.StationID(), cfg.bridgeBackend, bridgeStore.Path())
+	if j := bridgeStore.Journal(); j != nil {
+		workerLog("main").Printf("bridge journal enabled: dir=%s", j.Dir())
+	}
+
+	var epcRegistry *corepkg.EPCRegistry
+	if strings.TrimSpace(cfg.epcRegistry) != "" {
+		reg, err := corepkg.OpenEPCRegistry(cfg.epcRegistry)
+		if err != nil {
+			// Registry so'ralgan, lekin ochilmadi: unikalligi tekshirilmagan EPC chiqarmaymiz.
+			exitErr(fmt.Errorf("epc registry: %w", err))
+		}
+		epcRegistry = reg
+		workerLog("main").Printf("epc registry: file=%s records=%d", reg.Path(), reg.Len())
+	}
+	scheme, err := newEPCScheme(cfg)
+	if err != nil {
+		exitErr(fmt.Errorf("epc scheme: %w", err))
+	}
+	detector, err := newAutoDetector(cfg, scheme)
+	if err != nil {
+		exitErr(err)
+	}
+	workerLog("main").Printf("auto encode stability: strategy=%s scheme=%s", detector.Strategy().Name(), scheme.Name())
+	issuer := newEPCIssuer(epcRegistry, bridgeStore.StationID())
+	auto := autoEncode{detector: detector, issuer: issuer, checkPolicy: cfg.checkPolicy, zero: newZeroGuard(cfg)}
+	auto.sgtin, _ = scheme.(*corepkg.SGTINScheme)
+	workerLog("main").Printf("zero tracking: policy=%s band=%.4f drift=%.4f", cfg.zeroPolicy, cfg.zeroCfg.Band, cfg.zeroCfg.DriftLimit)
+	if strings.TrimSpace(cfg.checkRules) != "" {
+		checker, err := corepkg.LoadCheckWeigher(cfg.checkRules)
+		if err != nil {
+			exitErr(err)
+		}
+		auto.checker = checker
+		workerLog("main").Printf("check-weigh: rules=%s policy=%s", cfg.checkRules, cfg.checkPolicy)
+	}
+
+	tare, err := newTareRegister(cfg)
+	if err != nil {
+		exitErr(err)
+	}
+	ctl := &scaleControl{cmdr: cmdr, tare: tare, zero: auto.zero, store: bridgeStore}
+	watchScaleCommands(ctx, bridgeStore, ctl)
+
+	// Bus bot'dan oldin ochiladi, shunda bot birinchi urinishdayoq ulanadi.
+	var bus *ipc.Server
+	if strings.TrimSpace(cfg.ipcSocket) != "" {
+		srv, err := startIPCBus(ctx, cfg.ipcSocket, bridgeStore, ctl)
+		if err != nil {
+			workerLog("main").Printf("ipc bus warning: %v", err)
+			fmt.Fprintf(os.Stderr, "warning: ipc bus ochilmadi: %v\n", err)
+		} else {
+			bus = srv
+			defer bus.Close()
+			workerLog("main").Printf("ipc bus started: socket=%s", bus.Path())
+		}
+	}
+
+	if strings.TrimSpace(cfg.httpAddr) != "" {
+		addr, err := httpapi.Start(ctx, cfg.httpAddr, bridgeStore)
+		if err != nil {
+			workerLog("main").Printf("http api warning: %v", err)
+			fmt.Fprintf(os.Stderr, "warning: http api ochilmadi: %v\n", err)
+		} else {
+			workerLog("main").Printf("http api started: addr=%s", addr)
+		}
+	}
+
 	var botProc *BotProcess
 	if !cfg.disableBot {
 		bp, err := startBotProcess(cfg.botDir)
@@ -88,7 +180,7 @@ func main() {
 		}
 	}
 
-	if err := runTUI(ctx, updates, zebraUpdates, sourceLine, cfg.zebraDevice, cfg.bridgeStateFile, cfg.disableBot, serialErr); err != nil {
+	if err := runTUI(ctx, updates, zebraUpdates, sourceLine, cfg.zebraDevice, bridgeStore, bus, auto, tare, ctl, cfg.disableBot, serialErr); err != nil {
 		workerLog("main").Printf("tui run error: %v", err)
 		cancel()
 		if botProc != nil {
@@ -100,3 +192,74 @@ func main() {
 		exitErr(err)
 	}
 }
+
+// startSerialSource port/baud/driver'ni aniqlab serial reader'ni ishga tushiradi.
+func startSerialSource(ctx context.Context, cfg appConfig, updates chan<- Reading) (*scaleCommander, string, error) {
+	port, usedBaud, usedDriver, err := detectScalePort(cfg.device, cfg.bauds, cfg.probeTimeout, cfg.unit, cfg.scaleDriver)
+	if err != nil {
+		workerLog("main").Printf("serial detect error: %v", err)
+		return nil, "", err
+	}
+	drv, err := newScaleDriver(usedDriver)
+	if err != nil {
+		workerLog("main").Printf("serial detect error: %v", err)
+		return nil, "", err
+	}
+	recorder := startTraceRecorder(cfg, port, usedBaud, drv)
+	cmdr := newScaleCommander(drv)
+	hp := newSerialHotplug(port, usedBaud, cfg.probeTimeout, cfg.unit, driverName(drv), strings.TrimSpace(cfg.device) != "")
+	if err := startSerialReader(ctx, port, usedBaud, cfg.unit, drv, cfg.pollInterval, cmdr, updates, recorder, hp); err != nil {
+		workerLog("main").Printf("serial reader start error: %v", err)
+		return nil, "", err
+	}
+	workerLog("main").Printf("serial reader started: device=%s baud=%d driver=%s", port, usedBaud, driverName(drv))
+	return cmdr, fmt.Sprintf("serial (%s @ %d, %s)", port, usedBaud, driverName(drv)), nil
+}
+
+// startTCPSource Ethernet indikator reader'i. TCP'da port so'ralmaydi: auto = stream,
+// sics/ad kerak bo'lsa --scale-driver bilan aniq beriladi.
+func startTCPSource(ctx context.Context, cfg appConfig, updates chan<- Reading) (*scaleCommander, string, error) {
+	name := cfg.scaleDriver
+	if name == driverAuto {
+		name = driverStream
+	}
+	drv, err := newScaleDriver(name)
+	if err != nil {
+		return nil, "", err
+	}
+	recorder := startTraceRecorder(cfg, cfg.source.String(), 0, drv)
+	cmdr := newScaleCommander(drv)
+	if err := startTCPReader(ctx, cfg.source, cfg.unit, drv, cfg.pollInterval, cfg.tcpKeepAlive, cfg.tcpIdleTimeout, cmdr, updates, recorder); err != nil {
+		workerLog("main").Printf("tcp reader start error: %v", err)
+		return nil, "", err
+	}
+	workerLog("main").Printf("tcp reader started: source=%s driver=%s", cfg.source, driverName(drv))
+	return cmdr, fmt.Sprintf("%s (%s, %s)", cfg.source.Kind, cfg.source.Addr, driverName(drv)), nil
+}
+
+// startModbusSource Modbus RTU/TCP transmitter reader'i. Tara/nol buyruqlari yo'q (cmdr nil).
+func startModbusSource(ctx context.Context, cfg appConfig, updates chan<- Reading) (string, error) {
+	baud := cfg.bauds[0]
+	if err := startModbusReader(ctx, cfg.source, baud, cfg.unit, cfg.modbus, cfg.pollInterval, updates); err != nil {
+		workerLog("main").Printf("modbus reader start error: %v", err)
+		return "", err
+	}
+	workerLog("main").Printf("modbus reader started: source=%s unit_id=%d reg=%d", cfg.source, cfg.modbus.Map.UnitID, cfg.modbus.Map.WeightReg)
+	if cfg.source.Kind == sourceModbusRTU {
+		return fmt.Sprintf("%s (%s @ %d, unit %d)", cfg.source.Kind, cfg.source.Addr, baud, cfg.modbus.Map.UnitID), nil
+	}
+	return fmt.Sprintf("%s (%s, unit %d)", cfg.source.Kind, cfg.source.Addr, cfg.modbus.Map.UnitID), nil
+}
+
+// startTraceRecorder --trace-record bo'lsa recorder ochadi (scale to'xtaguncha ochiq qoladi).
+func startTraceRecorder(cfg appConfig, device string, baud int, drv scaleDriver) *traceRecorder {
+	if strings.TrimSpace(cfg.traceRecord) == "" {
+		return nil
+	}
+	rec, err := newTraceRecorder(cfg.traceRecord, device, baud, cfg.unit, driverName(drv))
+	if err != nil {
+		exitErr(err)
+	}
+	workerLog("main").Printf("serial trace recording: file=%s", cfg.traceRecord)
+	return rec
+}
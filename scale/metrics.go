@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bridge/metrics"
+	"strings"
+)
+
+// Scale metrikalari. initMetrics chaqirilmaguncha nil: metrics turlari
+// nil-safe, shuning uchun testlar va --metrics-addr siz ishga tushirish o'zgarmaydi.
+var (
+	metricReadings       *metrics.Counter
+	metricParseMisses    *metrics.Counter
+	metricStableTriggers *metrics.Counter
+	metricEncodes        *metrics.Counter
+	metricZebraBusy      *metrics.Counter
+	metricBatchActive    *metrics.Gauge
+	metricZeroAlarm      *metrics.Gauge
+)
+
+func initMetrics(stationID string) *metrics.Registry {
+	r := metrics.NewRegistry(map[string]string{"station": stationID})
+	metricReadings = r.Counter("gscale_scale_readings_total", "Og'irlik o'qishlari soni (manba bo'yicha); rate() = o'qish/sekund.", "source")
+	metricParseMisses = r.Counter("gscale_scale_parse_miss_total", "streamSerial parse qila olmagan serial frame'lar.")
+	metricStableTriggers = r.Counter("gscale_stable_triggers_total", "StableEPCDetector auto encode'ni qo'zg'atgan holatlar.")
+	metricEncodes = r.Counter("gscale_zebra_encode_total", "Zebra encode urinishlari Verify natijasi bo'yicha (MATCH/WRITTEN/MISMATCH/CORRUPT/NO TAG/ERROR).", "verify")
+	metricZebraBusy = r.Counter("gscale_zebra_busy_errors_total", "Printer band (busy) xatolari.")
+	metricBatchActive = r.Gauge("gscale_batch_active", "Batch gate ochiq bo'lsa 1.")
+	metricZeroAlarm = r.Gauge("gscale_scale_zero_alarm", "Zero-tracking alarmi (drift yoki nolga qaytmaslik) bo'lsa 1.")
+	return r
+}
+
+func observeEncodeResult(st ZebraStatus) {
+	verify := strings.ToUpper(strings.TrimSpace(st.Verify))
+	if strings.TrimSpace(st.Error) != "" || verify == "" || verify == "-" {
+		verify = "ERROR"
+	}
+	metricEncodes.Inc(verify)
+}
+
+func boolGauge(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestObserveEncodeResultLabels(t *testing.T) {
+	r := initMetrics("line-1")
+	t.Cleanup(func() { initMetrics("default") })
+
+	observeEncodeResult(ZebraStatus{Verify: "match"})
+	observeEncodeResult(ZebraStatus{Verify: "NO TAG"})
+	observeEncodeResult(ZebraStatus{Verify: "-", Error: "printer busy"})
+
+	if got := metricEncodes.Value("MATCH"); got != 1 {
+		t.Fatalf("MATCH=%v, want 1", got)
+	}
+	if got := metricEncodes.Value("ERROR"); got != 1 {
+		t.Fatalf("ERROR=%v, want 1", got)
+	}
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	if !strings.Contains(b.String(), `gscale_zebra_encode_total{station="line-1",verify="NO TAG"} 1`) {
+		t.Fatalf("metrics output:\n%s", b.String())
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	modbusFuncHolding byte = 0x03
+	modbusFuncInput   byte = 0x04
+
+	modbusFormatInt16   = "int16"
+	modbusFormatUint16  = "uint16"
+	modbusFormatInt32   = "int32"
+	modbusFormatUint32  = "uint32"
+	modbusFormatFloat32 = "float32"
+)
+
+// modbusMap transmitter'ning registr xaritasi: vazn qaysi registrda, qanday formatda va
+// qanday masshtab bilan; status registridagi bit barqarorlikni bildiradi.
+type modbusMap struct {
+	UnitID   byte
+	Function byte
+	// WeightReg 0-based registr manzili (40001 = 0).
+	WeightReg uint16
+	Format    string
+	// WordSwap 32-bit qiymatda past so'z birinchi keladi (CDAB).
+	WordSwap bool
+	// Vazn = raw * Scale / 10^Decimals.
+	Scale    float64
+	Decimals int
+	// StatusReg < 0 bo'lsa barqarorlik noma'lum (Reading.Stable = nil).
+	StatusReg int
+	StableBit int
+}
+
+func defaultModbusMap() modbusMap {
+	return modbusMap{
+		UnitID:    1,
+		Function:  modbusFuncHolding,
+		Format:    modbusFormatInt32,
+		Scale:     1,
+		StatusReg: -1,
+		StableBit: 0,
+	}
+}
+
+// parseModbusFunction --modbus-function: holding (0x03) yoki input (0x04).
+func parseModbusFunction(raw string) (byte, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "holding", "3":
+		return modbusFuncHolding, nil
+	case "input", "4":
+		return modbusFuncInput, nil
+	}
+	return 0, fmt.Errorf("--modbus-function noto'g'ri: %q (holding yoki input)", raw)
+}
+
+func (m modbusMap) validate() error {
+	switch m.Format {
+	case modbusFormatInt16, modbusFormatUint16, modbusFormatInt32, modbusFormatUint32, modbusFormatFloat32:
+	default:
+		return fmt.Errorf("--modbus-weight-format noto'g'ri: %q (int16, uint16, int32, uint32 yoki float32)", m.Format)
+	}
+	if m.UnitID == 0 || m.UnitID > 247 {
+		return fmt.Errorf("--modbus-unit-id 1..247 bo'lishi kerak: %d", m.UnitID)
+	}
+	if m.Scale == 0 {
+		return errors.New("--modbus-scale 0 bo'lmasligi kerak")
+	}
+	if m.Decimals < 0 || m.Decimals > 6 {
+		return fmt.Errorf("--modbus-decimals 0..6 bo'lishi kerak: %d", m.Decimals)
+	}
+	if m.StatusReg > 0xFFFF {
+		return fmt.Errorf("--modbus-status-reg noto'g'ri: %d", m.StatusReg)
+	}
+	if m.StatusReg >= 0 && (m.StableBit < 0 || m.StableBit > 15) {
+		return fmt.Errorf("--modbus-stable-bit 0..15 bo'lishi kerak: %d", m.StableBit)
+	}
+	return nil
+}
+
+// weightRegs vazn nechta registr egallaydi.
+func (m modbusMap) weightRegs() uint16 {
+	if m.Format == modbusFormatInt16 || m.Format == modbusFormatUint16 {
+		return 1
+	}
+	return 2
+}
+
+// decodeWeight registrlardan vazn (masshtab va kasr bilan).
+func (m modbusMap) decodeWeight(regs []uint16) (float64, error) {
+	if len(regs) < int(m.weightRegs()) {
+		return 0, fmt.Errorf("modbus: %d registr keldi, %d kerak", len(regs), m.weightRegs())
+	}
+	var raw float64
+	switch m.Format {
+	case modbusFormatInt16:
+		raw = float64(int16(regs[0]))
+	case modbusFormatUint16:
+		raw = float64(regs[0])
+	default:
+		hi, lo := regs[0], regs[1]
+		if m.WordSwap {
+			hi, lo = lo, hi
+		}
+		u := uint32(hi)<<16 | uint32(lo)
+		switch m.Format {
+		case modbusFormatInt32:
+			raw = float64(int32(u))
+		case modbusFormatUint32:
+			raw = float64(u)
+		case modbusFormatFloat32:
+			raw = float64(math.Float32frombits(u))
+		}
+	}
+	return raw * m.Scale / math.Pow10(m.Decimals), nil
+}
+
+// modbusException qurilma exception javobi (funksiya | 0x80); ulanish sog', faqat so'rov rad etildi.
+type modbusException struct {
+	Function byte
+	Code     byte
+}
+
+func (e modbusException) Error() string {
+	name := map[byte]string{1: "illegal function", 2: "illegal data address", 3: "illegal data value", 4: "device failure", 6: "device busy"}[e.Code]
+	if name == "" {
+		name = "exception"
+	}
+	return fmt.Sprintf("modbus exception 0x%02X (%s) fn=0x%02X", e.Code, name, e.Function)
+}
+
+// errModbusLine RTU liniyasidagi o'tkinchi xato (javob kelmadi, CRC yoki boshqa unit):
+// port sog', kirish buferi tozalangan - so'rov qayta yuboriladi, qayta ulanish kerak emas.
+var errModbusLine = errors.New("modbus rtu liniya xatosi")
+
+// modbusClient registrlarni o'qiydi (RTU yoki TCP).
+type modbusClient interface {
+	ReadRegisters(fn byte, addr, count uint16) ([]uint16, error)
+	Close() error
+}
+
+func modbusReadRequest(fn byte, addr, count uint16) []byte {
+	pdu := []byte{fn, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(pdu[1:], addr)
+	binary.BigEndian.PutUint16(pdu[3:], count)
+	return pdu
+}
+
+// parseModbusReadResponse read holding/input javobi PDU'sidan registrlar.
+func parseModbusReadResponse(fn byte, count uint16, pdu []byte) ([]uint16, error) {
+	if len(pdu) >= 2 && pdu[0] == fn|0x80 {
+		return nil, modbusException{Function: fn, Code: pdu[1]}
+	}
+	if len(pdu) < 2 || pdu[0] != fn {
+		return nil, fmt.Errorf("modbus: kutilmagan javob % X", pdu)
+	}
+	n := int(pdu[1])
+	if n != int(count)*2 || len(pdu) != 2+n {
+		return nil, fmt.Errorf("modbus: javob uzunligi noto'g'ri (%d bayt, %d registr kutilgan)", n, count)
+	}
+	regs := make([]uint16, count)
+	for i := range regs {
+		regs[i] = binary.BigEndian.Uint16(pdu[2+2*i:])
+	}
+	return regs, nil
+}
+
+// modbusCRC16 Modbus RTU CRC (poly 0xA001, init 0xFFFF); frame'ga kichik bayt birinchi qo'shiladi.
+func modbusCRC16(b []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, c := range b {
+		crc ^= uint16(c)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// modbusRTU serial port ustida RTU: port ReadTimeout'da (0, nil) qaytarishi mumkin,
+// javob timeout ichida to'liq yig'iladi.
+type modbusRTU struct {
+	port    io.ReadWriteCloser
+	unit    byte
+	timeout time.Duration
+}
+
+func (c *modbusRTU) ReadRegisters(fn byte, addr, count uint16) ([]uint16, error) {
+	adu := append([]byte{c.unit}, modbusReadRequest(fn, addr, count)...)
+	adu = binary.LittleEndian.AppendUint16(adu, modbusCRC16(adu))
+	if err := c.flush(); err != nil {
+		return nil, err
+	}
+	if _, err := c.port.Write(adu); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(c.timeout)
+	buf := make([]byte, 0, 5+2*int(count)+2)
+	tmp := make([]byte, 256)
+	// want: unit + fn + (exception kodi | bayt soni) dan keyin ma'lum bo'ladi.
+	want := 3
+	for len(buf) < want {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s ichida javob kelmadi (%d bayt)", errModbusLine, c.timeout, len(buf))
+		}
+		n, err := c.port.Read(tmp)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, tmp[:n]...)
+		if len(buf) >= 3 {
+			if buf[1]&0x80 != 0 {
+				want = 5
+			} else {
+				want = 3 + int(buf[2]) + 2
+			}
+		}
+	}
+	buf = buf[:want]
+	body := buf[:want-2]
+	if got := binary.LittleEndian.Uint16(buf[want-2:]); got != modbusCRC16(body) {
+		return nil, fmt.Errorf("%w: CRC xato (% X)", errModbusLine, buf)
+	}
+	if body[0] != c.unit {
+		return nil, fmt.Errorf("%w: boshqa unit javobi (%d)", errModbusLine, body[0])
+	}
+	return parseModbusReadResponse(fn, count, body[1:])
+}
+
+// flush so'rovdan oldin kirish buferidagi qoldiqni (kech kelgan yoki buzuq javob) tashlaydi,
+// aks holda u keyingi javob bilan qo'shilib yana CRC xato beradi. Port ReadTimeout'da
+// (0, nil) qaytaradi; to'xtamay oqayotgan liniya uchun chegara bor.
+func (c *modbusRTU) flush() error {
+	tmp := make([]byte, 256)
+	for i := 0; i < 16; i++ {
+		n, err := c.port.Read(tmp)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (c *modbusRTU) Close() error { return c.port.Close() }
+
+// modbusTCP MBAP header bilan Modbus TCP.
+type modbusTCP struct {
+	conn    net.Conn
+	unit    byte
+	timeout time.Duration
+	txID    uint16
+}
+
+func (c *modbusTCP) ReadRegisters(fn byte, addr, count uint16) ([]uint16, error) {
+	c.txID++
+	pdu := modbusReadRequest(fn, addr, count)
+	adu := make([]byte, 7, 7+len(pdu))
+	binary.BigEndian.PutUint16(adu[0:], c.txID)
+	binary.BigEndian.PutUint16(adu[4:], uint16(len(pdu)+1))
+	adu[6] = c.unit
+	adu = append(adu, pdu...)
+
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := c.conn.Write(adu); err != nil {
+		return nil, err
+	}
+	for {
+		head := make([]byte, 7)
+		if _, err := io.ReadFull(c.conn, head); err != nil {
+			return nil, err
+		}
+		n := int(binary.BigEndian.Uint16(head[4:]))
+		if binary.BigEndian.Uint16(head[2:]) != 0 || n < 2 || n > 254 {
+			return nil, fmt.Errorf("modbus tcp: MBAP header noto'g'ri (% X)", head)
+		}
+		body := make([]byte, n-1)
+		if _, err := io.ReadFull(c.conn, body); err != nil {
+			return nil, err
+		}
+		// Oldingi timeout bo'lgan so'rovning kech javobi tashlab yuboriladi.
+		if binary.BigEndian.Uint16(head[0:]) != c.txID {
+			continue
+		}
+		return parseModbusReadResponse(fn, count, body)
+	}
+}
+
+func (c *modbusTCP) Close() error { return c.conn.Close() }
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/tarm/serial"
+)
+
+const sourceModbus = "modbus"
+
+// modbusOptions RTU/TCP ulanish sozlamalari (--modbus-*).
+type modbusOptions struct {
+	Map     modbusMap
+	Parity  serial.Parity
+	Timeout time.Duration
+}
+
+// parseModbusParity --modbus-parity: N, E yoki O (RTU odatda 8E1 yoki 8N1).
+func parseModbusParity(raw string) (serial.Parity, error) {
+	switch strings.ToUpper(strings.TrimSpace(raw)) {
+	case "", "N", "NONE":
+		return serial.ParityNone, nil
+	case "E", "EVEN":
+		return serial.ParityEven, nil
+	case "O", "ODD":
+		return serial.ParityOdd, nil
+	}
+	return 0, fmt.Errorf("--modbus-parity noto'g'ri: %q (N, E yoki O)", raw)
+}
+
+// startModbusReader transmitter registrlarini har poll'da o'qiydi. Ulanish yoki o'qish
+// xatosida qayta ochadi; exception javobi ulanishni yopmaydi. Reading.Source = "modbus".
+func startModbusReader(ctx context.Context, src scaleSource, baud int, unit string, opts modbusOptions, poll time.Duration, out chan<- Reading) error {
+	lg := workerLog("worker.modbus")
+	lg.Printf("start: source=%s baud=%d unit=%s fn=0x%02X reg=%d format=%s", src, baud, strings.TrimSpace(unit), opts.Map.Function, opts.Map.WeightReg, opts.Map.Format)
+	if src.Kind == sourceModbusTCP {
+		baud = 0
+	}
+	emit := func(r Reading) { push(out, r) }
+	fail := func(msg string) {
+		emit(Reading{Source: sourceModbus, Port: src.Addr, Baud: baud, Unit: unit, Error: msg, UpdatedAt: time.Now()})
+	}
+
+	var connect func() (modbusClient, error)
+	switch src.Kind {
+	case sourceModbusRTU:
+		connect = func() (modbusClient, error) {
+			port, err := serial.OpenPort(&serial.Config{Name: src.Addr, Baud: baud, Parity: opts.Parity, ReadTimeout: 50 * time.Millisecond})
+			if err != nil {
+				return nil, err
+			}
+			return &modbusRTU{port: port, unit: opts.Map.UnitID, timeout: opts.Timeout}, nil
+		}
+	case sourceModbusTCP:
+		dialer := &net.Dialer{Timeout: tcpDialTimeout}
+		connect = func() (modbusClient, error) {
+			conn, err := dialer.DialContext(ctx, "tcp", src.Addr)
+			if err != nil {
+				return nil, err
+			}
+			return &modbusTCP{conn: conn, unit: opts.Map.UnitID, timeout: opts.Timeout}, nil
+		}
+	default:
+		return fmt.Errorf("modbus source noto'g'ri: %s", src)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			client, err := connect()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				lg.Printf("connect error: %v", err)
+				fail(fmt.Sprintf("connect error: %v", err))
+				if !sleepWithContext(ctx, 900*time.Millisecond) {
+					return
+				}
+				continue
+			}
+
+			lg.Printf("connected: source=%s", src)
+			emit(Reading{Source: sourceModbus, Port: src.Addr, Baud: baud, Unit: unit, UpdatedAt: time.Now()})
+			err = pollModbus(ctx, client, opts.Map, src.Addr, baud, unit, poll, time.Now, emit)
+			_ = client.Close()
+			lg.Printf("connection closed: source=%s err=%v", src, err)
+
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				fail(fmt.Sprintf("read error: %v", err))
+			}
+			if !sleepWithContext(ctx, 400*time.Millisecond) {
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// pollModbus har poll'da vazn (va status) registrlarini o'qib emit'ga beradi. Exception
+// javobi va RTU liniya xatosi (timeout, CRC) xato Reading bo'lib chiqadi va o'qish davom
+// etadi; faqat haqiqiy I/O xatosi qaytariladi (qayta ulanish).
+func pollModbus(ctx context.Context, client modbusClient, m modbusMap, port string, baud int, unit string, poll time.Duration, now func() time.Time, emit func(Reading)) error {
+	lg := workerLog("worker.modbus")
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	if unit == "" {
+		unit = "kg"
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		r, err := readModbusWeight(client, m)
+		r.Source = sourceModbus
+		r.Port = port
+		r.Baud = baud
+		r.Unit = unit
+		r.UpdatedAt = now()
+		var exc modbusException
+		switch {
+		case errors.As(err, &exc):
+			lg.Printf("exception: %v", exc)
+			r.Error = exc.Error()
+			emit(r)
+		case errors.Is(err, errModbusLine):
+			lg.Printf("line error, retry: %v", err)
+			r.Error = err.Error()
+			emit(r)
+		case err != nil:
+			return err
+		default:
+			emit(r)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// readModbusWeight vazn va (StatusReg >= 0 bo'lsa) barqarorlik bitini o'qiydi.
+func readModbusWeight(client modbusClient, m modbusMap) (Reading, error) {
+	regs, err := client.ReadRegisters(m.Function, m.WeightReg, m.weightRegs())
+	if err != nil {
+		return Reading{}, err
+	}
+	w, err := m.decodeWeight(regs)
+	if err != nil {
+		return Reading{}, err
+	}
+	raw := fmt.Sprintf("u%d fn=0x%02X r%d=%04X", m.UnitID, m.Function, m.WeightReg, regs)
+	r := Reading{Weight: &w, Raw: raw}
+	if m.StatusReg >= 0 {
+		st, err := client.ReadRegisters(m.Function, uint16(m.StatusReg), 1)
+		if err != nil {
+			return Reading{}, err
+		}
+		stable := st[0]&(1<<uint(m.StableBit)) != 0
+		r.Stable = &stable
+		r.Raw = fmt.Sprintf("%s r%d=%04X", raw, m.StatusReg, st[0])
+	}
+	return r, nil
+}
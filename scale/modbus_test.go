@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModbusCRC16(t *testing.T) {
+	// Spec namunasi: 01 03 00 00 00 0A -> CRC C5 CD (frame'da CD C5 emas, kichik bayt birinchi).
+	if got := modbusCRC16([]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0A}); got != 0xCDC5 {
+		t.Fatalf("crc mismatch: got=%04X", got)
+	}
+}
+
+func TestModbusMapDecodeWeight(t *testing.T) {
+	cases := []struct {
+		name string
+		m    modbusMap
+		regs []uint16
+		want float64
+	}{
+		{"int16 decimals", modbusMap{Format: modbusFormatInt16, Scale: 1, Decimals: 2}, []uint16{0xFF9C}, -1},
+		{"uint16 scale", modbusMap{Format: modbusFormatUint16, Scale: 0.5}, []uint16{300}, 150},
+		{"int32", modbusMap{Format: modbusFormatInt32, Scale: 1, Decimals: 3}, []uint16{0x0001, 0x0000}, 65.536},
+		{"int32 swap", modbusMap{Format: modbusFormatInt32, Scale: 1, Decimals: 3, WordSwap: true}, []uint16{0x04E2, 0x0000}, 1.25},
+		{"float32", modbusMap{Format: modbusFormatFloat32, Scale: 1}, []uint16{0x4020, 0x0000}, 2.5},
+	}
+	for _, tc := range cases {
+		got, err := tc.m.decodeWeight(tc.regs)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if diff := got - tc.want; diff > 1e-9 || diff < -1e-9 {
+			t.Fatalf("%s: got=%v want=%v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestModbusMapValidate(t *testing.T) {
+	m := defaultModbusMap()
+	if err := m.validate(); err != nil {
+		t.Fatalf("default map invalid: %v", err)
+	}
+	m.Format = "int64"
+	if err := m.validate(); err == nil {
+		t.Fatalf("expected format error")
+	}
+	m = defaultModbusMap()
+	m.StatusReg, m.StableBit = 10, 16
+	if err := m.validate(); err == nil {
+		t.Fatalf("expected stable bit error")
+	}
+}
+
+// fakeRTUPort javobni so'rovga qarab tayyorlaydi va uni kichik bo'laklarda qaytaradi.
+type fakeRTUPort struct {
+	regs map[uint16]uint16
+	resp bytes.Buffer
+}
+
+func (p *fakeRTUPort) Write(b []byte) (int, error) {
+	body := b[:len(b)-2]
+	fn, addr, count := body[1], binary.BigEndian.Uint16(body[2:]), binary.BigEndian.Uint16(body[4:])
+	out := []byte{body[0]}
+	if _, ok := p.regs[addr]; !ok {
+		out = append(out, fn|0x80, 0x02)
+	} else {
+		out = append(out, fn, byte(2*count))
+		for i := uint16(0); i < count; i++ {
+			out = binary.BigEndian.AppendUint16(out, p.regs[addr+i])
+		}
+	}
+	p.resp.Write(binary.LittleEndian.AppendUint16(out, modbusCRC16(out)))
+	return len(b), nil
+}
+
+func (p *fakeRTUPort) Read(b []byte) (int, error) {
+	if len(b) > 3 {
+		b = b[:3]
+	}
+	n, _ := p.resp.Read(b)
+	return n, nil
+}
+
+func (p *fakeRTUPort) Close() error { return nil }
+
+func TestModbusRTUReadWeightAndStatus(t *testing.T) {
+	port := &fakeRTUPort{regs: map[uint16]uint16{0: 0x0000, 1: 0x04E2, 8: 0x0004}}
+	client := &modbusRTU{port: port, unit: 1, timeout: time.Second}
+	m := defaultModbusMap()
+	m.Decimals = 3
+	m.StatusReg, m.StableBit = 8, 2
+
+	r, err := readModbusWeight(client, m)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if r.Weight == nil || *r.Weight != 1.25 {
+		t.Fatalf("weight mismatch: %+v", r)
+	}
+	if r.Stable == nil || !*r.Stable {
+		t.Fatalf("stable mismatch: %+v", r)
+	}
+
+	_, err = client.ReadRegisters(modbusFuncHolding, 40, 1)
+	var exc modbusException
+	if !errors.As(err, &exc) || exc.Code != 0x02 {
+		t.Fatalf("expected illegal address exception, got %v", err)
+	}
+}
+
+// silentPort so'rovni qabul qiladi, lekin hech qachon javob bermaydi.
+type silentPort struct{}
+
+func (silentPort) Write(b []byte) (int, error) { return len(b), nil }
+func (silentPort) Read([]byte) (int, error)    { return 0, nil }
+func (silentPort) Close() error                { return nil }
+
+func TestModbusRTUTimeout(t *testing.T) {
+	client := &modbusRTU{port: silentPort{}, unit: 1, timeout: 20 * time.Millisecond}
+	if _, err := client.ReadRegisters(modbusFuncHolding, 0, 2); err == nil {
+		t.Fatalf("expected timeout error")
+	}
+}
+
+// flakyRTUPort har so'rovga script bo'yicha javob beradi: "crc" - buzilgan javob (ortiqcha
+// qoldiq bayt bilan), "silent" - javob yo'q, boshqasi - to'g'ri javob.
+type flakyRTUPort struct {
+	fakeRTUPort
+	script []string
+}
+
+func (p *flakyRTUPort) Write(b []byte) (int, error) {
+	mode := "ok"
+	if len(p.script) > 0 {
+		mode, p.script = p.script[0], p.script[1:]
+	}
+	switch mode {
+	case "silent":
+		return len(b), nil
+	case "crc":
+		n, err := p.fakeRTUPort.Write(b)
+		raw := p.resp.Bytes()
+		raw[3] ^= 0xFF
+		p.resp.Write([]byte{0xAA, 0x55})
+		return n, err
+	}
+	return p.fakeRTUPort.Write(b)
+}
+
+func TestPollModbusRTURetriesLineErrors(t *testing.T) {
+	port := &flakyRTUPort{fakeRTUPort: fakeRTUPort{regs: map[uint16]uint16{0: 0x0000, 1: 0x04E2}}, script: []string{"crc", "silent", "ok"}}
+	client := &modbusRTU{port: port, unit: 1, timeout: 20 * time.Millisecond}
+	m := defaultModbusMap()
+	m.Decimals = 3
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var got []Reading
+	err := pollModbus(ctx, client, m, "/dev/ttyUSB0", 9600, "kg", time.Millisecond, time.Now, func(r Reading) {
+		got = append(got, r)
+		if len(got) == 3 {
+			cancel()
+		}
+	})
+	// CRC va timeout qayta ulanishga olib kelmaydi: pollModbus xato qaytarmaydi.
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if len(got) != 3 || !strings.Contains(got[0].Error, "CRC") || !strings.Contains(got[1].Error, "javob kelmadi") {
+		t.Fatalf("readings: %+v", got)
+	}
+	// Buzuq javob qoldig'i tozalangan: keyingi to'g'ri javob o'qiladi.
+	if got[2].Error != "" || got[2].Weight == nil || *got[2].Weight != 1.25 {
+		t.Fatalf("recovered reading: %+v", got[2])
+	}
+}
+
+// serveModbusTCP bitta ulanishga holding registr so'rovlariga javob beradi.
+func serveModbusTCP(t *testing.T, ln net.Listener, regs map[uint16]uint16) {
+	t.Helper()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			req := make([]byte, 12)
+			if _, err := io.ReadFull(conn, req); err != nil {
+				return
+			}
+			addr, count := binary.BigEndian.Uint16(req[8:]), binary.BigEndian.Uint16(req[10:])
+			pdu := []byte{req[7], byte(2 * count)}
+			for i := uint16(0); i < count; i++ {
+				pdu = binary.BigEndian.AppendUint16(pdu, regs[addr+i])
+			}
+			resp := append([]byte{req[0], req[1], 0, 0, 0, byte(len(pdu) + 1), req[6]}, pdu...)
+			if _, err := conn.Write(resp); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func TestPollModbusTCPEmitsReadings(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	serveModbusTCP(t, ln, map[uint16]uint16{100: 2500, 101: 0x0001})
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	client := &modbusTCP{conn: conn, unit: 1, timeout: time.Second}
+	defer client.Close()
+
+	m := defaultModbusMap()
+	m.WeightReg, m.Format, m.Decimals = 100, modbusFormatUint16, 2
+	m.StatusReg, m.StableBit = 101, 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var got []Reading
+	err = pollModbus(ctx, client, m, ln.Addr().String(), 0, "kg", 10*time.Millisecond, time.Now, func(r Reading) {
+		got = append(got, r)
+		if len(got) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	for _, r := range got {
+		if r.Source != sourceModbus || r.Unit != "kg" || r.Error != "" {
+			t.Fatalf("reading mismatch: %+v", r)
+		}
+		if r.Weight == nil || *r.Weight != 25 || r.Stable == nil || !*r.Stable {
+			t.Fatalf("weight/stable mismatch: %+v", r)
+		}
+	}
+}
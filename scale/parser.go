@@ -10,6 +10,9 @@ var (
 	weightRegex   = regexp.MustCompile(`(?i)([-+N]?)\s*(\d+(?:[.,]\d+)?)\s*(kg|g|lb|lbs|oz)?\s*([-+]?)`)
 	stableRegex   = regexp.MustCompile(`(?i)\bST\b|\bSTABLE\b`)
 	unstableRegex = regexp.MustCompile(`(?i)\bUS\b|\bUNSTABLE\b`)
+	// Indikator tara maydoni: `TR 2.000kg`, `ST,TR,+0002.00kg`, `TARE: 1.5`, `PT 0.8 kg`.
+	tareFieldRegex = regexp.MustCompile(`(?i)\b(?:TR|TARE|PT)\b[\s:=,]*([-+]?\d+(?:[.,]\d+)?)\s*(?:kg|g|lb|lbs|oz)?`)
+	netFrameRegex  = regexp.MustCompile(`(?i)\bNT\b|\bNET\b`)
 )
 
 type weightCandidate struct {
@@ -95,6 +98,28 @@ func parseWeight(raw, defaultUnit string) (float64, string, *bool, bool) {
 	return best.weight, best.unit, stable, true
 }
 
+// splitIndicatorTare frame'dagi tara maydonini ajratadi: tara qiymati va qolgan matn.
+// GS/TR/NT yuboradigan indikatorlar tarani ko'pincha alohida qatorda beradi.
+func splitIndicatorTare(raw string) (float64, string, bool) {
+	normalized := normalizeMinus(raw)
+	idx := tareFieldRegex.FindStringSubmatchIndex(normalized)
+	if idx == nil {
+		return 0, raw, false
+	}
+	num := strings.ReplaceAll(normalized[idx[2]:idx[3]], ",", ".")
+	tare, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, raw, false
+	}
+	rest := strings.TrimSpace(normalized[:idx[0]] + " " + normalized[idx[1]:])
+	return tare, rest, true
+}
+
+// isNetFrame frame vazni netto (NT/NET) deb belgilangan: indikator tarani allaqachon ayirgan.
+func isNetFrame(raw string) bool {
+	return netFrameRegex.MatchString(raw)
+}
+
 func scoreCandidate(c weightCandidate) int {
 	score := 0
 	if c.hasUnit {
@@ -58,3 +58,30 @@ func TestParseWeightPositiveStillWorks(t *testing.T) {
 		t.Fatalf("unit mismatch: got=%q want=%q", unit, "kg")
 	}
 }
+
+func TestSplitIndicatorTare(t *testing.T) {
+	tests := []struct {
+		raw      string
+		tare     float64
+		rest     string
+		hasTare  bool
+		netFrame bool
+	}{
+		{raw: "TR 2.000 kg", tare: 2, rest: "", hasTare: true},
+		{raw: "ST,TR,+0002.50kg", tare: 2.5, rest: "ST,", hasTare: true},
+		{raw: "ST NT 10.340kg TARE: 1,5", tare: 1.5, rest: "ST NT 10.340kg", hasTare: true, netFrame: true},
+		{raw: "ST,GS,+0012.34kg", rest: "ST,GS,+0012.34kg"},
+	}
+	for _, tc := range tests {
+		tare, rest, ok := splitIndicatorTare(tc.raw)
+		if ok != tc.hasTare || tare != tc.tare || rest != tc.rest {
+			t.Fatalf("%q: tare=%v rest=%q ok=%v", tc.raw, tare, rest, ok)
+		}
+		if isNetFrame(rest) != tc.netFrame {
+			t.Fatalf("%q: netFrame=%v want %v", tc.raw, !tc.netFrame, tc.netFrame)
+		}
+	}
+	if _, _, _, ok := parseWeight("ST,", "kg"); ok {
+		t.Fatal("tare-only frame rest should not parse as weight")
+	}
+}
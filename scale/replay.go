@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	corepkg "core"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// replayTrigger replay paytida detector trigger'i (Err bo'lsa sxema EPC bermagan).
+type replayTrigger struct {
+	Offset time.Duration
+	Weight float64
+	Unit   string
+	EPC    string
+	Err    string
+}
+
+// replayReport trace replay natijasi: regressiya testlari trigger'larni shu yerdan tekshiradi.
+type replayReport struct {
+	Chunks    int
+	Readings  int
+	Misses    int
+	Errors    int
+	Resets    int
+	Duration  time.Duration
+	Triggers  []replayTrigger
+	LastEvent string
+}
+
+func (r replayReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "trace: chunks=%d readings=%d parse_miss=%d errors=%d resets=%d duration=%s\n",
+		r.Chunks, r.Readings, r.Misses, r.Errors, r.Resets, r.Duration)
+	fmt.Fprintf(&b, "triggers: %d\n", len(r.Triggers))
+	for i, t := range r.Triggers {
+		if t.Err != "" {
+			fmt.Fprintf(&b, "  #%d +%s %.3f %s xato: %s\n", i+1, t.Offset, t.Weight, t.Unit, t.Err)
+			continue
+		}
+		fmt.Fprintf(&b, "  #%d +%s %.3f %s epc=%s\n", i+1, t.Offset, t.Weight, t.Unit, t.EPC)
+	}
+	return b.String()
+}
+
+// replaySerialTrace trace'ni header'dagi driver (bo'sh = streamSerial/parseWeight) va detector
+// orqali virtual soatda o'tkazadi. Batch active deb olinadi; indikator tarasi bo'lsa qo'llanadi.
+// Detector'ning event handler'i replay davomida almashtiriladi.
+func replaySerialTrace(ctx context.Context, tr serialTrace, unit string, detector *corepkg.StableEPCDetector) (replayReport, error) {
+	if detector == nil {
+		return replayReport{}, errors.New("replay: detector yo'q")
+	}
+	if strings.TrimSpace(unit) == "" {
+		unit = tr.Unit
+	}
+	rep := replayReport{Chunks: len(tr.Chunks)}
+	if n := len(tr.Chunks); n > 0 {
+		rep.Duration = tr.Chunks[n-1].Offset
+	}
+
+	lastUnit := unit
+	detector.SetEventHandler(func(ev corepkg.DetectorEvent) {
+		rep.LastEvent = ev.String()
+		switch ev.Kind {
+		case corepkg.DetectorReset:
+			rep.Resets++
+		case corepkg.DetectorTriggered:
+			t := replayTrigger{Offset: ev.At.Sub(tr.Start), Weight: ev.Weight, Unit: lastUnit}
+			if ev.Err != nil {
+				t.Err = ev.Err.Error()
+			}
+			rep.Triggers = append(rep.Triggers, t)
+		}
+	})
+	defer detector.SetEventHandler(nil)
+
+	emit := func(rd Reading) {
+		rd = applyTare(nil, rd)
+		if rd.Unit != "" {
+			lastUnit = rd.Unit
+		}
+		switch {
+		case rd.Weight != nil:
+			rep.Readings++
+			if epc, ok := detector.ObserveReading(rd.netWeight(), rd.Stable, rd.UpdatedAt); ok && len(rep.Triggers) > 0 {
+				rep.Triggers[len(rep.Triggers)-1].EPC = epc
+			}
+		case strings.TrimSpace(rd.Error) != "":
+			rep.Errors++
+			detector.Abort(strings.TrimSpace(rd.Error), rd.UpdatedAt)
+		default:
+			rep.Misses++
+		}
+	}
+
+	drv, err := newScaleDriver(tr.Driver)
+	if err != nil {
+		return rep, err
+	}
+	reader := newTraceReader(&tr)
+	if drv == nil {
+		err = streamSerial(ctx, reader, tr.Device, tr.Baud, unit, reader.Now, emit)
+	} else {
+		err = streamDriver(ctx, reader, nil, nil, drv, tr.Device, tr.Baud, unit, 0, reader.Now, emit)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return rep, err
+	}
+	return rep, nil
+}
+
+// replayEPCSalt replay'dagi vaqt sxemasi salt'i (qiymati muhim emas, faqat o'zgarmas).
+const replayEPCSalt = 1
+
+// runReplay --replay rejimi: trace'ni o'tkazib hisobotni chiqaradi (TUI ishga tushmaydi).
+func runReplay(ctx context.Context, cfg appConfig, out io.Writer) error {
+	tr, err := loadSerialTrace(cfg.replay)
+	if err != nil {
+		return err
+	}
+	// Salt qat'iy: bir trace'ning replay hisoboti (EPC'lar bilan) har safar bir xil.
+	detector, err := newAutoDetector(cfg, corepkg.NewTimeEPCSchemeWithSalt(replayEPCSalt))
+	if err != nil {
+		return err
+	}
+	rep, err := replaySerialTrace(ctx, tr, "", detector)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "replay: %s (device=%s baud=%d unit=%s driver=%s stability=%s)\n",
+		cfg.replay, safeText("-", tr.Device), tr.Baud, tr.Unit, safeText(driverStream, tr.Driver), detector.Strategy().Name())
+	_, err = io.WriteString(out, rep.String())
+	return err
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	corepkg "core"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func replayTestDetector(t *testing.T) *corepkg.StableEPCDetector {
+	t.Helper()
+	scheme, err := corepkg.NewSGTINScheme("0614141", 1, corepkg.NewMemorySerialAllocator(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := scheme.SetGTIN("80614141123458"); err != nil {
+		t.Fatal(err)
+	}
+	cfg := corepkg.DefaultStableEPCConfig()
+	cfg.Scheme = scheme
+	return corepkg.NewStableEPCDetector(cfg)
+}
+
+func TestReplaySerialTrace_CustomerTraces(t *testing.T) {
+	cases := []struct {
+		trace  string
+		want   []float64
+		at     []time.Duration
+		misses int
+	}{
+		{"ad_continuous_two_items.trace", []float64{1.250, 2.100}, []time.Duration{2000 * time.Millisecond, 4700 * time.Millisecond}, 1},
+		// Netto (brutto 1.500 - indikator tarasi 0.500) bo'yicha trigger.
+		{"indicator_tare_net.trace", []float64{1.000}, []time.Duration{1000 * time.Millisecond}, 0},
+		// MT-SICS javoblari (driver=sics): netto 0.800, tara `T S` javobidan.
+		{"sics_polled.trace", []float64{0.800}, []time.Duration{2400 * time.Millisecond}, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.trace, func(t *testing.T) {
+			tr, err := loadSerialTrace(filepath.Join("testdata", tc.trace))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rep, err := replaySerialTrace(context.Background(), tr, "", replayTestDetector(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rep.Triggers) != len(tc.want) || rep.Misses != tc.misses {
+				t.Fatalf("report:\n%s", rep)
+			}
+			for i, trg := range rep.Triggers {
+				if math.Abs(trg.Weight-tc.want[i]) > 1e-9 || trg.Offset != tc.at[i] || trg.Err != "" {
+					t.Fatalf("trigger[%d]=%+v want %.3f at %s", i, trg, tc.want[i], tc.at[i])
+				}
+				epc, err := corepkg.DecodeSGTIN96(trg.EPC)
+				if err != nil || epc.Serial != uint64(100+i) {
+					t.Fatalf("trigger[%d] epc=%s decoded=%+v err=%v", i, trg.EPC, epc, err)
+				}
+			}
+
+			// Deterministik: ikkinchi replay aynan shu hisobot.
+			again, err := replaySerialTrace(context.Background(), tr, "", replayTestDetector(t))
+			if err != nil || again.String() != rep.String() {
+				t.Fatalf("replay not deterministic:\n%s\n%s", rep, again)
+			}
+		})
+	}
+}
+
+func TestRunReplay_Deterministic(t *testing.T) {
+	cfg := appConfig{
+		replay:        filepath.Join("testdata", "ad_continuous_two_items.trace"),
+		stability:     corepkg.StabilityEpsilon,
+		stabilityOpts: corepkg.DefaultStabilityOptions(),
+	}
+	var a, b bytes.Buffer
+	if err := runReplay(context.Background(), cfg, &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := runReplay(context.Background(), cfg, &b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(a.String(), "epc=30") || a.String() != b.String() {
+		t.Fatalf("replay output differs:\n%s\n%s", a.String(), b.String())
+	}
+}
+
+func TestTraceRecorderRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.trace")
+	rec, err := newTraceRecorder(path, "/dev/ttyUSB9", 19200, "kg", driverCAS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := rec.wrap(strings.NewReader("ST,GS,+0001.250kg\r\n\x00"))
+	var got bytes.Buffer
+	if _, err := got.ReadFrom(src); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := loadSerialTrace(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Device != "/dev/ttyUSB9" || tr.Baud != 19200 || tr.Unit != "kg" || tr.Driver != driverCAS || tr.Start.IsZero() {
+		t.Fatalf("header=%+v", tr)
+	}
+	var data strings.Builder
+	for _, c := range tr.Chunks {
+		data.WriteString(c.Data)
+	}
+	if data.String() != got.String() {
+		t.Fatalf("data=%q want %q", data.String(), got.String())
+	}
+
+	if _, err := parseSerialTrace(strings.NewReader("100 \"a\"\n50 \"b\"\n")); err == nil {
+		t.Fatal("time going backwards should fail")
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+}
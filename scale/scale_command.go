@@ -0,0 +1,175 @@
+package main
+
+import (
+	bridgestate "bridge/state"
+	"context"
+	corepkg "core"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// scaleCommandTimeout indikator buyruqqa shu vaqtda javob bermasa xato.
+const scaleCommandTimeout = 3 * time.Second
+
+// scaleCommandReq serial reader goroutine'iga yuboriladigan buyruq; natija done'ga.
+type scaleCommandReq struct {
+	op   scaleOp
+	done chan error
+}
+
+// scaleCommander indikatorga buyruq yuboradi: baytlarni ochiq port'ga serial reader
+// goroutine'ining o'zi yozadi (streamDriver), shuning uchun port bitta joyda qoladi.
+type scaleCommander struct {
+	drv  scaleDriver
+	reqs chan scaleCommandReq
+}
+
+// newScaleCommander drv nil (stream) bo'lsa ham commander qaytadi: buyruqlar xato bilan tugaydi.
+func newScaleCommander(drv scaleDriver) *scaleCommander {
+	return &scaleCommander{drv: drv, reqs: make(chan scaleCommandReq)}
+}
+
+// requests streamDriver uchun kanal (nil commander = nil kanal, buyruq kelmaydi).
+func (c *scaleCommander) requests() <-chan scaleCommandReq {
+	if c == nil {
+		return nil
+	}
+	return c.reqs
+}
+
+// Do buyruqni yuborib indikator javobini (ack/xato) kutadi.
+func (c *scaleCommander) Do(ctx context.Context, op scaleOp) error {
+	if c == nil || c.drv == nil {
+		return fmt.Errorf("%s: %w (driver=%s)", op, errOpUnsupported, driverStream)
+	}
+	if _, err := c.drv.Command(op); err != nil {
+		return fmt.Errorf("%s: %w (driver=%s)", op, err, c.drv.Name())
+	}
+	ctx, cancel := context.WithTimeout(ctx, scaleCommandTimeout)
+	defer cancel()
+	req := scaleCommandReq{op: op, done: make(chan error, 1)}
+	select {
+	case c.reqs <- req:
+	case <-ctx.Done():
+		return fmt.Errorf("%s: serial port tayyor emas", op)
+	}
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%s: indikator javob bermadi", op)
+	}
+}
+
+// scaleControl tare/zero/clear_tare'ni bajaradi (TUI tugmasi, bus yoki bridge so'rovi) va
+// natijani bridge snapshot'ning `command` bo'limiga yozadi.
+type scaleControl struct {
+	cmdr  *scaleCommander
+	tare  *corepkg.TareRegister
+	zero  *zeroGuard
+	store *bridgestate.Store
+}
+
+var scaleCommandSeq atomic.Uint64
+
+// run buyruqni bajaradi. cmd.ID bo'sh bo'lsa (TUI) yangi ID beriladi.
+func (c *scaleControl) run(ctx context.Context, cmd bridgestate.ScaleCommandSnapshot) bridgestate.ScaleCommandSnapshot {
+	now := time.Now().UTC()
+	if strings.TrimSpace(cmd.ID) == "" {
+		cmd.ID = fmt.Sprintf("scale-%d-%d", now.Unix(), scaleCommandSeq.Add(1))
+	}
+	if cmd.RequestedAt == "" {
+		cmd.RequestedAt = now.Format(time.RFC3339Nano)
+	}
+
+	err := c.do(ctx, scaleOp(strings.TrimSpace(cmd.Op)))
+	cmd.Status, cmd.Error = bridgestate.ScaleCommandOK, ""
+	if err != nil {
+		cmd.Status, cmd.Error = bridgestate.ScaleCommandError, err.Error()
+	}
+	cmd.DoneAt = time.Now().UTC().Format(time.RFC3339Nano)
+	workerLog("worker.scale_cmd").Printf("command: id=%s op=%s by=%s status=%s err=%s", cmd.ID, cmd.Op, safeText("-", cmd.RequestedBy), cmd.Status, cmd.Error)
+
+	if c.store != nil {
+		done := cmd
+		if werr := c.store.Update(func(s *bridgestate.Snapshot) { s.Command = &done }); werr != nil {
+			workerLog("worker.scale_cmd").Printf("bridge write error: %v", werr)
+		}
+	}
+	return cmd
+}
+
+func (c *scaleControl) do(ctx context.Context, op scaleOp) error {
+	switch op {
+	case scaleOpTare:
+		return c.cmdr.Do(ctx, op)
+	case scaleOpZero:
+		if err := c.cmdr.Do(ctx, op); err != nil {
+			return err
+		}
+		// Tarozi qayta nollandi: drift/no-return alarmlari tozalanadi.
+		c.zero.reset()
+		return nil
+	case scaleOpClearTare:
+		// Dastur tarasi (preset/tugma) har doim o'chiriladi; indikatorda buyruq bo'lmasa shu yetarli.
+		if c.tare != nil {
+			if _, err := c.tare.Capture(0); err != nil {
+				return err
+			}
+		}
+		if err := c.cmdr.Do(ctx, op); err != nil && !errors.Is(err, errOpUnsupported) {
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("scale buyrug'i noma'lum: %q (tare, zero yoki clear_tare)", op)
+}
+
+// watchScaleCommands bus bo'lmaganda bot bridge state'ga yozgan pending buyruqlarni bajaradi.
+// Bot kutishni to'xtatgan (ScaleCommandTimeout'dan eski) buyruq bajarilmaydi: masalan scale
+// qayta ishga tushganda state'da qolgan eski `zero` tarozini kutilmaganda nollamasin.
+func watchScaleCommands(ctx context.Context, store *bridgestate.Store, ctl *scaleControl) {
+	if store == nil || ctl == nil {
+		return
+	}
+	updates := store.WatchWithFallback(ctx, 250*time.Millisecond)
+	go func() {
+		lastID := ""
+		for snap := range updates {
+			cmd := snap.Command
+			if !cmd.Pending() || cmd.ID == lastID {
+				continue
+			}
+			lastID = cmd.ID
+			if cmd.Stale(time.Now()) {
+				ctl.expire(*cmd)
+				continue
+			}
+			ctl.run(ctx, *cmd)
+		}
+	}()
+}
+
+// expire eskirgan pending buyruqni bajarmasdan expired deb yozadi.
+func (c *scaleControl) expire(cmd bridgestate.ScaleCommandSnapshot) {
+	workerLog("worker.scale_cmd").Printf("command expired: id=%s op=%s by=%s requested_at=%s", cmd.ID, cmd.Op, safeText("-", cmd.RequestedBy), safeText("-", cmd.RequestedAt))
+	if c.store == nil {
+		return
+	}
+	err := c.store.Update(func(s *bridgestate.Snapshot) {
+		if s.Command == nil || s.Command.ID != cmd.ID || !s.Command.Pending() {
+			return
+		}
+		done := *s.Command
+		done.Status = bridgestate.ScaleCommandExpired
+		done.Error = fmt.Sprintf("buyruq eskirgan (%s'dan eski), bajarilmadi", bridgestate.ScaleCommandTimeout)
+		done.DoneAt = time.Now().UTC().Format(time.RFC3339Nano)
+		s.Command = &done
+	})
+	if err != nil {
+		workerLog("worker.scale_cmd").Printf("bridge write error: %v", err)
+	}
+}
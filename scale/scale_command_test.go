@@ -0,0 +1,200 @@
+package main
+
+import (
+	bridgestate "bridge/state"
+	"context"
+	corepkg "core"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIndicator so'rovlarga reply() bo'yicha javob beradigan port.
+type fakeIndicator struct {
+	mu    sync.Mutex
+	out   strings.Builder
+	sent  []string
+	reply func(cmd string) string
+}
+
+func (f *fakeIndicator) send(b []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cmd := strings.TrimSpace(string(b))
+	f.sent = append(f.sent, cmd)
+	f.out.WriteString(f.reply(cmd))
+	return nil
+}
+
+func (f *fakeIndicator) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	s := f.out.String()
+	f.out.Reset()
+	f.mu.Unlock()
+	if s == "" {
+		time.Sleep(2 * time.Millisecond)
+		return 0, nil
+	}
+	return copy(p, s), nil
+}
+
+func TestScaleControl_ZeroAndClearTare(t *testing.T) {
+	ind := &fakeIndicator{reply: func(cmd string) string {
+		switch cmd {
+		case "Z":
+			return "Z A\r\n"
+		case "T":
+			return "T I\r\n"
+		case "TAC":
+			return "TAC A\r\n"
+		}
+		return "S S 0.000 kg\r\n"
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmdr := newScaleCommander(sicsDriver{})
+	go streamDriver(ctx, ind, ind.send, cmdr.requests(), sicsDriver{}, "/dev/ttyUSB1", 9600, "kg", 20*time.Millisecond, time.Now, func(Reading) {})
+
+	store := bridgestate.New(filepath.Join(t.TempDir(), "bridge_state.json"))
+	tare, err := corepkg.NewTareRegister(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tare.Capture(0.5); err != nil {
+		t.Fatal(err)
+	}
+	g := newZeroGuard(appConfig{zeroPolicy: corepkg.ZeroPolicyBlock, zeroCfg: corepkg.DefaultZeroTrackerConfig()})
+	ctl := &scaleControl{cmdr: cmdr, tare: tare, zero: g, store: store}
+
+	res := ctl.run(ctx, bridgestate.ScaleCommandSnapshot{ID: "bot-1", Op: bridgestate.ScaleOpZero, RequestedBy: "bot"})
+	if res.Status != bridgestate.ScaleCommandOK || res.DoneAt == "" {
+		t.Fatalf("zero: %+v", res)
+	}
+	snap, err := store.Read()
+	if err != nil || snap.Command == nil || snap.Command.ID != "bot-1" || snap.Command.Status != bridgestate.ScaleCommandOK {
+		t.Fatalf("snapshot command: %+v err=%v", snap.Command, err)
+	}
+
+	if res := ctl.run(ctx, bridgestate.ScaleCommandSnapshot{Op: bridgestate.ScaleOpTare}); res.Status != bridgestate.ScaleCommandError || !strings.Contains(res.Error, "(I)") || res.ID == "" {
+		t.Fatalf("rejected tare: %+v", res)
+	}
+
+	if res := ctl.run(ctx, bridgestate.ScaleCommandSnapshot{Op: bridgestate.ScaleOpClearTare}); res.Status != bridgestate.ScaleCommandOK {
+		t.Fatalf("clear tare: %+v", res)
+	}
+	if tare.Resolve(nil).Active() {
+		t.Fatal("software tare should be cleared")
+	}
+	ind.mu.Lock()
+	sent := strings.Join(ind.sent, " ")
+	ind.mu.Unlock()
+	for _, want := range []string{"Z", "T", "TAC"} {
+		if !strings.Contains(" "+sent+" ", " "+want+" ") {
+			t.Fatalf("%s not sent: %s", want, sent)
+		}
+	}
+
+	if res := ctl.run(ctx, bridgestate.ScaleCommandSnapshot{Op: "calibrate"}); res.Status != bridgestate.ScaleCommandError {
+		t.Fatalf("unknown op: %+v", res)
+	}
+}
+
+func TestScaleCommander_ADAckBeforeFrame(t *testing.T) {
+	// A&D AK rejimi: T'ga alohida javob yo'q, ACK keyingi vazn frame'i boshida keladi.
+	ind := &fakeIndicator{reply: func(cmd string) string {
+		if cmd == "T" {
+			return "\x06ST,NT,+00000.000kg\r\n"
+		}
+		return "ST,GS,+00001.250kg\r\n"
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmdr := newScaleCommander(adDriver{})
+	var mu sync.Mutex
+	var ackReading *Reading
+	go streamDriver(ctx, ind, ind.send, cmdr.requests(), adDriver{}, "/dev/ttyUSB1", 9600, "kg", 20*time.Millisecond, time.Now, func(r Reading) {
+		mu.Lock()
+		if r.Raw == "\x06ST,NT,+00000.000kg" {
+			ackReading = &r
+		}
+		mu.Unlock()
+	})
+
+	if err := cmdr.Do(ctx, scaleOpTare); err != nil {
+		t.Fatalf("tare with ack-prefixed frame: %v", err)
+	}
+	// Frame'ning vazni ham o'qish sifatida chiqadi.
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		r := ackReading
+		mu.Unlock()
+		if r != nil {
+			if r.Weight == nil || *r.Weight != 0 || r.Error != "" {
+				t.Fatalf("ack frame reading: %+v", *r)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("ack frame weight lost")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestScaleCommander_StreamDriver(t *testing.T) {
+	ctl := &scaleControl{cmdr: newScaleCommander(nil)}
+	res := ctl.run(context.Background(), bridgestate.ScaleCommandSnapshot{Op: bridgestate.ScaleOpZero})
+	if res.Status != bridgestate.ScaleCommandError || !strings.Contains(res.Error, "driver=stream") {
+		t.Fatalf("stream zero: %+v", res)
+	}
+	// Indikator buyrug'i yo'q, lekin dastur tarasi tozalanadi.
+	if res := ctl.run(context.Background(), bridgestate.ScaleCommandSnapshot{Op: bridgestate.ScaleOpClearTare}); res.Status != bridgestate.ScaleCommandOK {
+		t.Fatalf("stream clear tare: %+v", res)
+	}
+}
+
+func TestWatchScaleCommands_ExpiresStale(t *testing.T) {
+	store := bridgestate.New(filepath.Join(t.TempDir(), "bridge_state.json"))
+	old := bridgestate.ScaleCommandSnapshot{
+		ID:          "bot-old",
+		Op:          bridgestate.ScaleOpZero,
+		Status:      bridgestate.ScaleCommandPending,
+		RequestedAt: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339Nano),
+	}
+	if err := store.Update(func(s *bridgestate.Snapshot) { s.Command = &old }); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watchScaleCommands(ctx, store, &scaleControl{cmdr: newScaleCommander(nil), store: store})
+
+	wait := func(id string) bridgestate.ScaleCommandSnapshot {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if snap, err := store.Read(); err == nil && snap.Command != nil && snap.Command.ID == id && !snap.Command.Pending() {
+				return *snap.Command
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("command %s still pending", id)
+		return bridgestate.ScaleCommandSnapshot{}
+	}
+	// Scale qayta ishga tushganda state'da qolgan eski so'rov bajarilmaydi.
+	if got := wait("bot-old"); got.Status != bridgestate.ScaleCommandExpired {
+		t.Fatalf("stale command: %+v", got)
+	}
+
+	fresh := old
+	fresh.ID = "bot-new"
+	fresh.RequestedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := store.Update(func(s *bridgestate.Snapshot) { s.Command = &fresh }); err != nil {
+		t.Fatal(err)
+	}
+	if got := wait("bot-new"); got.Status != bridgestate.ScaleCommandError || !strings.Contains(got.Error, "driver=stream") {
+		t.Fatalf("fresh command must run: %+v", got)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const serialByIDGlob = "/dev/serial/by-id/*"
+
+// scaleOutage serial uzilish oynasi: Until nol bo'lsa hali tiklanmagan.
+type scaleOutage struct {
+	Since         time.Time
+	Until         time.Time
+	Port          string
+	RecoveredPort string
+}
+
+func (o *scaleOutage) active() bool {
+	return o != nil && o.Until.IsZero()
+}
+
+// serialHotplug USB-serial adapter chiqarib qayta ulanganda tarozi port'ini qayta topadi.
+// Identity /dev/serial/by-id symlink'i (adapter serial raqami bilan): ttyUSB0 ttyUSB1 bo'lib
+// qaytsa ham symlink o'sha adapterga ishora qiladi. Port topilgach detectScalePort eslab
+// qolingan baud va driver bilan qayta ishga tushiriladi.
+type serialHotplug struct {
+	byID string
+	// fixed --device qo'lda berilgan va by-id yo'q: boshqa port'lar skanerlanmaydi.
+	fixed        bool
+	baud         int
+	probeTimeout time.Duration
+	unit         string
+	driver       string
+
+	resolve func(string) (string, error)
+	exists  func(string) bool
+	detect  func(device string, bauds []int, probeTimeout time.Duration, unit, driver string) (string, int, string, error)
+	// scan identity yo'q bo'lganda port'larni skanerlaydi: faqat tarozi topilgan port.
+	scan func(bauds []int, probeTimeout time.Duration, unit, driver string) (string, int, string, error)
+}
+
+func newSerialHotplug(device string, baud int, probeTimeout time.Duration, unit, driver string, fixed bool) *serialHotplug {
+	return &serialHotplug{
+		byID:         serialByID(device),
+		fixed:        fixed,
+		baud:         baud,
+		probeTimeout: probeTimeout,
+		unit:         unit,
+		driver:       driver,
+		resolve:      filepath.EvalSymlinks,
+		exists:       pathExists,
+		detect:       detectScalePort,
+		scan:         findScalePort,
+	}
+}
+
+// serialByID device'ga ishora qiluvchi /dev/serial/by-id yo'li ("" = udev identity yo'q).
+func serialByID(device string) string {
+	target, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return ""
+	}
+	links, _ := filepath.Glob(serialByIDGlob)
+	sort.Strings(links)
+	for _, link := range links {
+		if t, err := filepath.EvalSymlinks(link); err == nil && t == target {
+			return link
+		}
+	}
+	return ""
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// relocate uzilishdan keyin tarozi hozir qaysi port'da: by-id symlink qayerga ishora qilsa o'sha,
+// identity yo'q bo'lsa joriy yo'l (hali mavjud bo'lsa) yoki barcha port'lar eslab qolingan baud bilan.
+// Skanerda faqat probe tarozi frame'ini topgan port olinadi. Adapter hali qaytmagan yoki tarozi
+// topilmagan bo'lsa xato (uzilish davom etadi).
+func (h *serialHotplug) relocate(current string) (string, error) {
+	hint := current
+	switch {
+	case h.byID != "":
+		target, err := h.resolve(h.byID)
+		if err != nil {
+			return "", fmt.Errorf("%s yo'q (adapter uzilgan)", filepath.Base(h.byID))
+		}
+		hint = target
+	case h.fixed || h.exists(current):
+	default:
+		hint = ""
+	}
+	if hint != "" && !h.exists(hint) {
+		return "", fmt.Errorf("%s yo'q (adapter uzilgan)", hint)
+	}
+	detect := h.detect
+	if hint == "" {
+		detect = func(_ string, bauds []int, probeTimeout time.Duration, unit, driver string) (string, int, string, error) {
+			return h.scan(bauds, probeTimeout, unit, driver)
+		}
+	}
+	dev, _, _, err := detect(hint, []int{h.baud}, h.probeTimeout, h.unit, h.driver)
+	if err != nil {
+		return "", err
+	}
+	if dev == "" {
+		return "", errors.New("serial device topilmadi")
+	}
+	return dev, nil
+}
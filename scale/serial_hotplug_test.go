@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeHotplug by-id symlink va mavjud device'larni xotirada saqlaydi; detect chaqiruvlari yoziladi.
+type fakeHotplug struct {
+	link    string
+	devices map[string]bool
+	calls   []string
+	// scale skanerda tarozi topiladigan port ("" = hech birida frame yo'q).
+	scale string
+}
+
+func (f *fakeHotplug) hotplug(byID string, fixed bool) *serialHotplug {
+	return &serialHotplug{
+		byID:  byID,
+		fixed: fixed,
+		baud:  19200,
+		unit:  "kg",
+		resolve: func(string) (string, error) {
+			if f.link == "" {
+				return "", errors.New("no such file")
+			}
+			return f.link, nil
+		},
+		exists: func(p string) bool { return f.devices[p] },
+		detect: func(device string, bauds []int, _ time.Duration, _, _ string) (string, int, string, error) {
+			f.calls = append(f.calls, device)
+			if len(bauds) != 1 || bauds[0] != 19200 {
+				return "", 0, "", errors.New("baud not remembered")
+			}
+			if device == "" {
+				return "", 0, "", errors.New("detect must not scan")
+			}
+			return device, bauds[0], driverStream, nil
+		},
+		scan: func(bauds []int, _ time.Duration, _, _ string) (string, int, string, error) {
+			f.calls = append(f.calls, "scan")
+			if f.scale == "" {
+				return "", 0, "", errors.New("tarozi topilmadi")
+			}
+			return f.scale, bauds[0], driverStream, nil
+		},
+	}
+}
+
+func TestSerialHotplugFollowsByIDToNewPort(t *testing.T) {
+	f := &fakeHotplug{devices: map[string]bool{}}
+	hp := f.hotplug("/dev/serial/by-id/usb-FTDI_FT232R_A1B2C3-if00-port0", false)
+
+	if _, err := hp.relocate("/dev/ttyUSB0"); err == nil {
+		t.Fatalf("expected error while adapter is unplugged")
+	}
+	if len(f.calls) != 0 {
+		t.Fatalf("detect should not run while by-id is missing: %v", f.calls)
+	}
+
+	f.link = "/dev/ttyUSB1"
+	f.devices["/dev/ttyUSB1"] = true
+	dev, err := hp.relocate("/dev/ttyUSB0")
+	if err != nil {
+		t.Fatalf("relocate: %v", err)
+	}
+	if dev != "/dev/ttyUSB1" || len(f.calls) != 1 || f.calls[0] != "/dev/ttyUSB1" {
+		t.Fatalf("relocate mismatch: dev=%s calls=%v", dev, f.calls)
+	}
+}
+
+func TestSerialHotplugWithoutIdentity(t *testing.T) {
+	f := &fakeHotplug{devices: map[string]bool{"/dev/ttyUSB0": true}}
+	hp := f.hotplug("", false)
+
+	// Yo'l hali bor: o'sha port'ning o'zi.
+	if dev, err := hp.relocate("/dev/ttyUSB0"); err != nil || dev != "/dev/ttyUSB0" {
+		t.Fatalf("same path mismatch: dev=%s err=%v", dev, err)
+	}
+
+	// Yo'l yo'qoldi, boshqa port'larda tarozi frame'i yo'q (printer, modem): birinchi port
+	// olinmaydi, uzilish davom etadi.
+	delete(f.devices, "/dev/ttyUSB0")
+	if dev, err := hp.relocate("/dev/ttyUSB0"); err == nil {
+		t.Fatalf("scan without a scale must keep the outage, got %s", dev)
+	}
+
+	// Tarozi qaytdi: skaner frame topgan port olinadi.
+	f.scale = "/dev/ttyUSB3"
+	if dev, err := hp.relocate("/dev/ttyUSB0"); err != nil || dev != "/dev/ttyUSB3" {
+		t.Fatalf("rescan mismatch: dev=%s err=%v", dev, err)
+	}
+
+	// --device qo'lda berilgan bo'lsa boshqa port'ga o'tilmaydi.
+	fixed := f.hotplug("", true)
+	if _, err := fixed.relocate("/dev/ttyUSB0"); err == nil {
+		t.Fatalf("fixed device: expected error while path is missing")
+	}
+}
+
+func TestOutageSnapshotOf(t *testing.T) {
+	since := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	active := &scaleOutage{Since: since, Port: "/dev/ttyUSB0"}
+	snap := outageSnapshotOf(active)
+	if snap == nil || !snap.Active || snap.Until != "" || snap.DurationMS != 0 || snap.Port != "/dev/ttyUSB0" {
+		t.Fatalf("active outage mismatch: %+v", snap)
+	}
+
+	done := *active
+	done.Until = since.Add(4500 * time.Millisecond)
+	done.RecoveredPort = "/dev/ttyUSB1"
+	snap = scaleSnapshotOf(Reading{Source: "serial", Outage: &done}).Outage
+	if snap == nil || snap.Active || snap.DurationMS != 4500 || snap.RecoveredPort != "/dev/ttyUSB1" {
+		t.Fatalf("recovered outage mismatch: %+v", snap)
+	}
+	if snap.Since != "2026-10-17T09:00:00Z" || snap.Until != "2026-10-17T09:00:04.5Z" {
+		t.Fatalf("window mismatch: %+v", snap)
+	}
+
+	if outageSnapshotOf(nil) != nil {
+		t.Fatalf("nil outage should stay nil")
+	}
+}
@@ -3,15 +3,36 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/tarm/serial"
 )
 
-func startSerialReader(ctx context.Context, device string, baud int, unit string, out chan<- Reading) error {
+// startSerialReader recorder nil bo'lmasa port'dan kelgan har bir chunk trace'ga yoziladi.
+// drv nil bo'lsa passiv oqim (streamSerial), aks holda driver protokoli, poll - so'rov oralig'i.
+// cmdr tare/zero buyruqlarini shu port orqali yuboradi (nil = buyruqsiz).
+// hp nil bo'lmasa uzilishdan keyin port hp.relocate bilan qayta topiladi (ttyUSB0 -> ttyUSB1) va
+// uzilish oynasi har Reading'ning Outage maydonida yuriladi.
+func startSerialReader(ctx context.Context, device string, baud int, unit string, drv scaleDriver, poll time.Duration, cmdr *scaleCommander, out chan<- Reading, recorder *traceRecorder, hp *serialHotplug) error {
 	lg := workerLog("worker.serial")
-	lg.Printf("start: device=%s baud=%d unit=%s", strings.TrimSpace(device), baud, strings.TrimSpace(unit))
+	lg.Printf("start: device=%s baud=%d unit=%s driver=%s", strings.TrimSpace(device), baud, strings.TrimSpace(unit), driverName(drv))
+	if hp != nil && hp.byID != "" {
+		lg.Printf("hotplug identity: %s", hp.byID)
+	}
+	var outage *scaleOutage
+	emit := func(r Reading) {
+		r.Outage = outage
+		push(out, r)
+	}
+	fail := func(msg string) {
+		if outage == nil || !outage.active() {
+			outage = &scaleOutage{Since: time.Now(), Port: device}
+			lg.Printf("outage start: device=%s", device)
+		}
+		emit(Reading{Source: "serial", Port: device, Baud: baud, Unit: unit, Error: msg, UpdatedAt: time.Now()})
+	}
 	go func() {
 		for {
 			select {
@@ -20,17 +41,26 @@ func startSerialReader(ctx context.Context, device string, baud int, unit string
 			default:
 			}
 
+			if hp != nil && outage.active() {
+				dev, err := hp.relocate(device)
+				if err != nil {
+					lg.Printf("relocate: %v", err)
+					fail(fmt.Sprintf("serial uzilgan: %v", err))
+					if !sleepWithContext(ctx, 900*time.Millisecond) {
+						return
+					}
+					continue
+				}
+				if dev != device {
+					lg.Printf("hotplug: port switched %s -> %s", device, dev)
+					device = dev
+				}
+			}
+
 			port, err := serial.OpenPort(&serial.Config{Name: device, Baud: baud, ReadTimeout: 250 * time.Millisecond})
 			if err != nil {
 				lg.Printf("open error: %v", err)
-				push(out, Reading{
-					Source:    "serial",
-					Port:      device,
-					Baud:      baud,
-					Unit:      unit,
-					Error:     fmt.Sprintf("open error: %v", err),
-					UpdatedAt: time.Now(),
-				})
+				fail(fmt.Sprintf("open error: %v", err))
 				if !sleepWithContext(ctx, 900*time.Millisecond) {
 					return
 				}
@@ -38,7 +68,14 @@ func startSerialReader(ctx context.Context, device string, baud int, unit string
 			}
 
 			lg.Printf("port opened: device=%s baud=%d", device, baud)
-			push(out, Reading{
+			if outage.active() {
+				done := *outage
+				done.Until = time.Now()
+				done.RecoveredPort = device
+				outage = &done
+				lg.Printf("outage end: device=%s recovered=%s duration=%s", done.Port, device, done.Until.Sub(done.Since).Round(time.Millisecond))
+			}
+			emit(Reading{
 				Source:    "serial",
 				Port:      device,
 				Baud:      baud,
@@ -46,7 +83,19 @@ func startSerialReader(ctx context.Context, device string, baud int, unit string
 				UpdatedAt: time.Now(),
 			})
 
-			err = streamSerial(ctx, port, device, baud, unit, out)
+			var src io.Reader = port
+			if recorder != nil {
+				src = recorder.wrap(port)
+			}
+			if drv == nil {
+				err = streamSerial(ctx, src, device, baud, unit, time.Now, emit)
+			} else {
+				send := func(b []byte) error {
+					_, err := port.Write(b)
+					return err
+				}
+				err = streamDriver(ctx, src, send, cmdr.requests(), drv, device, baud, unit, poll, time.Now, emit)
+			}
 			_ = port.Close()
 			lg.Printf("port closed: device=%s err=%v", device, err)
 
@@ -56,14 +105,7 @@ func startSerialReader(ctx context.Context, device string, baud int, unit string
 
 			if err != nil {
 				lg.Printf("stream read error: %v", err)
-				push(out, Reading{
-					Source:    "serial",
-					Port:      device,
-					Baud:      baud,
-					Unit:      unit,
-					Error:     fmt.Sprintf("read error: %v", err),
-					UpdatedAt: time.Now(),
-				})
+				fail(fmt.Sprintf("read error: %v", err))
 			}
 
 			if !sleepWithContext(ctx, 400*time.Millisecond) {
@@ -75,7 +117,9 @@ func startSerialReader(ctx context.Context, device string, baud int, unit string
 	return nil
 }
 
-func streamSerial(ctx context.Context, port *serial.Port, device string, baud int, unit string, out chan<- Reading) error {
+// streamSerial src'dan frame'larni o'qib parse qiladi va har o'qishni emit'ga beradi.
+// now o'qish vaqtini beradi: live'da time.Now, replay'da trace'ning virtual soati.
+func streamSerial(ctx context.Context, src io.Reader, device string, baud int, unit string, now func() time.Time, emit func(Reading)) error {
 	lg := workerLog("worker.serial")
 	buf := make([]byte, 256)
 	pending := ""
@@ -84,6 +128,8 @@ func streamSerial(ctx context.Context, port *serial.Port, device string, baud in
 		lastUnit = "kg"
 	}
 	seenParsedValue := false
+	// Indikator yuborgan oxirgi tara (TR qatori); 0 kelsa o'chiriladi.
+	var indicatorTare *float64
 
 	for {
 		select {
@@ -92,7 +138,7 @@ func streamSerial(ctx context.Context, port *serial.Port, device string, baud in
 		default:
 		}
 
-		n, err := port.Read(buf)
+		n, err := src.Read(buf)
 		if err != nil {
 			return err
 		}
@@ -117,34 +163,59 @@ func streamSerial(ctx context.Context, port *serial.Port, device string, baud in
 				}
 				zero := 0.0
 				lg.Printf("frame empty -> weight=0")
-				push(out, Reading{
+				emit(Reading{
 					Source:    "serial",
 					Port:      device,
 					Baud:      baud,
 					Weight:    &zero,
 					Unit:      lastUnit,
 					Raw:       "<empty-frame>",
-					UpdatedAt: time.Now(),
+					UpdatedAt: now(),
 				})
 				continue
 			}
 
-			weight, parsedUnit, stable, ok := parseWeight(trimmed, unit)
+			body := trimmed
+			if tare, rest, ok := splitIndicatorTare(trimmed); ok {
+				indicatorTare = nil
+				if tare > 0 {
+					v := tare
+					indicatorTare = &v
+				}
+				lg.Printf("frame tare: tare=%.3f raw=%q", tare, trimmed)
+				if _, _, _, hasWeight := parseWeight(rest, unit); !hasWeight {
+					// Faqat tara qatori: keyingi GS/NT qatori bilan birga chiqadi.
+					continue
+				}
+				body = rest
+			}
+
+			weight, parsedUnit, stable, ok := parseWeight(body, unit)
 			if !ok {
 				// Keep stream alive even when a frame cannot be parsed.
 				lg.Printf("frame parse miss: raw=%q", trimmed)
-				push(out, Reading{
+				metricParseMisses.Inc()
+				emit(Reading{
 					Source:    "serial",
 					Port:      device,
 					Baud:      baud,
 					Unit:      lastUnit,
 					Raw:       trimmed,
-					UpdatedAt: time.Now(),
+					UpdatedAt: now(),
 				})
 				continue
 			}
 
 			w := weight
+			var frameTare *float64
+			if indicatorTare != nil {
+				v := *indicatorTare
+				frameTare = &v
+				if isNetFrame(body) {
+					// Reading.Weight har doim brutto.
+					w += v
+				}
+			}
 			if strings.TrimSpace(parsedUnit) != "" {
 				lastUnit = parsedUnit
 			}
@@ -158,15 +229,16 @@ func streamSerial(ctx context.Context, port *serial.Port, device string, baud in
 				}
 			}
 			lg.Printf("frame parsed: weight=%.3f unit=%s stable=%s raw=%q", w, lastUnit, stableText, trimmed)
-			push(out, Reading{
+			emit(Reading{
 				Source:    "serial",
 				Port:      device,
 				Baud:      baud,
 				Weight:    &w,
 				Unit:      lastUnit,
 				Stable:    stable,
+				Tare:      frameTare,
 				Raw:       trimmed,
-				UpdatedAt: time.Now(),
+				UpdatedAt: now(),
 			})
 		}
 	}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	sourceSerial = "serial"
+	// sourceTCP indikatorga client sifatida ulanadi (`tcp://host:port`).
+	sourceTCP = "tcp"
+	// sourceTCPListen indikator o'zi ulanadi, scale server (`tcp-listen://[host]:port`).
+	sourceTCPListen = "tcp-listen"
+	// sourceModbusRTU serial port orqali Modbus RTU (`modbus-rtu` + --device yoki `modbus-rtu:///dev/ttyUSB0`).
+	sourceModbusRTU = "modbus-rtu"
+	// sourceModbusTCP Modbus TCP transmitter (`modbus-tcp://host[:502]`).
+	sourceModbusTCP = "modbus-tcp"
+
+	modbusTCPDefaultPort = "502"
+)
+
+// scaleSource --source qiymati: bo'sh yoki `serial` = serial auto-detect (default).
+// modbus-rtu'da Addr serial device yo'li (bo'sh bo'lsa --device ishlatiladi).
+type scaleSource struct {
+	Kind string
+	Addr string
+}
+
+func (s scaleSource) String() string {
+	if s.Kind == sourceSerial || s.Addr == "" {
+		return s.Kind
+	}
+	return s.Kind + "://" + s.Addr
+}
+
+// isModbus manba Modbus (RTU yoki TCP) registrlaridan o'qiladimi.
+func (s scaleSource) isModbus() bool {
+	return s.Kind == sourceModbusRTU || s.Kind == sourceModbusTCP
+}
+
+// parseScaleSource `serial`, `tcp://host:port`, `tcp-listen://[host]:port`,
+// `modbus-rtu[:///dev/ttyX]` yoki `modbus-tcp://host[:port]`.
+func parseScaleSource(raw string) (scaleSource, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, sourceSerial) {
+		return scaleSource{Kind: sourceSerial}, nil
+	}
+	if strings.EqualFold(raw, sourceModbusRTU) {
+		return scaleSource{Kind: sourceModbusRTU}, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return scaleSource{}, fmt.Errorf("--source noto'g'ri: %q: %v", raw, err)
+	}
+	kind := strings.ToLower(u.Scheme)
+	switch kind {
+	case sourceTCP, sourceTCPListen, sourceModbusTCP:
+	case sourceModbusRTU:
+		if u.Host != "" || u.RawQuery != "" || !strings.HasPrefix(u.Path, "/") {
+			return scaleSource{}, fmt.Errorf("--source noto'g'ri: %q (modbus-rtu:///dev/ttyUSB0)", raw)
+		}
+		return scaleSource{Kind: kind, Addr: u.Path}, nil
+	default:
+		return scaleSource{}, fmt.Errorf("--source noto'g'ri: %q (serial, tcp://host:port, tcp-listen://:port, modbus-rtu yoki modbus-tcp://host:port)", raw)
+	}
+	if u.Path != "" && u.Path != "/" || u.RawQuery != "" {
+		return scaleSource{}, fmt.Errorf("--source noto'g'ri: %q (faqat host:port)", raw)
+	}
+	hostPort := u.Host
+	if kind == sourceModbusTCP && u.Port() == "" && u.Hostname() != "" {
+		hostPort = net.JoinHostPort(u.Hostname(), modbusTCPDefaultPort)
+	}
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return scaleSource{}, fmt.Errorf("--source noto'g'ri: %q: %v", raw, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return scaleSource{}, fmt.Errorf("--source port noto'g'ri: %q", port)
+	}
+	if kind != sourceTCPListen && host == "" {
+		return scaleSource{}, fmt.Errorf("--source %q: host kerak (server rejimi uchun tcp-listen://:%s)", raw, port)
+	}
+	return scaleSource{Kind: kind, Addr: hostPort}, nil
+}
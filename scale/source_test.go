@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestParseScaleSource(t *testing.T) {
+	cases := []struct {
+		raw  string
+		kind string
+		addr string
+	}{
+		{"", sourceSerial, ""},
+		{"serial", sourceSerial, ""},
+		{"tcp://192.168.1.50:4001", sourceTCP, "192.168.1.50:4001"},
+		{"tcp-listen://:4001", sourceTCPListen, ":4001"},
+		{"TCP-LISTEN://0.0.0.0:4001", sourceTCPListen, "0.0.0.0:4001"},
+		{"modbus-tcp://10.0.0.7", sourceModbusTCP, "10.0.0.7:502"},
+		{"modbus-tcp://10.0.0.7:1502", sourceModbusTCP, "10.0.0.7:1502"},
+		{"modbus-rtu", sourceModbusRTU, ""},
+		{"modbus-rtu:///dev/ttyUSB1", sourceModbusRTU, "/dev/ttyUSB1"},
+	}
+	for _, tc := range cases {
+		got, err := parseScaleSource(tc.raw)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.raw, err)
+		}
+		if got.Kind != tc.kind || got.Addr != tc.addr {
+			t.Fatalf("%q: got=%+v", tc.raw, got)
+		}
+	}
+}
+
+func TestParseScaleSourceRejects(t *testing.T) {
+	for _, raw := range []string{"udp://h:1", "tcp://:4001", "tcp://host", "tcp://host:0", "tcp://host:4001/x", "/dev/ttyUSB0", "modbus-tcp://:502", "modbus-rtu://host/x"} {
+		if _, err := parseScaleSource(raw); err == nil {
+			t.Fatalf("%q: expected error", raw)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	corepkg "core"
+	"strings"
+)
+
+// applyTare o'qishga amaldagi tarani qo'yadi (indikator > tugma/preset) va Net'ni
+// hisoblaydi. Weight o'zgarmaydi (brutto); vazn yo'q bo'lsa Net ham yo'q.
+func applyTare(reg *corepkg.TareRegister, rd Reading) Reading {
+	indicator := rd.Tare
+	if rd.TareSource != "" && rd.TareSource != string(corepkg.TareSourceIndicator) {
+		// Qayta hisoblash (refreshTare): Tare'da avvalgi preset/tugma tarasi turibdi.
+		indicator = nil
+	}
+	t := reg.Resolve(indicator)
+	rd.Tare, rd.Net, rd.TareSource, rd.Container = nil, nil, "", ""
+	if t.Active() {
+		v := t.Value
+		rd.Tare = &v
+		rd.TareSource = string(t.Source)
+		rd.Container = t.Container
+	}
+	if rd.Weight == nil {
+		return rd
+	}
+	net := t.Apply(*rd.Weight).Net
+	rd.Net = &net
+	return rd
+}
+
+// netWeight label, check-weigh va ERP qty uchun vazn: Net bo'lsa u, aks holda Weight.
+func (r Reading) netWeight() *float64 {
+	if r.Net != nil {
+		return r.Net
+	}
+	return r.Weight
+}
+
+// tareText TUI uchun: `1.200 (preset crate)` yoki `-`.
+func tareText(rd Reading) string {
+	if rd.Tare == nil {
+		return "-"
+	}
+	t := corepkg.Tare{Value: *rd.Tare, Source: corepkg.TareSource(rd.TareSource), Container: rd.Container}
+	return t.String()
+}
+
+// newTareRegister --tare-presets/--container dan register yasaydi. Preset'siz ham
+// register bor: tugma va indikator tarasi doim ishlaydi.
+func newTareRegister(cfg appConfig) (*corepkg.TareRegister, error) {
+	if strings.TrimSpace(cfg.tarePresets) == "" {
+		return corepkg.NewTareRegister(nil)
+	}
+	reg, err := corepkg.LoadTareRegister(cfg.tarePresets)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := reg.SelectContainer(cfg.container); err != nil {
+		return nil, err
+	}
+	workerLog("main").Printf("tare presets: file=%s containers=%v container=%s", cfg.tarePresets, reg.Containers(), safeText("-", cfg.container))
+	return reg, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	corepkg "core"
+	"testing"
+)
+
+func TestApplyTare(t *testing.T) {
+	reg, err := corepkg.NewTareRegister(map[string]float64{"crate": 1.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gross := 11.2
+	rd := applyTare(reg, Reading{Weight: &gross})
+	if rd.Tare != nil || rd.Net == nil || *rd.Net != 11.2 {
+		t.Fatalf("no tare: %+v", rd)
+	}
+
+	if _, err := reg.SelectContainer("crate"); err != nil {
+		t.Fatal(err)
+	}
+	rd = applyTare(reg, rd)
+	if *rd.netWeight() != 10 || *rd.Weight != 11.2 || rd.TareSource != "preset" || rd.Container != "crate" {
+		t.Fatalf("preset: net=%v %+v", *rd.netWeight(), rd)
+	}
+
+	// Preset tarasi qayta hisoblashda indikator tarasi deb olinmasin.
+	reg.Clear()
+	rd = applyTare(reg, rd)
+	if rd.Tare != nil || *rd.netWeight() != 11.2 {
+		t.Fatalf("cleared: %+v", rd)
+	}
+
+	ind := 0.2
+	rd = applyTare(reg, Reading{Weight: &gross, Tare: &ind})
+	if *rd.netWeight() != 11 || rd.TareSource != "indicator" {
+		t.Fatalf("indicator: %+v", rd)
+	}
+	if snap := scaleSnapshotOf(rd); *snap.Gross != 11.2 || *snap.Net != 11 || *snap.Tare != 0.2 || *snap.Qty() != 11 {
+		t.Fatalf("snapshot: %+v", snap)
+	}
+}
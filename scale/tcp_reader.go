@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	tcpDialTimeout = 3 * time.Second
+	// tcpReadTick serial ReadTimeout'i bilan bir xil: ctx va buyruqlar shu oraliqda tekshiriladi.
+	tcpReadTick = 250 * time.Millisecond
+)
+
+// tcpConnReader conn'dan serial port kabi o'qiydi: tcpReadTick ichida ma'lumot kelmasa (0, nil),
+// idle > 0 bo'lsa va shuncha vaqt bayt kelmasa ulanish o'lik deb xato qaytadi (reconnect).
+type tcpConnReader struct {
+	conn     net.Conn
+	idle     time.Duration
+	lastData time.Time
+}
+
+func (r *tcpConnReader) Read(p []byte) (int, error) {
+	_ = r.conn.SetReadDeadline(time.Now().Add(tcpReadTick))
+	n, err := r.conn.Read(p)
+	if n > 0 {
+		r.lastData = time.Now()
+	}
+	var ne net.Error
+	if err != nil && errors.As(err, &ne) && ne.Timeout() {
+		if r.idle > 0 && time.Since(r.lastData) > r.idle {
+			return n, fmt.Errorf("tcp: %s davomida ma'lumot kelmadi", r.idle)
+		}
+		return n, nil
+	}
+	return n, err
+}
+
+// startTCPReader Ethernet indikatordan o'qiydi: tcp rejimida ulanib, uzilsa qayta ulanadi;
+// tcp-listen rejimida listener ochiq qoladi va har ulanish navbat bilan o'qiladi.
+// Frame'lar serial bilan bir xil (streamSerial/streamDriver), Reading.Source = "tcp".
+// keepAlive TCP keepalive davri (0 = o'chirilgan), idle - ma'lumotsiz qolsa reconnect (0 = o'chirilgan).
+func startTCPReader(ctx context.Context, src scaleSource, unit string, drv scaleDriver, poll, keepAlive, idle time.Duration, cmdr *scaleCommander, out chan<- Reading, recorder *traceRecorder) error {
+	lg := workerLog("worker.tcp")
+	lg.Printf("start: source=%s unit=%s driver=%s keepalive=%s idle=%s", src, strings.TrimSpace(unit), driverName(drv), keepAlive, idle)
+	emit := func(r Reading) {
+		r.Source = sourceTCP
+		push(out, r)
+	}
+	fail := func(msg string) {
+		emit(Reading{Port: src.Addr, Unit: unit, Error: msg, UpdatedAt: time.Now()})
+	}
+
+	var connect func() (net.Conn, error)
+	switch src.Kind {
+	case sourceTCP:
+		dialer := &net.Dialer{Timeout: tcpDialTimeout, KeepAlive: -1}
+		connect = func() (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", src.Addr)
+		}
+	case sourceTCPListen:
+		ln, err := net.Listen("tcp", src.Addr)
+		if err != nil {
+			return fmt.Errorf("tcp listen: %w", err)
+		}
+		lg.Printf("listening: addr=%s", ln.Addr())
+		go func() {
+			<-ctx.Done()
+			_ = ln.Close()
+		}()
+		connect = ln.Accept
+	default:
+		return fmt.Errorf("tcp source noto'g'ri: %s", src)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			conn, err := connect()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				lg.Printf("connect error: %v", err)
+				fail(fmt.Sprintf("connect error: %v", err))
+				if !sleepWithContext(ctx, 900*time.Millisecond) {
+					return
+				}
+				continue
+			}
+			if tc, ok := conn.(*net.TCPConn); ok {
+				if keepAlive > 0 {
+					_ = tc.SetKeepAlive(true)
+					_ = tc.SetKeepAlivePeriod(keepAlive)
+				} else {
+					_ = tc.SetKeepAlive(false)
+				}
+			}
+
+			peer := conn.RemoteAddr().String()
+			lg.Printf("connected: source=%s peer=%s", src, peer)
+			emit(Reading{Port: src.Addr, Unit: unit, UpdatedAt: time.Now()})
+
+			var rd io.Reader = &tcpConnReader{conn: conn, idle: idle, lastData: time.Now()}
+			if recorder != nil {
+				rd = recorder.wrap(rd)
+			}
+			if drv == nil {
+				err = streamSerial(ctx, rd, src.Addr, 0, unit, time.Now, emit)
+			} else {
+				send := func(b []byte) error {
+					_ = conn.SetWriteDeadline(time.Now().Add(tcpDialTimeout))
+					_, err := conn.Write(b)
+					return err
+				}
+				err = streamDriver(ctx, rd, send, cmdr.requests(), drv, src.Addr, 0, unit, poll, time.Now, emit)
+			}
+			_ = conn.Close()
+			lg.Printf("connection closed: peer=%s err=%v", peer, err)
+
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				fail(fmt.Sprintf("read error: %v", err))
+			}
+			if !sleepWithContext(ctx, 400*time.Millisecond) {
+				return
+			}
+		}
+	}()
+
+	return nil
+}
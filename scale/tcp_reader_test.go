@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPReaderClientReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for _, frame := range []string{"ST,GS,+  1.250kg\r\n", "ST,GS,+  2.500kg\r\n"} {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte(frame))
+			time.Sleep(50 * time.Millisecond)
+			_ = conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan Reading, 32)
+	src := scaleSource{Kind: sourceTCP, Addr: ln.Addr().String()}
+	if err := startTCPReader(ctx, src, "kg", nil, 0, 0, time.Second, nil, out, nil); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	var weights []float64
+	deadline := time.After(5 * time.Second)
+	for len(weights) < 2 {
+		select {
+		case r := <-out:
+			if r.Source != sourceTCP {
+				t.Fatalf("source mismatch: %+v", r)
+			}
+			if r.Weight != nil {
+				weights = append(weights, *r.Weight)
+			}
+		case <-deadline:
+			t.Fatalf("timeout, weights=%v", weights)
+		}
+	}
+	if weights[0] != 1.25 || weights[1] != 2.5 {
+		t.Fatalf("weights mismatch: %v", weights)
+	}
+}
+
+func TestTCPReaderListenAcceptsIndicator(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan Reading, 32)
+	src := scaleSource{Kind: sourceTCPListen, Addr: addr}
+	if err := startTCPReader(ctx, src, "kg", nil, 0, 0, 0, nil, out, nil); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte("  3.75 kg\r\n"))
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case r := <-out:
+			if r.Weight == nil {
+				continue
+			}
+			if *r.Weight != 3.75 || r.Source != sourceTCP {
+				t.Fatalf("reading mismatch: %+v", r)
+			}
+			return
+		case <-deadline:
+			t.Fatalf("timeout")
+		}
+	}
+}
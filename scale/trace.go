@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trace fayl formati (matn, qatorma-qator):
+//
+//	# gscale-trace v1 device=/dev/ttyUSB0 baud=9600 unit=kg driver=stream start=2026-10-17T09:00:00Z
+//	0 "ST,GS,+0001.250kg\r\n"
+//	105 "ST,GS,+0001.250kg\r\n"
+//
+// Har qator: trace boshidan millisekund va port'dan o'qilgan xom bayt'lar (Go quoted).
+// `#` izoh; header ixtiyoriy, replay undan device/baud/unit/driver/start oladi.
+// So'rovli driver'larda (sics, ad) faqat javoblar yoziladi; replay so'rov yubormaydi.
+const traceHeaderPrefix = "# gscale-trace v1"
+
+// traceChunk port'dan bitta Read natijasi.
+type traceChunk struct {
+	Offset time.Duration
+	Data   string
+}
+
+// serialTrace yozib olingan serial oqim.
+type serialTrace struct {
+	Device string
+	Baud   int
+	Unit   string
+	Driver string
+	Start  time.Time
+	Chunks []traceChunk
+}
+
+// traceRecorder serial port'dan o'qilgan bayt'larni vaqt bilan trace faylga yozadi.
+// Port qayta ochilsa ham (reconnect) bitta fayl va bitta boshlang'ich vaqt ishlatiladi.
+type traceRecorder struct {
+	mu    sync.Mutex
+	f     *os.File
+	w     *bufio.Writer
+	start time.Time
+	err   error
+}
+
+func newTraceRecorder(path, device string, baud int, unit, driver string) (*traceRecorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("trace record: %w", err)
+	}
+	r := &traceRecorder{f: f, w: bufio.NewWriter(f), start: time.Now()}
+	fmt.Fprintf(r.w, "%s device=%s baud=%d unit=%s driver=%s start=%s\n", traceHeaderPrefix, device, baud, unit, driver, r.start.UTC().Format(time.RFC3339Nano))
+	if err := r.w.Flush(); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("trace record: %w", err)
+	}
+	return r, nil
+}
+
+func (r *traceRecorder) record(at time.Time, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || len(data) == 0 {
+		return
+	}
+	fmt.Fprintf(r.w, "%d %s\n", at.Sub(r.start).Milliseconds(), strconv.Quote(string(data)))
+	// Har chunk'dan keyin flush: scale yiqilsa ham trace oxirigacha qoladi.
+	if err := r.w.Flush(); err != nil {
+		r.err = err
+		workerLog("worker.serial").Printf("trace record error: %v", err)
+	}
+}
+
+// wrap src'dan o'qilgan har bir chunk'ni trace'ga ham yozadi.
+func (r *traceRecorder) wrap(src io.Reader) io.Reader {
+	return recordingReader{src: src, rec: r}
+}
+
+func (r *traceRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	flushErr := r.w.Flush()
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+type recordingReader struct {
+	src io.Reader
+	rec *traceRecorder
+}
+
+func (rr recordingReader) Read(p []byte) (int, error) {
+	n, err := rr.src.Read(p)
+	if n > 0 {
+		rr.rec.record(time.Now(), p[:n])
+	}
+	return n, err
+}
+
+// loadSerialTrace trace faylni o'qiydi.
+func loadSerialTrace(path string) (serialTrace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return serialTrace{}, fmt.Errorf("read trace: %w", err)
+	}
+	defer f.Close()
+	tr, err := parseSerialTrace(f)
+	if err != nil {
+		return serialTrace{}, fmt.Errorf("trace noto'g'ri (%s): %w", path, err)
+	}
+	return tr, nil
+}
+
+func parseSerialTrace(r io.Reader) (serialTrace, error) {
+	tr := serialTrace{Unit: "kg"}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, traceHeaderPrefix) {
+			parseTraceHeader(&tr, strings.TrimPrefix(line, traceHeaderPrefix))
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		msText, quoted, ok := strings.Cut(line, " ")
+		if !ok {
+			return serialTrace{}, fmt.Errorf("qator %d: `<ms> \"data\"` kerak", lineNo)
+		}
+		ms, err := strconv.ParseInt(msText, 10, 64)
+		if err != nil || ms < 0 {
+			return serialTrace{}, fmt.Errorf("qator %d: vaqt noto'g'ri: %q", lineNo, msText)
+		}
+		data, err := strconv.Unquote(strings.TrimSpace(quoted))
+		if err != nil {
+			return serialTrace{}, fmt.Errorf("qator %d: data noto'g'ri: %w", lineNo, err)
+		}
+		offset := time.Duration(ms) * time.Millisecond
+		if n := len(tr.Chunks); n > 0 && offset < tr.Chunks[n-1].Offset {
+			return serialTrace{}, fmt.Errorf("qator %d: vaqt orqaga ketdi", lineNo)
+		}
+		tr.Chunks = append(tr.Chunks, traceChunk{Offset: offset, Data: data})
+	}
+	if err := sc.Err(); err != nil {
+		return serialTrace{}, err
+	}
+	if tr.Start.IsZero() {
+		// Header'siz trace: deterministik bo'lishi uchun qat'iy boshlang'ich vaqt.
+		tr.Start = time.Unix(1_700_000_000, 0).UTC()
+	}
+	return tr, nil
+}
+
+func parseTraceHeader(tr *serialTrace, fields string) {
+	for _, kv := range strings.Fields(fields) {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "device":
+			tr.Device = v
+		case "baud":
+			if n, err := strconv.Atoi(v); err == nil {
+				tr.Baud = n
+			}
+		case "unit":
+			if v != "" {
+				tr.Unit = v
+			}
+		case "driver":
+			tr.Driver = v
+		case "start":
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				tr.Start = t
+			}
+		}
+	}
+}
+
+// traceReader trace chunk'larini serial port kabi qaytaradi va virtual soatni
+// chunk vaqtiga suradi. Oxirida io.EOF.
+type traceReader struct {
+	trace *serialTrace
+	next  int
+	pos   int
+	now   time.Time
+}
+
+func newTraceReader(tr *serialTrace) *traceReader {
+	return &traceReader{trace: tr, now: tr.Start}
+}
+
+func (r *traceReader) Read(p []byte) (int, error) {
+	if r.next >= len(r.trace.Chunks) {
+		return 0, io.EOF
+	}
+	c := r.trace.Chunks[r.next]
+	r.now = r.trace.Start.Add(c.Offset)
+	n := copy(p, c.Data[r.pos:])
+	r.pos += n
+	if r.pos >= len(c.Data) {
+		// Chunk buf'dan katta bo'lsa qolgani keyingi Read'da, xuddi shu vaqt bilan.
+		r.next++
+		r.pos = 0
+	}
+	return n, nil
+}
+
+// Now virtual soat: oxirgi o'qilgan chunk vaqti.
+func (r *traceReader) Now() time.Time { return r.now }
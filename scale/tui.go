@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bridge/ipc"
 	bridgestate "bridge/state"
 	"context"
 	corepkg "core"
@@ -17,6 +18,13 @@ type readingMsg struct {
 
 type zebraMsg struct {
 	status ZebraStatus
+	// trigger encode'ni qo'zg'atgan scale o'qishi (monitor/read uchun bo'sh).
+	trigger Reading
+}
+
+// scaleCommandMsg indikator buyrug'i (T/z/x tugmalari) natijasi.
+type scaleCommandMsg struct {
+	cmd bridgestate.ScaleCommandSnapshot
 }
 
 type quitMsg struct{}
@@ -30,6 +38,7 @@ type tuiModel struct {
 	sourceLine     string
 	zebraPreferred string
 	bridgeStore    *bridgestate.Store
+	bus            *ipc.Server
 	batchState     *batchStateReader
 	batchActive    bool
 	message        string
@@ -40,23 +49,39 @@ type tuiModel struct {
 	height         int
 	now            time.Time
 	autoDetector   *corepkg.StableEPCDetector
+	autoStatus     *autoStatus
+	auto           autoEncode
+	tare           *corepkg.TareRegister
+	ctl            *scaleControl
+	lastCheck      string
+	bridgeHealth   string
+	healthAt       time.Time
 }
 
-func runTUI(ctx context.Context, updates <-chan Reading, zebraUpdates <-chan ZebraStatus, sourceLine string, zebraPreferred string, bridgeStateFile string, autoWhenNoBatch bool, serialErr error) error {
+// bridgeHealthInterval TUI bridge holatini qanchada bir tekshiradi.
+const bridgeHealthInterval = 5 * time.Second
+
+func runTUI(ctx context.Context, updates <-chan Reading, zebraUpdates <-chan ZebraStatus, sourceLine string, zebraPreferred string, bridgeStore *bridgestate.Store, bus *ipc.Server, auto autoEncode, tare *corepkg.TareRegister, ctl *scaleControl, autoWhenNoBatch bool, serialErr error) error {
 	m := tuiModel{
 		ctx:            ctx,
 		updates:        updates,
 		zebraUpdates:   zebraUpdates,
 		sourceLine:     sourceLine,
 		zebraPreferred: zebraPreferred,
-		bridgeStore:    bridgestate.New(bridgeStateFile),
-		batchState:     newBatchStateReader(bridgeStateFile, autoWhenNoBatch),
+		bridgeStore:    bridgeStore,
+		bus:            bus,
+		batchState:     newBatchStateReaderFromStore(bridgeStore, autoWhenNoBatch),
 		batchActive:    true,
 		last:           Reading{Unit: "kg"},
 		message:        "scale oqimi kutilmoqda",
 		info:           "ready",
 		now:            time.Now(),
-		autoDetector:   corepkg.NewStableEPCDetector(corepkg.DefaultStableEPCConfig()),
+		autoDetector:   auto.detector,
+		autoStatus:     &autoStatus{},
+		auto:           auto,
+		tare:           tare,
+		ctl:            ctl,
+		lastCheck:      "-",
 		zebra: ZebraStatus{
 			Connected: false,
 			Verify:    "-",
@@ -65,8 +90,15 @@ func runTUI(ctx context.Context, updates <-chan Reading, zebraUpdates <-chan Zeb
 			UpdatedAt: time.Now(),
 		},
 	}
+	if m.autoDetector != nil {
+		m.autoDetector.SetEventHandler(m.autoStatus.handle)
+	}
 	if m.batchState != nil {
 		m.batchActive = m.batchState.Active(time.Now())
+		m.batchState.Watch(ctx)
+	}
+	if h := m.refreshBridgeHealth(time.Now()); h.LastRecovery != nil {
+		m.info = "bridge state tiklangan: " + h.Summary()
 	}
 	if serialErr != nil {
 		m.message = serialErr.Error()
@@ -108,12 +140,37 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.info = "zebra monitor o'chirilgan (--no-zebra)"
 				return m, nil
 			}
-			m.info = "encode+print yuborildi"
-			itemName := ""
-			if m.batchState != nil {
-				itemName = m.batchState.ItemLabel(time.Now())
+			if m.last.ZeroBlock {
+				m.info = "zero: " + m.last.ZeroAlarm + ": EPC berilmadi, tarozini qayta nollang"
+				return m, nil
+			}
+			now := time.Now()
+			itemCode, itemName := m.batchItem(now)
+			res, allow, note := m.auto.check(itemCode, m.last.netWeight())
+			if res.Class != "" {
+				m.lastCheck = res.String()
+			}
+			if !allow {
+				m.info = "check-weigh " + res.String() + ": EPC berilmadi"
+				return m, nil
+			}
+			lq, err := labelQtyFor(m.batchCounter(now), m.last)
+			if err != nil {
+				m.info = "counting: " + err.Error() + ": EPC berilmadi"
+				return m, nil
+			}
+			epc, scheme, err := m.auto.nextEPC(m.batchGTIN(now), now)
+			if err != nil {
+				m.info = "epc: " + err.Error() + ": EPC berilmadi"
+				return m, nil
+			}
+			epc, err = m.auto.issuer.issue(epc, scheme, lq.qty, lq.unit, itemCode, itemName, now)
+			if err != nil {
+				m.info = "epc registry xato: " + err.Error()
+				return m, nil
 			}
-			return m, runEncodeEPCCmd(m.zebraPreferred, m.last.Weight, m.last.Unit, itemName)
+			m.info = "encode+print yuborildi"
+			return m, runEncodeEPCCmdWithEPC(m.zebraPreferred, m.auto.issuer, epc, m.last, itemName, lq.text, note)
 		case "r":
 			if !m.batchActive {
 				m.info = "batch inactive: botda Material Issue ni bosing"
@@ -125,6 +182,39 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.info = "rfid read yuborildi"
 			return m, runRFIDReadCmd(m.zebraPreferred)
+		case "t":
+			if m.last.Weight == nil {
+				m.info = "tara: vazn yo'q"
+				return m, nil
+			}
+			t, err := m.tare.Capture(*m.last.Weight)
+			if err != nil {
+				m.info = "tara xato: " + err.Error()
+				return m, nil
+			}
+			m.info = "tara: " + t.String()
+			if m.last.TareSource == string(corepkg.TareSourceIndicator) {
+				m.info += " (indikator tarasi ustun)"
+			}
+			m.refreshTare()
+			return m, nil
+		case "T", "z", "x":
+			op := map[string]scaleOp{"T": scaleOpTare, "z": scaleOpZero, "x": scaleOpClearTare}[msg.String()]
+			if m.ctl == nil {
+				m.info = string(op) + ": scale buyruqlari yo'q"
+				return m, nil
+			}
+			m.info = string(op) + " yuborildi"
+			return m, runScaleCommandCmd(m.ctx, m.ctl, op)
+		case "c":
+			t, err := m.tare.NextContainer()
+			if err != nil {
+				m.info = "tara xato: " + err.Error()
+				return m, nil
+			}
+			m.info = "konteyner: " + t.String()
+			m.refreshTare()
+			return m, nil
 		default:
 			return m, nil
 		}
@@ -133,10 +223,13 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if upd.Unit == "" && m.last.Unit != "" {
 			upd.Unit = m.last.Unit
 		}
+		upd = applyTare(m.tare, upd)
+		upd = m.auto.zero.apply(upd)
 
 		prevBatchActive := m.batchActive
 		if m.batchState != nil {
 			m.batchActive = m.batchState.Active(time.Now())
+			metricBatchActive.Set(boolGauge(m.batchActive))
 		}
 		if prevBatchActive != m.batchActive {
 			if m.batchActive {
@@ -146,10 +239,19 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if m.batchActive {
+			itemCode, _ := m.batchItem(upd.UpdatedAt)
+			upd = m.auto.applyCheck(upd, itemCode)
+		}
+
 		m.last = upd
+		if upd.Weight != nil {
+			metricReadings.Inc(safeText("unknown", upd.Source))
+		}
 		if err := writeBridgeStateSnapshot(m.bridgeStore, upd, m.zebra); err != nil {
 			m.info = "bridge snapshot xato: " + err.Error()
 		}
+		publishScaleReading(m.bus, upd)
 		if upd.Error != "" {
 			m.message = upd.Error
 		} else {
@@ -159,33 +261,74 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd := waitForReadingCmd(m.ctx, m.updates)
 		if !m.batchActive {
 			if m.autoDetector != nil {
-				m.autoDetector.Observe(nil, upd.UpdatedAt)
+				m.autoDetector.Abort("batch inactive", upd.UpdatedAt)
 			}
 			return m, cmd
 		}
 
 		if m.zebraUpdates != nil && m.autoDetector != nil {
 			if upd.Weight != nil {
-				if epc, ok := m.autoDetector.Observe(upd.Weight, upd.UpdatedAt); ok {
-					m.info = fmt.Sprintf("auto encode queued: epc=%s", epc)
-					itemName := ""
-					if m.batchState != nil {
-						itemName = m.batchState.ItemLabel(upd.UpdatedAt)
+				if err := m.auto.syncGTIN(m.batchGTIN(upd.UpdatedAt)); err != nil {
+					m.info = "sgtin: " + err.Error()
+				}
+				if epc, ok := m.autoDetector.ObserveReading(upd.netWeight(), upd.Stable, upd.UpdatedAt); ok {
+					metricStableTriggers.Inc()
+					if upd.ZeroBlock {
+						// Nol noto'g'ri: vazn ishonchsiz, re-zero'gacha yangi sikl yo'q (block policy).
+						m.info = "zero: " + upd.ZeroAlarm + ": EPC berilmadi, tarozini qayta nollang"
+						return m, cmd
+					}
+					itemCode, itemName := m.batchItem(upd.UpdatedAt)
+					res, allow, note := m.auto.check(itemCode, upd.netWeight())
+					if res.Class != "" {
+						m.lastCheck = res.String()
+					}
+					if !allow {
+						// Tolerance'dan tashqari: EPC ham, label ham yo'q (block policy).
+						m.info = "check-weigh " + res.String() + ": EPC berilmadi"
+						return m, cmd
+					}
+					lq, err := labelQtyFor(m.batchCounter(upd.UpdatedAt), upd)
+					if err != nil {
+						// Dona soni ishonchsiz: noto'g'ri son label'ga ham, ERP'ga ham chiqmasin.
+						m.info = "counting: " + err.Error() + ": EPC berilmadi"
+						return m, cmd
 					}
-					cmd = tea.Batch(cmd, runEncodeEPCCmdWithEPC(m.zebraPreferred, epc, upd.Weight, upd.Unit, itemName))
+					epc, err := m.auto.issuer.issue(epc, m.autoDetector.Scheme(), lq.qty, lq.unit, itemCode, itemName, upd.UpdatedAt)
+					if err != nil {
+						// Unikalligi tasdiqlanmagan EPC yozilmaydi.
+						m.info = "epc registry xato: " + err.Error()
+						return m, cmd
+					}
+					workerLog("worker.auto").Printf("issued epc=%s item=%s", epc, itemCode)
+					m.info = fmt.Sprintf("auto encode queued: epc=%s", epc)
+					cmd = tea.Batch(cmd, runEncodeEPCCmdWithEPC(m.zebraPreferred, m.auto.issuer, epc, upd, itemName, lq.text, note))
 				}
 			} else if strings.TrimSpace(upd.Error) != "" {
 				// Connection/read errors should reset stability window.
-				m.autoDetector.Observe(nil, upd.UpdatedAt)
+				m.autoDetector.Abort(strings.TrimSpace(upd.Error), upd.UpdatedAt)
 			}
 		}
 		return m, cmd
+	case scaleCommandMsg:
+		c := msg.cmd
+		if c.Status != bridgestate.ScaleCommandOK {
+			m.info = fmt.Sprintf("%s xato: %s", c.Op, c.Error)
+			return m, nil
+		}
+		m.info = c.Op + ": indikator tasdiqladi"
+		if c.Op == bridgestate.ScaleOpClearTare {
+			m.info = "tara tozalandi"
+			m.refreshTare()
+		}
+		return m, nil
 	case zebraMsg:
 		st := mergeZebraStatus(m.zebra, msg.status)
 		m.zebra = st
 		if err := writeBridgeStateSnapshot(m.bridgeStore, m.last, m.zebra); err != nil {
 			m.info = "bridge snapshot xato: " + err.Error()
 		}
+		publishZebraResult(m.bus, st, msg.trigger)
 		if st.Action != "" {
 			m.info = zebraActionSummary(st)
 		}
@@ -200,12 +343,36 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	case clockMsg:
 		m.now = time.Time(msg)
+		if m.now.Sub(m.healthAt) >= bridgeHealthInterval {
+			m.refreshBridgeHealth(m.now)
+		}
 		return m, clockTickCmd()
 	default:
 		return m, nil
 	}
 }
 
+// refreshTare tara o'zgargach oxirgi o'qishni qayta hisoblaydi va bridge'ga yozadi,
+// shunda bot keyingi frame'ni kutmasdan netto vaznni ko'radi.
+func (m *tuiModel) refreshTare() {
+	m.last = applyTare(m.tare, m.last)
+	if err := writeBridgeStateSnapshot(m.bridgeStore, m.last, m.zebra); err != nil {
+		m.info = "bridge snapshot xato: " + err.Error()
+	}
+	publishScaleReading(m.bus, m.last)
+}
+
+func (m *tuiModel) refreshBridgeHealth(now time.Time) bridgestate.Health {
+	m.healthAt = now
+	if m.bridgeStore == nil {
+		m.bridgeHealth = "-"
+		return bridgestate.Health{}
+	}
+	h := m.bridgeStore.Health()
+	m.bridgeHealth = h.Summary()
+	return h
+}
+
 func mergeZebraStatus(prev ZebraStatus, incoming ZebraStatus) ZebraStatus {
 	st := incoming
 	if strings.TrimSpace(st.LastEPC) == "" && strings.TrimSpace(prev.LastEPC) != "" {
@@ -236,8 +403,12 @@ func (m tuiModel) View() string {
 		unit = "kg"
 	}
 	qty := "-- " + unit
-	if m.last.Weight != nil {
-		qty = fmt.Sprintf("%.3f %s", *m.last.Weight, unit)
+	if w := m.last.netWeight(); w != nil {
+		qty = fmt.Sprintf("%.3f %s", *w, unit)
+	}
+	gross := "-"
+	if m.last.Tare != nil && m.last.Weight != nil {
+		gross = fmt.Sprintf("%.3f %s", *m.last.Weight, unit)
 	}
 
 	status := strings.TrimSpace(m.message)
@@ -296,13 +467,21 @@ func (m tuiModel) View() string {
 
 	scaleLines := []string{
 		kv("STATUS", scaleState),
+		kv("STATION", elideMiddle(m.bridgeStore.StationID(), maxInt(20, panelW-16))),
 		kv("BATCH", batchGateText(m.batchActive)),
 		kv("QTY", qty),
+		kv("GROSS", gross),
+		kv("TARE", elideMiddle(tareText(m.last), maxInt(20, panelW-16))),
 		kv("STABLE", strings.ToUpper(stableText(m.last.Stable))),
+		kv("AUTO", elideMiddle(m.autoStatus.text(), maxInt(20, panelW-16))),
+		kv("CHECK", elideMiddle(safeText("-", m.lastCheck), maxInt(20, panelW-16))),
+		kv("COUNT", elideMiddle(countText(m.batchCounter(now), m.last), maxInt(20, panelW-16))),
+		kv("ZERO", elideMiddle(zeroText(m.auto.zero, m.last), maxInt(20, panelW-16))),
 		kv("UPDATED", updated),
 		kv("LAG", lag),
 		kv("SOURCE", elideMiddle(m.sourceLine, maxInt(20, panelW-16))),
 		kv("PORT", elideMiddle(port, maxInt(20, panelW-16))),
+		kv("BRIDGE", elideMiddle(safeText("-", m.bridgeHealth), maxInt(20, panelW-16))),
 	}
 
 	zebraLines := []string{
@@ -355,18 +534,42 @@ func waitForZebraCmd(ctx context.Context, updates <-chan ZebraStatus) tea.Cmd {
 	}
 }
 
-func runEncodeEPCCmd(preferredDevice string, weight *float64, unit, itemName string) tea.Cmd {
-	epc := generateTestEPC(time.Now())
-	return runEncodeEPCCmdWithEPC(preferredDevice, epc, weight, unit, itemName)
+// batchItem faol batch item kodi va label'i (batch yo'q bo'lsa bo'sh).
+func (m tuiModel) batchItem(now time.Time) (string, string) {
+	if m.batchState == nil {
+		return "", ""
+	}
+	return m.batchState.ItemCode(now), m.batchState.ItemLabel(now)
+}
+
+// batchGTIN faol batch item'ining GTIN'i (SGTIN-96 sxemasi uchun).
+func (m tuiModel) batchGTIN(now time.Time) string {
+	if m.batchState == nil {
+		return ""
+	}
+	return m.batchState.GTIN(now)
+}
+
+// batchCounter faol batch counting rejimida bo'lsa dona counter'i.
+func (m tuiModel) batchCounter(now time.Time) corepkg.PieceCounter {
+	if m.batchState == nil {
+		return corepkg.PieceCounter{}
+	}
+	return m.batchState.Counter(now)
 }
 
-func runEncodeEPCCmdWithEPC(preferredDevice, epc string, weight *float64, unit, itemName string) tea.Cmd {
-	qtyText := formatLabelQty(weight, unit)
+// runEncodeEPCCmdWithEPC qtyText label'dagi qty (vazn yoki dona); qtyNote bo'sh bo'lmasa
+// uning yoniga yoziladi (check-weigh mark). Encode natijasi issuer orqali registry'ga yoziladi.
+func runEncodeEPCCmdWithEPC(preferredDevice string, issuer *epcIssuer, epc string, trigger Reading, itemName, qtyText, qtyNote string) tea.Cmd {
+	if qtyNote = strings.TrimSpace(qtyNote); qtyNote != "" {
+		qtyText += " " + qtyNote
+	}
 	itemName = strings.TrimSpace(itemName)
 	return func() tea.Msg {
 		st := runZebraEncodeAndRead(preferredDevice, epc, qtyText, itemName, 1400*time.Millisecond)
 		st.UpdatedAt = time.Now()
-		return zebraMsg{status: st}
+		issuer.settle(epc, st)
+		return zebraMsg{status: st, trigger: trigger}
 	}
 }
 
@@ -381,6 +584,12 @@ func formatLabelQty(weight *float64, unit string) string {
 	return fmt.Sprintf("%.3f %s", *weight, u)
 }
 
+func runScaleCommandCmd(ctx context.Context, ctl *scaleControl, op scaleOp) tea.Cmd {
+	return func() tea.Msg {
+		return scaleCommandMsg{cmd: ctl.run(ctx, bridgestate.ScaleCommandSnapshot{Op: string(op), RequestedBy: "tui"})}
+	}
+}
+
 func runRFIDReadCmd(preferredDevice string) tea.Cmd {
 	return func() tea.Msg {
 		st := runZebraRead(preferredDevice, 1400*time.Millisecond)
@@ -465,7 +674,7 @@ func renderHeader(width int, now time.Time, scaleState, zebraState string) strin
 }
 
 func renderFooter(width int, info string) string {
-	left := "keys: [q] quit [e] encode+print [r] read"
+	left := "keys: [q] quit [e] encode+print [r] read [t] tare [c] container [T] scale tare [z] zero [x] clear tare"
 	text := left + " | " + strings.TrimSpace(info)
 	if strings.TrimSpace(info) == "" {
 		text = left
@@ -1,6 +1,9 @@
 package main
 
-import "time"
+import (
+	corepkg "core"
+	"time"
+)
 
 type Reading struct {
 	Source    string
@@ -12,6 +15,26 @@ type Reading struct {
 	Raw       string
 	Error     string
 	UpdatedAt time.Time
+
+	// Weight har doim brutto. Tare parser'da indikator yuborgan tara (TR/TARE/PT);
+	// applyTare dan keyin amaldagi tara (preset/tugma/indikator) va Net shu yerda.
+	Tare       *float64
+	Net        *float64
+	TareSource string
+	Container  string
+
+	// Zero-tracking (zeroGuard.apply): bo'sh platforma offset'i va alarm matni.
+	// ZeroBlock - block policy'da alarm: yangi siklga EPC berilmaydi.
+	ZeroOffset *float64
+	ZeroAlarm  string
+	ZeroBlock  bool
+
+	// Check faol batch item'i uchun check-weigh natijasi (autoEncode.applyCheck); CheckBlock -
+	// block policy'da tolerance'dan tashqari: EPC berilmaydi.
+	Check      *corepkg.CheckResult
+	CheckBlock bool
+	// Outage oxirgi serial uzilishi (hot-plug); Until nol bo'lsa hali davom etmoqda.
+	Outage *scaleOutage
 }
 
 type scaleAPIResponse struct {
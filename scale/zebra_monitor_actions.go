@@ -39,13 +39,14 @@ func runZebraRead(preferredDevice string, timeout time.Duration) ZebraStatus {
 	return st
 }
 
-func runZebraEncodeAndRead(preferredDevice, epc, qtyText, itemName string, timeout time.Duration) ZebraStatus {
+func runZebraEncodeAndRead(preferredDevice, epc, qtyText, itemName string, timeout time.Duration) (st ZebraStatus) {
 	lg := workerLog("worker.zebra_action")
 	lg.Printf("encode start: preferred_device=%s epc=%s qty=%s item=%s timeout=%s", preferredDevice, strings.TrimSpace(epc), strings.TrimSpace(qtyText), strings.TrimSpace(itemName), timeout)
 	zebraIOMutex.Lock()
 	defer zebraIOMutex.Unlock()
+	defer func() { observeEncodeResult(st) }()
 
-	st := ZebraStatus{
+	st = ZebraStatus{
 		Action:    "encode",
 		Verify:    "-",
 		UpdatedAt: time.Now(),
@@ -83,7 +84,7 @@ func runZebraEncodeAndRead(preferredDevice, epc, qtyText, itemName string, timeo
 	st.Attempts = attempts
 	st.AutoTuned = autoTuned
 	// Operator talabi bo'yicha encode urinish EPC'si har doim bridge'ga beriladi.
-	// Verify alohida signal sifatida qoladi (MATCH/WRITTEN/MISMATCH/NO TAG).
+	// Verify alohida signal sifatida qoladi (MATCH/WRITTEN/MISMATCH/CORRUPT/NO TAG).
 	st.LastEPC = attemptedEPC
 
 	st.DeviceState = safeText("-", queryVarRetry(p.DevicePath, "device.status", timeout, 3, 90*time.Millisecond))
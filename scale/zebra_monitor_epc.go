@@ -1,22 +1,9 @@
 package main
 
 import (
-	"crypto/rand"
-	"encoding/binary"
-	"errors"
+	corepkg "core"
 	"fmt"
-	"math/bits"
-	"os"
 	"strings"
-	"sync"
-	"time"
-)
-
-var (
-	testEPCMu     sync.Mutex
-	testEPCLastNS int64
-	testEPCSeq    uint32
-	testEPCSalt   uint32 = newEPCSalt()
 )
 
 func safeText(fallback, v string) string {
@@ -73,66 +60,14 @@ func buildRFIDEncodeCommand(epc, qtyText, itemName string) (string, error) {
 		"^XZ\n", nil
 }
 
+// normalizeEPC core.NormalizeEPC: hex, 16-bit word (22 hex = kesilgan PC word) va 8..64 uzunlik.
 func normalizeEPC(epc string) (string, error) {
-	v := strings.ToUpper(strings.TrimSpace(epc))
-	v = strings.TrimPrefix(v, "0X")
-	v = strings.ReplaceAll(v, " ", "")
-	v = strings.ReplaceAll(v, "-", "")
-
-	if v == "" {
-		return "", errors.New("epc bo'sh")
-	}
-	if !zebraHexOnlyRegex.MatchString(v) {
-		return "", errors.New("epc faqat hex bo'lishi kerak")
-	}
-	if len(v)%2 != 0 {
-		return "", errors.New("epc uzunligi juft bo'lishi kerak")
-	}
-	if len(v)%4 != 0 {
-		return "", errors.New("epc uzunligi 16-bit word (4 hex belgi) ga bo'linishi kerak")
-	}
-	if len(v) < 8 || len(v) > 64 {
-		return "", errors.New("epc uzunligi 8..64 oralig'ida bo'lsin")
-	}
-	return v, nil
-}
-
-func generateTestEPC(t time.Time) string {
-	if t.IsZero() {
-		t = time.Now()
-	}
-	ns := t.UnixNano()
-
-	testEPCMu.Lock()
-	if ns != testEPCLastNS {
-		testEPCLastNS = ns
-		testEPCSeq = 0
-	} else {
-		testEPCSeq++
-	}
-	seq := testEPCSeq
-	salt := testEPCSalt
-	testEPCMu.Unlock()
-
-	return formatEPC24(ns, seq, salt)
-}
-
-func formatEPC24(ns int64, seq, salt uint32) string {
-	atom := uint32((uint64(ns) / 1_000) & 0xFFFFFFFF)
-	tail := atom ^ bits.RotateLeft32(uint32(ns), 13) ^ bits.RotateLeft32(seq, 7) ^ salt
-	tail |= 1
-	return fmt.Sprintf("30%014X%08X", uint64(ns)&0x00FFFFFFFFFFFFFF, tail)
-}
-
-func newEPCSalt() uint32 {
-	var b [4]byte
-	if _, err := rand.Read(b[:]); err == nil {
-		return binary.BigEndian.Uint32(b[:]) | 1
-	}
-	fallback := uint32(time.Now().UnixNano()) ^ (uint32(os.Getpid()) << 16)
-	return fallback | 1
+	return corepkg.NormalizeEPC(epc)
 }
 
+// inferVerify readback qatorlaridan verify holatini chiqaradi. Kutilgan EPC topilmasa
+// o'qilgan hex core validatsiyasidan o'tkaziladi: yaroqsiz (kesilgan, checksum mos emas)
+// bo'lsa CORRUPT - tag noto'g'ri o'qilgan, readback qayta urinadi.
 func inferVerify(line1, line2, expected string) string {
 	line1 = strings.TrimSpace(strings.Trim(line1, "\""))
 	line2 = strings.TrimSpace(strings.Trim(line2, "\""))
@@ -153,6 +88,9 @@ func inferVerify(line1, line2, expected string) string {
 		if strings.Contains(strings.ReplaceAll(text, " ", ""), expected) {
 			return "MATCH"
 		}
+		if corepkg.ReadbackEPCCorrupt(text, expected) {
+			return "CORRUPT"
+		}
 		return "MISMATCH"
 	}
 
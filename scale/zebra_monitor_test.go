@@ -1,6 +1,7 @@
 package main
 
 import (
+	corepkg "core"
 	"strings"
 	"testing"
 	"time"
@@ -83,6 +84,14 @@ func TestFormatLabelQty(t *testing.T) {
 	}
 }
 
+// testEPCScheme test EPC'lari uchun: core'dagi vaqt sxemasi (CRC bilan).
+var testEPCScheme = corepkg.NewTimeEPCScheme()
+
+func generateTestEPC(t time.Time) string {
+	epc, _ := testEPCScheme.Next(t)
+	return epc
+}
+
 func TestGenerateTestEPC_LengthAndUniq(t *testing.T) {
 	t0 := time.Unix(1_700_000_000, 123_456_789)
 	a := generateTestEPC(t0)
@@ -111,3 +120,24 @@ func isUpperHexScale(v string) bool {
 	}
 	return true
 }
+
+func TestInferVerify_CorruptReadback(t *testing.T) {
+	expected := generateTestEPC(time.Unix(1_700_000_000, 0))
+	if got := inferVerify(`"`+expected+`"`, "", expected); got != "MATCH" {
+		t.Fatalf("match: %s", got)
+	}
+	// PC word noto'g'ri: reader oxirgi word'ni kesgan.
+	if got := inferVerify(expected[:22], "", expected); got != "CORRUPT" {
+		t.Fatalf("truncated: %s", got)
+	}
+	typo := []byte(expected)
+	typo[10] ^= 0x01
+	if got := inferVerify(string(typo), "", expected); got != "CORRUPT" {
+		t.Fatalf("bit error: %s (%s)", got, typo)
+	}
+	// Boshqa, lekin yaroqli tag (yonidagi label) - MISMATCH.
+	other := generateTestEPC(time.Unix(1_700_000_100, 0))
+	if got := inferVerify(other, "", expected); got != "MISMATCH" {
+		t.Fatalf("other valid tag: %s", got)
+	}
+}
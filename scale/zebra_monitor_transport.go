@@ -21,6 +21,7 @@ func sendRawRetry(device string, payload []byte, retries int, delay time.Duratio
 		if !isBusyLikeError(err) {
 			return err
 		}
+		metricZebraBusy.Inc()
 		time.Sleep(delay)
 	}
 	if lastErr == nil {
@@ -44,6 +45,7 @@ func sendSGDRetry(device, command string, retries int, delay time.Duration) erro
 		if !isBusyLikeError(err) {
 			return err
 		}
+		metricZebraBusy.Inc()
 		time.Sleep(delay)
 	}
 	if lastErr == nil {
@@ -1,12 +1,10 @@
 package main
 
 import (
-	"regexp"
 	"sync"
 	"time"
 )
 
-var zebraHexOnlyRegex = regexp.MustCompile(`^[0-9A-F]+$`)
 var zebraIOMutex sync.Mutex
 
 type ZebraStatus struct {
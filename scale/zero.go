@@ -0,0 +1,70 @@
+package main
+
+import corepkg "core"
+
+// zeroGuard zero-tracking va policy. nil = o'chirilgan (--zero-policy off).
+type zeroGuard struct {
+	tracker *corepkg.ZeroTracker
+	policy  string
+	last    corepkg.ZeroAlarm
+}
+
+func newZeroGuard(cfg appConfig) *zeroGuard {
+	if cfg.zeroPolicy == corepkg.ZeroPolicyOff {
+		return nil
+	}
+	return &zeroGuard{tracker: corepkg.NewZeroTracker(cfg.zeroCfg), policy: cfg.zeroPolicy}
+}
+
+// apply brutto vaznni tracker'ga beradi va holatni o'qishga yozadi (bridge snapshot va
+// bot shu maydonlarni ko'radi). ZeroBlock faqat block policy'da alarm bo'lsa.
+// Netto emas: tara qo'yilgan bo'sh idish netto nol bo'lsa ham platforma bo'sh emas,
+// preset tara bilan bo'sh platforma esa netto manfiy - nol siljishi faqat brutto'da ko'rinadi.
+func (g *zeroGuard) apply(rd Reading) Reading {
+	rd.ZeroOffset, rd.ZeroAlarm, rd.ZeroBlock = nil, "", false
+	if g == nil {
+		return rd
+	}
+	st := g.tracker.Observe(rd.Weight, rd.Stable, rd.UpdatedAt)
+	if st.Alarm != g.last {
+		g.last = st.Alarm
+		if st.OK() {
+			workerLog("worker.zero").Printf("zero ok: offset=%+.4f", st.Offset)
+		} else {
+			workerLog("worker.zero").Printf("zero alarm: %s policy=%s", st.String(), g.policy)
+		}
+	}
+	metricZeroAlarm.Set(boolGauge(!st.OK()))
+	if st.Known {
+		v := st.Offset
+		rd.ZeroOffset = &v
+	}
+	if !st.OK() {
+		rd.ZeroAlarm = st.String()
+		rd.ZeroBlock = g.policy == corepkg.ZeroPolicyBlock
+	}
+	return rd
+}
+
+// reset tarozi qayta nollanganda.
+func (g *zeroGuard) reset() {
+	if g == nil {
+		return
+	}
+	g.tracker.Reset()
+}
+
+// zeroText TUI `ZERO` qatori: `ok +0.001`, `DRIFT +0.012 (limit 0.005) [block: ...]`, `-` yoki `off`.
+func zeroText(g *zeroGuard, rd Reading) string {
+	if g == nil {
+		return "off"
+	}
+	if rd.ZeroAlarm != "" {
+		s := rd.ZeroAlarm
+		if rd.ZeroBlock {
+			s += " [block: qayta nollang]"
+		}
+		return s
+	}
+	return g.tracker.Status().String()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	corepkg "core"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestZeroGuard_BlockPolicy(t *testing.T) {
+	cfg := appConfig{zeroPolicy: corepkg.ZeroPolicyBlock, zeroCfg: corepkg.ZeroTrackerConfig{SettleFor: 300 * time.Millisecond}}
+	g := newZeroGuard(cfg)
+	at := time.Unix(1_700_000_000, 0)
+	yes := true
+	read := func(w float64) Reading {
+		at = at.Add(200 * time.Millisecond)
+		return g.apply(Reading{Weight: &w, Stable: &yes, UpdatedAt: at})
+	}
+
+	read(1.25)
+	if rd := read(2.40); !rd.ZeroBlock || !strings.HasPrefix(rd.ZeroAlarm, "NO RETURN") {
+		t.Fatalf("no return should block: %+v", rd)
+	}
+	if rd := read(0); rd.ZeroBlock || rd.ZeroAlarm != "" {
+		t.Fatalf("return to zero should unblock: %+v", rd)
+	}
+
+	// Preset tara bilan bo'sh platforma: netto -0.5, lekin brutto nolda - alarm yo'q.
+	empty, tare, negNet := 0.001, 0.5, -0.499
+	at = at.Add(time.Second)
+	rd := g.apply(Reading{Weight: &empty, Tare: &tare, Net: &negNet, Stable: &yes, UpdatedAt: at})
+	if rd.ZeroBlock || rd.ZeroAlarm != "" || rd.ZeroOffset == nil {
+		t.Fatalf("preset tare, empty platform: %+v", rd)
+	}
+	offset := *rd.ZeroOffset
+
+	// Tara qilingan bo'sh idish: netto nol, lekin platforma bo'sh emas - offset o'zgarmaydi.
+	gross, net := 0.5, 0.0
+	at = at.Add(time.Second)
+	rd = g.apply(Reading{Weight: &gross, Tare: &tare, Net: &net, Stable: &yes, UpdatedAt: at})
+	if rd.ZeroBlock || rd.ZeroOffset == nil || *rd.ZeroOffset != offset {
+		t.Fatalf("tared container must not move zero: %+v", rd)
+	}
+
+	g.reset()
+	if got := zeroText(g, Reading{}); got != "-" {
+		t.Fatalf("after reset: %q", got)
+	}
+}
+
+func TestZeroGuard_WarnAndOff(t *testing.T) {
+	g := newZeroGuard(appConfig{zeroPolicy: corepkg.ZeroPolicyWarn, zeroCfg: corepkg.ZeroTrackerConfig{SettleFor: time.Millisecond}})
+	at := time.Unix(1_700_000_000, 0)
+	w := -0.2
+	var rd Reading
+	for i := 0; i < 2; i++ {
+		at = at.Add(10 * time.Millisecond)
+		rd = g.apply(Reading{Weight: &w, UpdatedAt: at})
+	}
+	if rd.ZeroBlock || !strings.HasPrefix(rd.ZeroAlarm, "DRIFT") {
+		t.Fatalf("warn policy must alarm without blocking: %+v", rd)
+	}
+
+	off := newZeroGuard(appConfig{zeroPolicy: corepkg.ZeroPolicyOff})
+	rd.ZeroBlock = true
+	if got := off.apply(rd); got.ZeroBlock || got.ZeroAlarm != "" || got.ZeroOffset != nil {
+		t.Fatalf("off policy: %+v", got)
+	}
+	if zeroText(off, rd) != "off" {
+		t.Fatal("off text")
+	}
+}
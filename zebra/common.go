@@ -1,6 +1,9 @@
 package main
 
-import "strings"
+import (
+	corepkg "core"
+	"strings"
+)
 
 func safeStr(v, fallback string) string {
 	v = strings.TrimSpace(v)
@@ -10,6 +13,8 @@ func safeStr(v, fallback string) string {
 	return v
 }
 
+// inferVerify readback qatorlaridan verify holatini chiqaradi. Kutilgan EPC topilmasa
+// va o'qilgan hex core validatsiyasidan o'tmasa CORRUPT (tag noto'g'ri o'qilgan).
 func inferVerify(line1, line2, expected string) string {
 	line1 = strings.TrimSpace(strings.Trim(line1, "\""))
 	line2 = strings.TrimSpace(strings.Trim(line2, "\""))
@@ -25,6 +30,9 @@ func inferVerify(line1, line2, expected string) string {
 		if strings.Contains(all, expected) {
 			return "MATCH"
 		}
+		if corepkg.ReadbackEPCCorrupt(all, expected) {
+			return "CORRUPT"
+		}
 		return "MISMATCH"
 	}
 	return "OK"
@@ -1,3 +1,7 @@
 module zebra
 
 go 1.25
+
+require core v0.0.0
+
+replace core => ../core
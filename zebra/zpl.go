@@ -1,15 +1,12 @@
 package main
 
 import (
-	"errors"
+	corepkg "core"
 	"fmt"
-	"regexp"
 	"strings"
 	"time"
 )
 
-var hexOnlyRegex = regexp.MustCompile(`^[0-9A-F]+$`)
-
 func BuildPrintTestCommandStream(message string, copies int) string {
 	return BuildTestLabelZPL(message, copies)
 }
@@ -86,25 +83,9 @@ func BuildCalibrationCommands(save bool) []string {
 	return cmds
 }
 
+// NormalizeEPC core.NormalizeEPC: hex, 16-bit word (22 hex = kesilgan PC word) va 8..64 uzunlik.
 func NormalizeEPC(epc string) (string, error) {
-	v := strings.ToUpper(strings.TrimSpace(epc))
-	v = strings.TrimPrefix(v, "0X")
-	v = strings.ReplaceAll(v, " ", "")
-	v = strings.ReplaceAll(v, "-", "")
-
-	if v == "" {
-		return "", errors.New("epc bo'sh")
-	}
-	if !hexOnlyRegex.MatchString(v) {
-		return "", errors.New("epc faqat hex bo'lishi kerak")
-	}
-	if len(v)%2 != 0 {
-		return "", errors.New("epc uzunligi juft bo'lishi kerak")
-	}
-	if len(v) < 8 || len(v) > 64 {
-		return "", errors.New("epc uzunligi 8..64 hex oralig'ida bo'lsin")
-	}
-	return v, nil
+	return corepkg.NormalizeEPC(epc)
 }
 
 func sanitizeZPLText(v string) string {